@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"shoop-golang/config"
 	"shoop-golang/database"
 	"shoop-golang/database/seeders"
 	adminHandlers "shoop-golang/internal/handlers/admin"
 	"shoop-golang/internal/middleware"
+	"shoop-golang/internal/models"
+	"shoop-golang/internal/notifications"
+	"shoop-golang/internal/recommendations"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/mail"
+	"shoop-golang/pkg/payments"
 	"shoop-golang/pkg/session"
 	"shoop-golang/pkg/utils"
 
@@ -20,6 +28,34 @@ func main() {
 	db := database.Init(cfg.DBPath)
 	seeders.Seed(db)
 	session.Init(cfg.SessionSecret)
+	if cfg.LocalesDir != "" {
+		if err := i18n.Load(cfg.LocalesDir); err != nil {
+			log.Fatalf("failed to load translations: %v", err)
+		}
+	}
+	payments.RegisterDefaults(payments.VietQR{
+		BankBIN:       cfg.VietQRBankBIN,
+		BankName:      cfg.VietQRBankName,
+		AccountNumber: cfg.VietQRAccount,
+		AccountName:   cfg.VietQRAccountName,
+		WebhookSecret: cfg.PaymentWebhookSecret,
+		OrderPrefix:   models.OrderNumberPrefix,
+	})
+	if cfg.SMTPHost != "" {
+		mail.SetSender(mail.SMTP{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		})
+	}
+	// Product changes made here queue customer emails; deliver them in
+	// the background.
+	go notifications.Run(context.Background(), db, cfg.BaseURL, time.Minute)
+	// Related products on the storefront come from the order history,
+	// recomputed hourly.
+	go recommendations.Run(context.Background(), db, time.Hour)
 
 	e := echo.New()
 	e.Renderer = utils.NewAdminRenderer("templates")
@@ -27,6 +63,7 @@ func main() {
 	e.Use(echoMw.Logger())
 	e.Use(echoMw.Recover())
 	e.Use(echoMw.GzipWithConfig(echoMw.GzipConfig{Level: 5}))
+	e.Use(middleware.AdminLocale)
 
 	e.Static("/static", "static")
 	e.Static("/uploads", "uploads")
@@ -34,6 +71,7 @@ func main() {
 	e.GET("/login", adminHandlers.LoginPage)
 	e.POST("/login", adminHandlers.Login)
 	e.GET("/logout", adminHandlers.Logout)
+	e.GET("/lang/:code", adminHandlers.SetLanguage)
 
 	admin := e.Group("", middleware.AdminAuth)
 
@@ -49,7 +87,17 @@ func main() {
 	admin.POST("/categories/:id", adminHandlers.CategoryUpdate)
 	admin.POST("/categories/:id/delete", adminHandlers.CategoryDelete)
 
+	admin.GET("/attributes", adminHandlers.AttributeList)
+	admin.GET("/attributes/create", adminHandlers.AttributeCreate)
+	admin.POST("/attributes", adminHandlers.AttributeStore)
+	admin.GET("/attributes/:id/edit", adminHandlers.AttributeEdit)
+	admin.POST("/attributes/:id", adminHandlers.AttributeUpdate)
+	admin.POST("/attributes/:id/delete", adminHandlers.AttributeDelete)
+	admin.POST("/tags/:id/delete", adminHandlers.TagDelete)
+
 	admin.GET("/products", adminHandlers.ProductList)
+	admin.GET("/products/export", adminHandlers.ProductExport)
+	admin.POST("/products/bulk", adminHandlers.ProductBulk)
 	admin.GET("/products/create", adminHandlers.ProductCreate)
 	admin.POST("/products", adminHandlers.ProductStore)
 	admin.GET("/products/:id/edit", adminHandlers.ProductEdit)
@@ -58,8 +106,24 @@ func main() {
 	admin.POST("/images/:id/delete", adminHandlers.ImageDelete)
 
 	admin.GET("/orders", adminHandlers.OrderList)
+	admin.GET("/orders/export", adminHandlers.OrderExport)
+	admin.POST("/orders/documents", adminHandlers.OrderDocumentsBatch)
 	admin.GET("/orders/:id", adminHandlers.OrderDetail)
 	admin.POST("/orders/:id/status", adminHandlers.OrderUpdateStatus)
+	admin.POST("/orders/:id/refund", adminHandlers.OrderRefund)
+	admin.GET("/orders/:id/invoice", adminHandlers.OrderInvoice)
+	admin.GET("/orders/:id/packing-slip", adminHandlers.OrderPackingSlip)
+
+	admin.GET("/reviews", adminHandlers.ReviewList)
+	admin.POST("/reviews/:id/status", adminHandlers.ReviewUpdateStatus)
+	admin.POST("/reviews/:id/reply", adminHandlers.ReviewReply)
+
+	admin.GET("/shipping", adminHandlers.ShippingList)
+	admin.GET("/shipping/create", adminHandlers.ShippingCreate)
+	admin.POST("/shipping", adminHandlers.ShippingStore)
+	admin.GET("/shipping/:id/edit", adminHandlers.ShippingEdit)
+	admin.POST("/shipping/:id", adminHandlers.ShippingUpdate)
+	admin.POST("/shipping/:id/delete", adminHandlers.ShippingDelete)
 
 	admin.GET("/users", adminHandlers.UserList)
 	admin.GET("/users/:id", adminHandlers.UserDetail)
@@ -71,8 +135,19 @@ func main() {
 	admin.POST("/banners/:id", adminHandlers.BannerUpdate)
 	admin.POST("/banners/:id/delete", adminHandlers.BannerDelete)
 
+	admin.GET("/seo", adminHandlers.SEOList)
+	admin.GET("/seo/create", adminHandlers.SEOCreate)
+	admin.GET("/seo/targets", adminHandlers.SEOTargets)
+	admin.POST("/seo", adminHandlers.SEOStore)
+	admin.POST("/seo/robots", adminHandlers.SEORobotsUpdate)
+	admin.GET("/seo/:id/edit", adminHandlers.SEOEdit)
+	admin.POST("/seo/:id", adminHandlers.SEOUpdate)
+	admin.POST("/seo/:id/delete", adminHandlers.SEODelete)
+
 	admin.GET("/company", adminHandlers.CompanyEdit)
 	admin.POST("/company", adminHandlers.CompanyUpdate)
+	admin.GET("/exchange-rates", adminHandlers.ExchangeRateEdit)
+	admin.POST("/exchange-rates", adminHandlers.ExchangeRateUpdate)
 
 	admin.GET("/about", adminHandlers.AboutEdit)
 	admin.POST("/about", adminHandlers.AboutUpdate)
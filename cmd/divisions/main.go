@@ -0,0 +1,141 @@
+// Command divisions converts the ward-level list of administrative units
+// exported from the General Statistics Office (danhmuchanhchinh.gso.gov.vn,
+// saved as UTF-8 CSV) into database/seeders/data/divisions.json:
+//
+//	go run ./cmd/divisions -in danhmuc.csv -out database/seeders/data/divisions.json
+//
+// The export has a row per ward with the columns "Tỉnh Thành Phố", "Mã TP",
+// "Quận Huyện", "Mã QH", "Phường Xã" and "Mã PX"; other columns are ignored.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+type node struct {
+	Code      string  `json:"code"`
+	Name      string  `json:"name"`
+	Districts []*node `json:"districts,omitempty"`
+	Wards     []*node `json:"wards,omitempty"`
+}
+
+var columns = []string{"Tỉnh Thành Phố", "Mã TP", "Quận Huyện", "Mã QH", "Phường Xã", "Mã PX"}
+
+func main() {
+	in := flag.String("in", "", "GSO export as UTF-8 CSV")
+	out := flag.String("out", "database/seeders/data/divisions.json", "JSON file to write")
+	flag.Parse()
+	if *in == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	tree, err := convert(f)
+	if err != nil {
+		log.Fatalf("%s: %v", *in, err)
+	}
+
+	data, err := json.MarshalIndent(tree, "", " ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, append(data, '\n'), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	var districts, wards int
+	for _, p := range tree {
+		districts += len(p.Districts)
+		for _, d := range p.Districts {
+			wards += len(d.Wards)
+		}
+	}
+	log.Printf("Wrote %d provinces, %d districts, %d wards to %s", len(tree), districts, wards, *out)
+}
+
+func convert(r io.Reader) ([]*node, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	index := map[string]int{}
+	for i, h := range header {
+		index[strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))] = i
+	}
+	var at []int
+	for _, col := range columns {
+		i, ok := index[col]
+		if !ok {
+			return nil, fmt.Errorf("missing column %q", col)
+		}
+		at = append(at, i)
+	}
+
+	provinces := map[string]*node{}
+	districts := map[string]*node{}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(i int) string {
+			if at[i] < len(rec) {
+				return strings.TrimSpace(rec[at[i]])
+			}
+			return ""
+		}
+		pCode, dCode, wCode := field(1), field(3), field(5)
+		if pCode == "" {
+			continue
+		}
+		p := provinces[pCode]
+		if p == nil {
+			p = &node{Code: pCode, Name: field(0)}
+			provinces[pCode] = p
+		}
+		if dCode == "" {
+			continue
+		}
+		d := districts[dCode]
+		if d == nil {
+			d = &node{Code: dCode, Name: field(2)}
+			districts[dCode] = d
+			p.Districts = append(p.Districts, d)
+		}
+		if wCode != "" {
+			d.Wards = append(d.Wards, &node{Code: wCode, Name: field(4)})
+		}
+	}
+
+	var tree []*node
+	for _, p := range provinces {
+		tree = append(tree, p)
+		byCode(p.Districts)
+		for _, d := range p.Districts {
+			byCode(d.Wards)
+		}
+	}
+	byCode(tree)
+	return tree, nil
+}
+
+func byCode(nodes []*node) {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Code < nodes[j].Code })
+}
@@ -8,6 +8,9 @@ import (
 	"shoop-golang/database/seeders"
 	webHandlers "shoop-golang/internal/handlers/web"
 	"shoop-golang/internal/middleware"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/payments"
 	"shoop-golang/pkg/session"
 	"shoop-golang/pkg/utils"
 
@@ -20,10 +23,26 @@ func main() {
 	db := database.Init(cfg.DBPath)
 	seeders.Seed(db)
 	session.Init(cfg.SessionSecret)
+	if cfg.LocalesDir != "" {
+		if err := i18n.Load(cfg.LocalesDir); err != nil {
+			log.Fatalf("failed to load translations: %v", err)
+		}
+	}
+	payments.RegisterDefaults(payments.VietQR{
+		BankBIN:       cfg.VietQRBankBIN,
+		BankName:      cfg.VietQRBankName,
+		AccountNumber: cfg.VietQRAccount,
+		AccountName:   cfg.VietQRAccountName,
+		WebhookSecret: cfg.PaymentWebhookSecret,
+		OrderPrefix:   models.OrderNumberPrefix,
+	})
+
+	webHandlers.SetBaseURL(cfg.BaseURL)
 
 	e := echo.New()
 	e.Renderer = utils.NewWebRenderer("templates")
 
+	e.Pre(middleware.WebLocale)
 	e.Use(echoMw.Logger())
 	e.Use(echoMw.Recover())
 	e.Use(echoMw.GzipWithConfig(echoMw.GzipConfig{Level: 5}))
@@ -36,19 +55,61 @@ func main() {
 
 	e.GET("/products", webHandlers.ProductList)
 	e.GET("/products/:slug", webHandlers.ProductDetail)
+	e.POST("/products/:slug/reviews", webHandlers.ReviewStore, middleware.WebAuth)
+	e.POST("/products/:slug/notify", webHandlers.NotifyMeStore)
 
 	e.POST("/register", webHandlers.Register)
 	e.POST("/login", webHandlers.Login)
 	e.GET("/logout", webHandlers.Logout)
 
+	e.GET("/currency/:code", webHandlers.SetCurrency)
+	e.GET("/lang/:code", webHandlers.SetLanguage)
+
 	e.GET("/cart", webHandlers.CartPage)
 	e.POST("/cart/add", webHandlers.AddToCart)
 	e.POST("/cart/update", webHandlers.UpdateCart)
+	e.POST("/wishlist/add", webHandlers.AddToWishlist)
+	e.POST("/wishlist/remove", webHandlers.RemoveFromWishlist)
+	e.POST("/wishlist/move", webHandlers.MoveWishlistToCart)
+	e.GET("/notify/confirm/:token", webHandlers.NotifyConfirmPage)
+	e.POST("/notify/confirm/:token", webHandlers.NotifyConfirm)
+	e.GET("/notify/unsubscribe/:token", webHandlers.NotifyUnsubscribePage)
+	e.POST("/notify/unsubscribe/:token", webHandlers.NotifyUnsubscribe)
+	e.GET("/cart/shipping", webHandlers.ShippingQuote)
 	e.POST("/checkout", webHandlers.Checkout)
 
+	e.POST("/payments/webhook/:provider", webHandlers.PaymentWebhook)
+	pay := e.Group("/payments", middleware.WebAuth)
+	pay.GET("/:number", webHandlers.PaymentPage)
+	pay.GET("/:number/status", webHandlers.PaymentStatus)
+	pay.GET("/:number/qr.png", webHandlers.PaymentQR)
+
+	e.GET("/locations/provinces", webHandlers.ProvinceList)
+	e.GET("/locations/provinces/:code/districts", webHandlers.DistrictList)
+	e.GET("/locations/districts/:code/wards", webHandlers.WardList)
+
+	account := e.Group("/account", middleware.WebAuth)
+	account.GET("/orders", webHandlers.AccountOrderList)
+	account.GET("/orders/:number", webHandlers.AccountOrderDetail)
+	account.GET("/addresses", webHandlers.AddressList)
+	account.POST("/addresses", webHandlers.AddressStore)
+	account.GET("/addresses/:id/edit", webHandlers.AddressEdit)
+	account.POST("/addresses/:id", webHandlers.AddressUpdate)
+	account.POST("/addresses/:id/delete", webHandlers.AddressDelete)
+	account.POST("/addresses/:id/default", webHandlers.AddressSetDefault)
+	account.GET("/wishlist", webHandlers.WishlistPage)
+	account.POST("/element", webHandlers.SaveElementProfile)
+
 	e.GET("/about", webHandlers.AboutPage)
 	e.GET("/contact", webHandlers.ContactPage)
 
+	e.GET("/feeds/google.xml", webHandlers.GoogleMerchantFeed)
+	e.GET("/sitemap.xml", webHandlers.Sitemap)
+	e.GET("/sitemaps/pages.xml", webHandlers.SitemapPages)
+	e.GET("/sitemaps/categories.xml", webHandlers.SitemapCategories)
+	e.GET("/sitemaps/products.xml", webHandlers.SitemapProducts)
+	e.GET("/robots.txt", webHandlers.RobotsTxt)
+
 	log.Printf("Web server starting on :%s", cfg.WebPort)
 	e.Logger.Fatal(e.Start(":" + cfg.WebPort))
 }
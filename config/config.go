@@ -9,6 +9,25 @@ type Config struct {
 	DBPath        string
 	SessionSecret string
 	UploadDir     string
+	LocalesDir    string // optional translations overriding the built-in catalogs
+
+	// VietQR bank transfer; the provider is offered only when an account
+	// is configured.
+	VietQRBankBIN        string
+	VietQRBankName       string
+	VietQRAccount        string
+	VietQRAccountName    string
+	PaymentWebhookSecret string
+
+	// Customer email. Without SMTPHost messages are only logged. BaseURL is
+	// the public storefront address that absolute links (emails, sitemaps,
+	// feeds, canonical and share tags) start with.
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+	BaseURL      string
 }
 
 func Load() *Config {
@@ -19,6 +38,20 @@ func Load() *Config {
 		DBPath:        getEnv("DB_PATH", "data/shoop.db"),
 		SessionSecret: getEnv("SESSION_SECRET", "shoop-secret-key-change-in-production"),
 		UploadDir:     getEnv("UPLOAD_DIR", "uploads"),
+		LocalesDir:    getEnv("LOCALES_DIR", ""),
+
+		VietQRBankBIN:        getEnv("VIETQR_BANK_BIN", ""),
+		VietQRBankName:       getEnv("VIETQR_BANK_NAME", ""),
+		VietQRAccount:        getEnv("VIETQR_ACCOUNT", ""),
+		VietQRAccountName:    getEnv("VIETQR_ACCOUNT_NAME", ""),
+		PaymentWebhookSecret: getEnv("PAYMENT_WEBHOOK_SECRET", ""),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		MailFrom:     getEnv("MAIL_FROM", "no-reply@occ.io.vn"),
+		BaseURL:      getEnv("BASE_URL", "http://localhost:8600"),
 	}
 }
 
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
@@ -28,6 +29,13 @@ func Init(dbPath string) *gorm.DB {
 		log.Fatalf("failed to connect database: %v", err)
 	}
 
+	if err := roundMoneyColumns(DB); err != nil {
+		log.Fatalf("failed to convert amounts to whole đồng: %v", err)
+	}
+	// Orders placed before shipping fees existed consist of their lines only.
+	backfillSubtotals := addsColumn(DB, &models.Order{}, "subtotal")
+	// Subscriptions from before guests had to confirm stay active.
+	confirmSubscriptions := addsColumn(DB, &models.StockSubscription{}, "confirmed_at")
 	if err := DB.AutoMigrate(
 		&models.AdminUser{},
 		&models.User{},
@@ -40,10 +48,119 @@ func Init(dbPath string) *gorm.DB {
 		&models.CompanyInfo{},
 		&models.AboutPage{},
 		&models.SEOBanner{},
+		&models.Invoice{},
+		&models.Sequence{},
+		&models.ShippingMethod{},
+		&models.ShippingRule{},
+		&models.Province{},
+		&models.District{},
+		&models.Ward{},
+		&models.UserAddress{},
+		&models.Payment{},
+		&models.Refund{},
+		&models.RefundItem{},
+		&models.ExchangeRate{},
+		&models.ProductTranslation{},
+		&models.CategoryTranslation{},
+		&models.BannerTranslation{},
+		&models.AboutPageTranslation{},
+		&models.SEOBannerTranslation{},
+		&models.Review{},
+		&models.ReviewPhoto{},
+		&models.Wishlist{},
+		&models.Notification{},
+		&models.StockSubscription{},
+		&models.RobotsConfig{},
+		&models.SlugRedirect{},
+		&models.Attribute{},
+		&models.AttributeValue{},
+		&models.ProductAttribute{},
+		&models.Tag{},
+		&models.ProductAffinity{},
+		&models.RecentView{},
+		&models.ProductView{},
 	); err != nil {
 		log.Fatalf("failed to migrate: %v", err)
 	}
+	if err := backfillOrderNumbers(DB); err != nil {
+		log.Fatalf("failed to backfill order numbers: %v", err)
+	}
+	if confirmSubscriptions {
+		DB.Model(&models.StockSubscription{}).Where("confirmed_at IS NULL").Update("confirmed_at", gorm.Expr("created_at"))
+	}
+	if backfillSubtotals {
+		DB.Model(&models.Order{}).Where("subtotal = 0 AND shipping_fee = 0 AND total_amount > 0").
+			Update("subtotal", gorm.Expr("total_amount"))
+	}
 
 	log.Println("Database initialized and migrated successfully")
 	return DB
 }
+
+// moneyColumns lists the amounts that were REAL columns before they became
+// whole đồng (money.Money).
+var moneyColumns = map[string][]string{
+	"products":       {"original_price", "sale_price"},
+	"orders":         {"subtotal", "shipping_fee", "discount", "total_amount", "refunded_amount"},
+	"order_items":    {"price"},
+	"payments":       {"amount"},
+	"refunds":        {"amount"},
+	"refund_items":   {"amount"},
+	"shipping_rules": {"base_fee", "per_kg_fee", "free_over"},
+}
+
+// roundMoneyColumns rounds amounts still stored as REAL to whole đồng, so
+// AutoMigrate can turn the columns into integers. SQLite keeps a fractional
+// value as REAL even in an INTEGER column, which is why this runs first.
+func roundMoneyColumns(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for table, columns := range moneyColumns {
+			for _, column := range columns {
+				if !tx.Migrator().HasColumn(table, column) {
+					continue
+				}
+				res := tx.Exec(fmt.Sprintf("UPDATE %[1]s SET %[2]s = CAST(ROUND(%[2]s) AS INTEGER) WHERE typeof(%[2]s) = 'real'", table, column))
+				if res.Error != nil {
+					return res.Error
+				}
+				if res.RowsAffected > 0 {
+					log.Printf("Rounded %d amounts in %s.%s to whole đồng", res.RowsAffected, table, column)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// addsColumn reports whether AutoMigrate is about to add column to the
+// existing table of model, so a backfill for it runs only once.
+func addsColumn(db *gorm.DB, model any, column string) bool {
+	return db.Migrator().HasTable(model) && !db.Migrator().HasColumn(model, column)
+}
+
+// backfillOrderNumbers numbers orders created before order numbers existed,
+// oldest first, continuing each year's sequence.
+func backfillOrderNumbers(db *gorm.DB) error {
+	var orders []models.Order
+	if err := db.Unscoped().Where("number IS NULL OR number = ''").Order("created_at ASC").Find(&orders).Error; err != nil {
+		return err
+	}
+	for _, o := range orders {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			year := o.CreatedAt.Year()
+			n, err := models.NextSequence(tx, fmt.Sprintf("order-%d", year))
+			if err != nil {
+				return err
+			}
+			return tx.Unscoped().Model(&models.Order{}).Where("id = ?", o.ID).
+				Update("number", models.FormatOrderNumber(year, n)).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(orders) > 0 {
+		log.Printf("Assigned order numbers to %d existing orders", len(orders))
+	}
+	return nil
+}
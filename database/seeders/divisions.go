@@ -0,0 +1,68 @@
+package seeders
+
+import (
+	_ "embed"
+	"encoding/json"
+	"log"
+
+	"shoop-golang/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// divisionsJSON is the province → district → ward tree, keyed by the
+// official GSO codes. It is upserted by code on every seed, so a corrected
+// file reaches existing databases too.
+//
+//go:embed data/divisions.json
+var divisionsJSON []byte
+
+type divisionNode struct {
+	Code      string         `json:"code"`
+	Name      string         `json:"name"`
+	Districts []divisionNode `json:"districts,omitempty"`
+	Wards     []divisionNode `json:"wards,omitempty"`
+}
+
+func seedDivisions(db *gorm.DB) {
+	var tree []divisionNode
+	if err := json.Unmarshal(divisionsJSON, &tree); err != nil {
+		log.Printf("Invalid divisions dataset: %v", err)
+		return
+	}
+
+	var provinces []models.Province
+	var districts []models.District
+	var wards []models.Ward
+	for _, p := range tree {
+		provinces = append(provinces, models.Province{Code: p.Code, Name: p.Name})
+		for _, d := range p.Districts {
+			districts = append(districts, models.District{Code: d.Code, ProvinceCode: p.Code, Name: d.Name})
+			for _, w := range d.Wards {
+				wards = append(wards, models.Ward{Code: w.Code, DistrictCode: d.Code, Name: w.Name})
+			}
+		}
+	}
+
+	upsert := clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, UpdateAll: true}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(upsert).CreateInBatches(provinces, 200).Error; err != nil {
+			return err
+		}
+		if len(districts) > 0 {
+			if err := tx.Clauses(upsert).CreateInBatches(districts, 200).Error; err != nil {
+				return err
+			}
+		}
+		if len(wards) > 0 {
+			return tx.Clauses(upsert).CreateInBatches(wards, 200).Error
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to seed administrative divisions: %v", err)
+		return
+	}
+	log.Printf("Seeded %d provinces, %d districts, %d wards", len(provinces), len(districts), len(wards))
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 
 	"shoop-golang/internal/models"
+	"shoop-golang/pkg/fengshui"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -16,7 +17,11 @@ func Seed(db *gorm.DB) {
 	seedCategories(db)
 	seedProducts(db)
 	seedProductImages(db)
+	seedAttributes(db)
 	seedBanners(db)
+	seedDivisions(db)
+	seedShippingMethods(db)
+	seedExchangeRates(db)
 	log.Println("Seeding completed")
 }
 
@@ -99,16 +104,16 @@ func seedProducts(db *gorm.DB) {
 	}
 
 	products := []models.Product{
-		{Name: "Tượng Phật Di Lặc Ngọc Bích", Slug: "tuong-phat-di-lac-ngoc-bich", Description: "Tượng Phật Di Lặc bằng ngọc bích tự nhiên, mang lại may mắn và tài lộc", OriginalPrice: 2500000, SalePrice: 1990000, SKU: "TPT-001", Stock: 15, CategoryID: categories[0].ID, IsActive: true, IsFeatured: true},
-		{Name: "Tượng Tỳ Hưu Vàng", Slug: "tuong-ty-huu-vang", Description: "Tỳ Hưu vàng phong thủy chiêu tài lộc", OriginalPrice: 3200000, SalePrice: 2690000, SKU: "TPT-002", Stock: 10, CategoryID: categories[0].ID, IsActive: true, IsFeatured: true},
-		{Name: "Vòng Tay Thạch Anh Hồng", Slug: "vong-tay-thach-anh-hong", Description: "Vòng tay thạch anh hồng tự nhiên, hợp mệnh Hỏa", OriginalPrice: 850000, SalePrice: 650000, SKU: "VT-001", Stock: 30, CategoryID: categories[1].ID, IsActive: true, IsFeatured: true},
-		{Name: "Vòng Tay Mắt Hổ", Slug: "vong-tay-mat-ho", Description: "Vòng tay đá mắt hổ mang lại sức mạnh và bảo vệ", OriginalPrice: 750000, SalePrice: 590000, SKU: "VT-002", Stock: 25, CategoryID: categories[1].ID, IsActive: true, IsFeatured: false},
-		{Name: "Thạch Anh Tím Tự Nhiên", Slug: "thach-anh-tim-tu-nhien", Description: "Khối thạch anh tím tự nhiên, thanh lọc năng lượng", OriginalPrice: 4500000, SalePrice: 3800000, SKU: "DA-001", Stock: 5, CategoryID: categories[2].ID, IsActive: true, IsFeatured: true},
-		{Name: "Đá Fluorite Cầu Vồng", Slug: "da-fluorite-cau-vong", Description: "Đá Fluorite nhiều màu sắc, tăng cường trí tuệ", OriginalPrice: 1200000, SalePrice: 980000, SKU: "DA-002", Stock: 12, CategoryID: categories[2].ID, IsActive: true, IsFeatured: false},
-		{Name: "Cây Kim Tiền Phong Thủy", Slug: "cay-kim-tien-phong-thuy", Description: "Cây kim tiền mang lại tài lộc cho gia chủ", OriginalPrice: 500000, SalePrice: 420000, SKU: "CT-001", Stock: 20, CategoryID: categories[3].ID, IsActive: true, IsFeatured: true},
-		{Name: "Cây Lưỡi Hổ", Slug: "cay-luoi-ho", Description: "Cây lưỡi hổ thanh lọc không khí, hút tài lộc", OriginalPrice: 350000, SalePrice: 0, SKU: "CT-002", Stock: 18, CategoryID: categories[3].ID, IsActive: true, IsFeatured: false},
-		{Name: "Tranh Mã Đáo Thành Công", Slug: "tranh-ma-dao-thanh-cong", Description: "Tranh ngựa phong thủy mang lại thành công", OriginalPrice: 1800000, SalePrice: 1500000, SKU: "TR-001", Stock: 8, CategoryID: categories[4].ID, IsActive: true, IsFeatured: true},
-		{Name: "Tranh Cửu Ngư Quần Hội", Slug: "tranh-cuu-ngu-quan-hoi", Description: "Tranh 9 con cá phong thủy, biểu tượng thịnh vượng", OriginalPrice: 2200000, SalePrice: 1850000, SKU: "TR-002", Stock: 6, CategoryID: categories[4].ID, IsActive: true, IsFeatured: false},
+		{Name: "Tượng Phật Di Lặc Ngọc Bích", Slug: "tuong-phat-di-lac-ngoc-bich", Description: "Tượng Phật Di Lặc bằng ngọc bích tự nhiên, mang lại may mắn và tài lộc", OriginalPrice: 2500000, SalePrice: 1990000, SKU: "TPT-001", Weight: 1200, Stock: 15, CategoryID: categories[0].ID, IsActive: true, IsFeatured: true},
+		{Name: "Tượng Tỳ Hưu Vàng", Slug: "tuong-ty-huu-vang", Description: "Tỳ Hưu vàng phong thủy chiêu tài lộc", OriginalPrice: 3200000, SalePrice: 2690000, SKU: "TPT-002", Weight: 1500, Stock: 10, CategoryID: categories[0].ID, IsActive: true, IsFeatured: true},
+		{Name: "Vòng Tay Thạch Anh Hồng", Slug: "vong-tay-thach-anh-hong", Description: "Vòng tay thạch anh hồng tự nhiên, hợp mệnh Hỏa", OriginalPrice: 850000, SalePrice: 650000, SKU: "VT-001", Weight: 80, Stock: 30, CategoryID: categories[1].ID, IsActive: true, IsFeatured: true},
+		{Name: "Vòng Tay Mắt Hổ", Slug: "vong-tay-mat-ho", Description: "Vòng tay đá mắt hổ mang lại sức mạnh và bảo vệ", OriginalPrice: 750000, SalePrice: 590000, SKU: "VT-002", Weight: 80, Stock: 25, CategoryID: categories[1].ID, IsActive: true, IsFeatured: false},
+		{Name: "Thạch Anh Tím Tự Nhiên", Slug: "thach-anh-tim-tu-nhien", Description: "Khối thạch anh tím tự nhiên, thanh lọc năng lượng", OriginalPrice: 4500000, SalePrice: 3800000, SKU: "DA-001", Weight: 2500, Stock: 5, CategoryID: categories[2].ID, IsActive: true, IsFeatured: true},
+		{Name: "Đá Fluorite Cầu Vồng", Slug: "da-fluorite-cau-vong", Description: "Đá Fluorite nhiều màu sắc, tăng cường trí tuệ", OriginalPrice: 1200000, SalePrice: 980000, SKU: "DA-002", Weight: 600, Stock: 12, CategoryID: categories[2].ID, IsActive: true, IsFeatured: false},
+		{Name: "Cây Kim Tiền Phong Thủy", Slug: "cay-kim-tien-phong-thuy", Description: "Cây kim tiền mang lại tài lộc cho gia chủ", OriginalPrice: 500000, SalePrice: 420000, SKU: "CT-001", Weight: 3000, Stock: 20, CategoryID: categories[3].ID, IsActive: true, IsFeatured: true},
+		{Name: "Cây Lưỡi Hổ", Slug: "cay-luoi-ho", Description: "Cây lưỡi hổ thanh lọc không khí, hút tài lộc", OriginalPrice: 350000, SalePrice: 0, SKU: "CT-002", Weight: 2500, Stock: 18, CategoryID: categories[3].ID, IsActive: true, IsFeatured: false},
+		{Name: "Tranh Mã Đáo Thành Công", Slug: "tranh-ma-dao-thanh-cong", Description: "Tranh ngựa phong thủy mang lại thành công", OriginalPrice: 1800000, SalePrice: 1500000, SKU: "TR-001", Weight: 1800, Stock: 8, CategoryID: categories[4].ID, IsActive: true, IsFeatured: true},
+		{Name: "Tranh Cửu Ngư Quần Hội", Slug: "tranh-cuu-ngu-quan-hoi", Description: "Tranh 9 con cá phong thủy, biểu tượng thịnh vượng", OriginalPrice: 2200000, SalePrice: 1850000, SKU: "TR-002", Weight: 2000, Stock: 6, CategoryID: categories[4].ID, IsActive: true, IsFeatured: false},
 	}
 	db.Create(&products)
 }
@@ -183,3 +188,78 @@ func seedBanners(db *gorm.DB) {
 	}
 	db.Create(&banners)
 }
+
+func seedShippingMethods(db *gorm.DB) {
+	var count int64
+	db.Model(&models.ShippingMethod{}).Count(&count)
+	if count > 0 {
+		return
+	}
+	methods := []models.ShippingMethod{
+		{
+			Code: "standard", Name: "Giao hàng tiêu chuẩn", Description: "Giao trong 2-5 ngày làm việc", SortOrder: 1, IsActive: true,
+			Rules: []models.ShippingRule{
+				{Province: "Thành phố Hồ Chí Minh", BaseFee: 20000, IncludedWeight: 1000, PerKgFee: 5000, FreeOver: 1000000},
+				{Province: "Thành phố Hà Nội", BaseFee: 30000, IncludedWeight: 1000, PerKgFee: 8000, FreeOver: 1500000},
+				{BaseFee: 35000, IncludedWeight: 1000, PerKgFee: 10000, FreeOver: 2000000},
+			},
+		},
+		{
+			Code: "express", Name: "Giao hàng nhanh", Description: "Giao trong 24 giờ tại TP.HCM và Hà Nội", SortOrder: 2, IsActive: true,
+			Rules: []models.ShippingRule{
+				{Province: "Thành phố Hồ Chí Minh", BaseFee: 40000, IncludedWeight: 1000, PerKgFee: 10000, MaxWeight: 10000},
+				{Province: "Thành phố Hà Nội", BaseFee: 60000, IncludedWeight: 1000, PerKgFee: 15000, MaxWeight: 10000},
+			},
+		},
+		{
+			Code: "pickup", Name: "Nhận tại cửa hàng", Description: "123 Nguyễn Huệ, Quận 1, TP.HCM", SortOrder: 3, IsActive: true,
+			Rules: []models.ShippingRule{
+				{Province: "Thành phố Hồ Chí Minh"},
+			},
+		},
+	}
+	db.Create(&methods)
+}
+
+func seedAttributes(db *gorm.DB) {
+	var count int64
+	db.Model(&models.Attribute{}).Count(&count)
+	if count > 0 {
+		return
+	}
+	attributes := []models.Attribute{
+		{
+			Name: "Ngũ hành", Code: models.ElementAttribute, Type: models.AttributeMulti, Filterable: true, SortOrder: 1,
+			Values: []models.AttributeValue{
+				{Name: "Kim", Slug: fengshui.Kim, SortOrder: 0},
+				{Name: "Mộc", Slug: fengshui.Moc, SortOrder: 1},
+				{Name: "Thủy", Slug: fengshui.Thuy, SortOrder: 2},
+				{Name: "Hỏa", Slug: fengshui.Hoa, SortOrder: 3},
+				{Name: "Thổ", Slug: fengshui.Tho, SortOrder: 4},
+			},
+		},
+		{
+			Name: "Chất liệu", Code: "material", Type: models.AttributeSelect, Filterable: true, SortOrder: 2,
+			Values: []models.AttributeValue{
+				{Name: "Thạch anh", Slug: "thach-anh", SortOrder: 0},
+				{Name: "Đồng", Slug: "dong", SortOrder: 1},
+				{Name: "Gỗ", Slug: "go", SortOrder: 2},
+				{Name: "Gốm sứ", Slug: "gom-su", SortOrder: 3},
+			},
+		},
+	}
+	db.Create(&attributes)
+}
+
+func seedExchangeRates(db *gorm.DB) {
+	var count int64
+	db.Model(&models.ExchangeRate{}).Count(&count)
+	if count > 0 {
+		return
+	}
+	rates := []models.ExchangeRate{
+		{Currency: "USD", Rate: 25400, IsActive: true},
+		{Currency: "EUR", Rate: 27500, IsActive: true},
+	}
+	db.Create(&rates)
+}
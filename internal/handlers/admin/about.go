@@ -5,18 +5,20 @@ import (
 
 	"shoop-golang/database"
 	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
 	"shoop-golang/pkg/session"
 
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 func AboutEdit(c echo.Context) error {
 	data := adminData(c)
-	data["Title"] = "Trang Giới thiệu"
+	data["Title"] = i18n.T(c, "admin.page.about")
 	data["Active"] = "about"
 
 	var about models.AboutPage
-	database.DB.First(&about)
+	database.DB.Preload("Translations").First(&about)
 	data["About"] = about
 
 	return c.Render(http.StatusOK, "admin/about/index", data)
@@ -29,9 +31,18 @@ func AboutUpdate(c echo.Context) error {
 	about.Title = c.FormValue("title")
 	about.Content = c.FormValue("content")
 
-	database.DB.Save(&about)
-
 	sess := session.GetAdminSession(c)
-	session.SetFlash(c, sess, session.FlashSuccess, "Đã cập nhật trang giới thiệu")
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&about).Error; err != nil {
+			return err
+		}
+		return replaceTranslations(tx, "about_page_id", about.ID, aboutTranslations(c, about))
+	})
+	if err != nil {
+		session.SetFlash(c, sess, session.FlashError, i18n.T(c, "admin.about.update_failed", err.Error()))
+		return c.Redirect(http.StatusFound, "/about")
+	}
+
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.about.updated"))
 	return c.Redirect(http.StatusFound, "/about")
 }
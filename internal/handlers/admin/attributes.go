@@ -0,0 +1,313 @@
+package admin
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/session"
+	"shoop-golang/pkg/utils"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// reservedAttributeCodes are query parameters the product list already
+// uses, so no attribute filter can take them.
+var reservedAttributeCodes = []string{"category", "q", "page", "sort", "tag", "birth_year", "gender"}
+
+func AttributeList(c echo.Context) error {
+	data := adminData(c)
+	data["Title"] = i18n.T(c, "admin.page.attributes")
+	data["Active"] = "attributes"
+
+	var attributes []models.Attribute
+	database.DB.Preload("Values", orderValues).Preload("Categories").Order("sort_order ASC, name ASC").Find(&attributes)
+	data["Attributes"] = attributes
+
+	var tags []struct {
+		models.Tag
+		Products int64
+	}
+	database.DB.Model(&models.Tag{}).
+		Select("tags.*, (SELECT COUNT(*) FROM product_tags WHERE product_tags.tag_id = tags.id) AS products").
+		Order("name ASC").Scan(&tags)
+	data["Tags"] = tags
+
+	return c.Render(http.StatusOK, "admin/attributes/index", data)
+}
+
+func AttributeCreate(c echo.Context) error {
+	attr := models.Attribute{Type: models.AttributeSelect, Filterable: true, Values: []models.AttributeValue{{}}}
+	return renderAttributeForm(c, attr, false, "")
+}
+
+func AttributeStore(c echo.Context) error {
+	var attr models.Attribute
+	bindAttribute(c, &attr)
+	values := parseAttributeValues(c)
+
+	if msg := validateAttribute(attr); msg != "" {
+		attr.Values = values
+		return renderAttributeForm(c, attr, false, i18n.T(c, msg))
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		categories := attr.Categories
+		attr.Categories = nil
+		// Create swaps a false Filterable for the column default.
+		filterable := attr.Filterable
+		if err := tx.Create(&attr).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&attr).Update("filterable", filterable).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&attr).Association("Categories").Replace(categories); err != nil {
+			return err
+		}
+		return saveAttributeValues(tx, attr, values)
+	})
+	if err != nil {
+		attr.Values = values
+		return renderAttributeForm(c, attr, false, i18n.T(c, "admin.attribute.save_failed", err.Error()))
+	}
+
+	sess := session.GetAdminSession(c)
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.attribute.saved"))
+	return c.Redirect(http.StatusFound, "/attributes")
+}
+
+func AttributeEdit(c echo.Context) error {
+	var attr models.Attribute
+	if err := database.DB.Preload("Values", orderValues).Preload("Categories").First(&attr, "id = ?", c.Param("id")).Error; err != nil {
+		return c.Redirect(http.StatusFound, "/attributes")
+	}
+	return renderAttributeForm(c, attr, true, "")
+}
+
+func AttributeUpdate(c echo.Context) error {
+	var attr models.Attribute
+	if err := database.DB.First(&attr, "id = ?", c.Param("id")).Error; err != nil {
+		return c.Redirect(http.StatusFound, "/attributes")
+	}
+	bindAttribute(c, &attr)
+	values := parseAttributeValues(c)
+
+	if msg := validateAttribute(attr); msg != "" {
+		attr.Values = values
+		return renderAttributeForm(c, attr, true, i18n.T(c, msg))
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		categories := attr.Categories
+		attr.Categories = nil
+		if err := tx.Save(&attr).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&attr).Association("Categories").Replace(categories); err != nil {
+			return err
+		}
+		return saveAttributeValues(tx, attr, values)
+	})
+	if err != nil {
+		attr.Values = values
+		return renderAttributeForm(c, attr, true, i18n.T(c, "admin.attribute.save_failed", err.Error()))
+	}
+
+	sess := session.GetAdminSession(c)
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.attribute.saved"))
+	return c.Redirect(http.StatusFound, "/attributes")
+}
+
+// AttributeDelete removes an attribute with its values and what products
+// had of it.
+func AttributeDelete(c echo.Context) error {
+	id := c.Param("id")
+	database.DB.Transaction(func(tx *gorm.DB) error {
+		tx.Unscoped().Where("attribute_id = ?", id).Delete(&models.ProductAttribute{})
+		tx.Unscoped().Where("attribute_id = ?", id).Delete(&models.AttributeValue{})
+		tx.Exec("DELETE FROM category_attributes WHERE attribute_id = ?", id)
+		return tx.Unscoped().Where("id = ?", id).Delete(&models.Attribute{}).Error
+	})
+	sess := session.GetAdminSession(c)
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.attribute.deleted"))
+	return c.Redirect(http.StatusFound, "/attributes")
+}
+
+// TagDelete removes a tag from every product.
+func TagDelete(c echo.Context) error {
+	id := c.Param("id")
+	database.DB.Transaction(func(tx *gorm.DB) error {
+		tx.Exec("DELETE FROM product_tags WHERE tag_id = ?", id)
+		return tx.Unscoped().Where("id = ?", id).Delete(&models.Tag{}).Error
+	})
+	sess := session.GetAdminSession(c)
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.tag.deleted"))
+	return c.Redirect(http.StatusFound, "/attributes#tags")
+}
+
+func renderAttributeForm(c echo.Context, attr models.Attribute, isEdit bool, errMsg string) error {
+	data := adminData(c)
+	data["Title"] = i18n.T(c, "admin.page.attribute_create")
+	if isEdit {
+		data["Title"] = i18n.T(c, "admin.page.attribute_edit")
+		data["IsEdit"] = true
+	}
+	data["Active"] = "attributes"
+	data["Attribute"] = attr
+	data["Types"] = models.AttributeTypes
+
+	assigned := map[string]bool{}
+	for _, cat := range attr.Categories {
+		assigned[cat.ID] = true
+	}
+	data["CategoryOptions"] = categoryOptions(assigned)
+	if errMsg != "" {
+		data["Error"] = errMsg
+	}
+	return c.Render(http.StatusOK, "admin/attributes/form", data)
+}
+
+func bindAttribute(c echo.Context, a *models.Attribute) {
+	sortOrder, _ := strconv.Atoi(c.FormValue("sort_order"))
+	a.Name = strings.TrimSpace(c.FormValue("name"))
+	a.Code = utils.Slugify(c.FormValue("code"))
+	if a.Code == "" {
+		a.Code = utils.Slugify(a.Name)
+	}
+	a.Type = c.FormValue("type")
+	a.Unit = strings.TrimSpace(c.FormValue("unit"))
+	a.Filterable = c.FormValue("filterable") == "on"
+	a.SortOrder = sortOrder
+
+	form, _ := c.FormParams()
+	a.Categories = nil
+	for _, id := range form["category_ids"] {
+		a.Categories = append(a.Categories, models.Category{BaseModel: models.BaseModel{ID: id}})
+	}
+}
+
+// validateAttribute returns a message key when a cannot be saved.
+func validateAttribute(a models.Attribute) string {
+	if a.Name == "" || a.Code == "" {
+		return "admin.attribute.required"
+	}
+	if !slices.Contains(models.AttributeTypes, a.Type) {
+		return "admin.attribute.invalid_type"
+	}
+	if slices.Contains(reservedAttributeCodes, a.Code) {
+		return "admin.attribute.code_reserved"
+	}
+	var count int64
+	database.DB.Model(&models.Attribute{}).Where("code = ? AND id <> ?", a.Code, a.ID).Count(&count)
+	if count > 0 {
+		return "admin.attribute.code_taken"
+	}
+	return ""
+}
+
+// parseAttributeValues reads the value rows of the form, submitted as
+// parallel value_id and value_name arrays. Rows without a name are dropped.
+func parseAttributeValues(c echo.Context) []models.AttributeValue {
+	form, _ := c.FormParams()
+	ids := form["value_id"]
+	var values []models.AttributeValue
+	for i, name := range form["value_name"] {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		v := models.AttributeValue{Name: name, SortOrder: len(values)}
+		if i < len(ids) {
+			v.ID = ids[i]
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// saveAttributeValues makes values the allowed values of attr, keeping the
+// IDs of existing ones so products keep them. Removed values are taken off
+// products; text and number attributes have none.
+func saveAttributeValues(tx *gorm.DB, attr models.Attribute, values []models.AttributeValue) error {
+	if !attr.HasValues() {
+		values = nil
+	}
+	var existing []models.AttributeValue
+	tx.Where("attribute_id = ?", attr.ID).Find(&existing)
+	known := map[string]bool{}
+	for _, v := range existing {
+		known[v.ID] = true
+	}
+
+	kept := []string{}
+	slugs := map[string]bool{}
+	for i := range values {
+		v := &values[i]
+		v.AttributeID = attr.ID
+		if !known[v.ID] {
+			v.ID = ""
+		}
+		// Slugs are unique per attribute.
+		base := utils.Slugify(v.Name)
+		if base == "" {
+			base = "value"
+		}
+		v.Slug = base
+		for n := 2; slugs[v.Slug]; n++ {
+			v.Slug = base + "-" + strconv.Itoa(n)
+		}
+		slugs[v.Slug] = true
+		if v.ID != "" {
+			kept = append(kept, v.ID)
+		}
+	}
+
+	removed := tx.Unscoped().Where("attribute_id = ?", attr.ID)
+	if len(kept) > 0 {
+		removed = removed.Where("id NOT IN ?", kept)
+	}
+	var gone []string
+	removed.Model(&models.AttributeValue{}).Pluck("id", &gone)
+	if len(gone) > 0 {
+		if err := tx.Unscoped().Where("value_id IN ?", gone).Delete(&models.ProductAttribute{}).Error; err != nil {
+			return err
+		}
+	}
+	// Clear every slug first so values can swap names.
+	if err := tx.Unscoped().Where("attribute_id = ?", attr.ID).Delete(&models.AttributeValue{}).Error; err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return tx.Create(&values).Error
+}
+
+// categoryOptions lists every category in tree order, labelled with its
+// path and selected when in selected.
+func categoryOptions(selected map[string]bool) []categoryOption {
+	var all []models.Category
+	database.DB.Select("id", "name", "parent_id", "sort_order").Order("sort_order ASC, name ASC").Find(&all)
+	var options []categoryOption
+	var walk func(level []models.Category, path string)
+	walk = func(level []models.Category, path string) {
+		for _, c := range level {
+			label := c.Name
+			if path != "" {
+				label = path + " › " + c.Name
+			}
+			options = append(options, categoryOption{ID: c.ID, Label: label, Selected: selected[c.ID]})
+			walk(c.Children, label)
+		}
+	}
+	walk(models.CategoryTree(all), "")
+	return options
+}
+
+func orderValues(db *gorm.DB) *gorm.DB {
+	return db.Order("sort_order ASC")
+}
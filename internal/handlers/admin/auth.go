@@ -5,6 +5,7 @@ import (
 
 	"shoop-golang/database"
 	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
 	"shoop-golang/pkg/session"
 
 	"github.com/labstack/echo/v4"
@@ -13,7 +14,7 @@ import (
 
 func LoginPage(c echo.Context) error {
 	return c.Render(http.StatusOK, "admin/login", map[string]any{
-		"Title": "Admin Login",
+		"Title": i18n.T(c, "admin.page.login"),
 	})
 }
 
@@ -24,15 +25,15 @@ func Login(c echo.Context) error {
 	var admin models.AdminUser
 	if err := database.DB.Where("email = ? AND is_active = ?", email, true).First(&admin).Error; err != nil {
 		return c.Render(http.StatusOK, "admin/login", map[string]any{
-			"Title": "Admin Login",
-			"Error": "Email hoặc mật khẩu không đúng",
+			"Title": i18n.T(c, "admin.page.login"),
+			"Error": i18n.T(c, "auth.invalid_credentials"),
 		})
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte(password)); err != nil {
 		return c.Render(http.StatusOK, "admin/login", map[string]any{
-			"Title": "Admin Login",
-			"Error": "Email hoặc mật khẩu không đúng",
+			"Title": i18n.T(c, "admin.page.login"),
+			"Error": i18n.T(c, "auth.invalid_credentials"),
 		})
 	}
 
@@ -10,10 +10,12 @@ import (
 
 	"shoop-golang/database"
 	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
 	"shoop-golang/pkg/session"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 func BannerList(c echo.Context) error {
@@ -21,16 +23,29 @@ func BannerList(c echo.Context) error {
 	data["Title"] = "Banner"
 	data["Active"] = "banners"
 
+	query, pagination := listQuery(c, database.DB.Model(&models.Banner{}), ListOptions{
+		SearchColumns: []string{"title", "subtitle"},
+		SortColumns: map[string]string{
+			"sort_order": "sort_order",
+			"title":      "title",
+			"created_at": "created_at",
+		},
+		DefaultSort: "sort_order",
+		DefaultDir:  "asc",
+		DateColumn:  "created_at",
+	})
+
 	var banners []models.Banner
-	database.DB.Order("sort_order ASC").Find(&banners)
+	query.Find(&banners)
 	data["Banners"] = banners
+	data["Pagination"] = pagination
 
 	return c.Render(http.StatusOK, "admin/banners/index", data)
 }
 
 func BannerCreate(c echo.Context) error {
 	data := adminData(c)
-	data["Title"] = "Thêm Banner"
+	data["Title"] = i18n.T(c, "admin.page.banner_create")
 	data["Active"] = "banners"
 	return c.Render(http.StatusOK, "admin/banners/form", data)
 }
@@ -52,32 +67,38 @@ func BannerStore(c echo.Context) error {
 		banner.Image = urlInput
 	} else {
 		data := adminData(c)
-		data["Title"] = "Thêm Banner"
+		data["Title"] = i18n.T(c, "admin.page.banner_create")
 		data["Active"] = "banners"
-		data["Error"] = "Vui lòng tải hình ảnh hoặc nhập URL hình ảnh"
+		data["Error"] = i18n.T(c, "admin.banner.image_required")
 		return c.Render(http.StatusOK, "admin/banners/form", data)
 	}
 
-	if err := database.DB.Create(&banner).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&banner).Error; err != nil {
+			return err
+		}
+		return replaceTranslations(tx, "banner_id", banner.ID, bannerTranslations(c, banner))
+	})
+	if err != nil {
 		data := adminData(c)
-		data["Title"] = "Thêm Banner"
+		data["Title"] = i18n.T(c, "admin.page.banner_create")
 		data["Active"] = "banners"
-		data["Error"] = "Không thể tạo banner"
+		data["Error"] = i18n.T(c, "admin.banner.create_failed")
 		return c.Render(http.StatusOK, "admin/banners/form", data)
 	}
 
 	sess := session.GetAdminSession(c)
-	session.SetFlash(c, sess, session.FlashSuccess, "Đã tạo banner thành công")
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.banner.created"))
 	return c.Redirect(http.StatusFound, "/banners")
 }
 
 func BannerEdit(c echo.Context) error {
 	data := adminData(c)
-	data["Title"] = "Sửa Banner"
+	data["Title"] = i18n.T(c, "admin.page.banner_edit")
 	data["Active"] = "banners"
 
 	var banner models.Banner
-	if err := database.DB.First(&banner, "id = ?", c.Param("id")).Error; err != nil {
+	if err := database.DB.Preload("Translations").First(&banner, "id = ?", c.Param("id")).Error; err != nil {
 		return c.Redirect(http.StatusFound, "/banners")
 	}
 	data["Banner"] = banner
@@ -106,17 +127,27 @@ func BannerUpdate(c echo.Context) error {
 	}
 	// if neither provided, keep existing banner.Image
 
-	database.DB.Save(&banner)
-
 	sess := session.GetAdminSession(c)
-	session.SetFlash(c, sess, session.FlashSuccess, "Đã cập nhật banner")
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&banner).Error; err != nil {
+			return err
+		}
+		return replaceTranslations(tx, "banner_id", banner.ID, bannerTranslations(c, banner))
+	})
+	if err != nil {
+		session.SetFlash(c, sess, session.FlashError, i18n.T(c, "admin.banner.update_failed", err.Error()))
+		return c.Redirect(http.StatusFound, "/banners/"+banner.ID+"/edit")
+	}
+
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.banner.updated"))
 	return c.Redirect(http.StatusFound, "/banners")
 }
 
 func BannerDelete(c echo.Context) error {
+	database.DB.Unscoped().Where("banner_id = ?", c.Param("id")).Delete(&models.BannerTranslation{})
 	database.DB.Where("id = ?", c.Param("id")).Delete(&models.Banner{})
 	sess := session.GetAdminSession(c)
-	session.SetFlash(c, sess, session.FlashSuccess, "Đã xóa banner")
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.banner.deleted"))
 	return c.Redirect(http.StatusFound, "/banners")
 }
 
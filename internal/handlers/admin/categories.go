@@ -1,33 +1,53 @@
 package admin
 
 import (
+	"errors"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	"shoop-golang/database"
 	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
 	"shoop-golang/pkg/session"
 	"shoop-golang/pkg/utils"
 
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 func CategoryList(c echo.Context) error {
 	data := adminData(c)
-	data["Title"] = "Danh mục"
+	data["Title"] = i18n.T(c, "admin.page.categories")
 	data["Active"] = "categories"
 
+	query, pagination := listQuery(c, database.DB.Model(&models.Category{}), ListOptions{
+		SearchColumns: []string{"name", "slug"},
+		SortColumns: map[string]string{
+			"sort_order": "sort_order",
+			"name":       "name",
+			"created_at": "created_at",
+		},
+		DefaultSort: "sort_order",
+		DefaultDir:  "asc",
+		DateColumn:  "created_at",
+	})
+
 	var categories []models.Category
-	database.DB.Order("sort_order ASC").Find(&categories)
+	query.Find(&categories)
 	data["Categories"] = categories
+	data["Pagination"] = pagination
+	data["Parents"] = categoryParentNames()
 
 	return c.Render(http.StatusOK, "admin/categories/index", data)
 }
 
 func CategoryCreate(c echo.Context) error {
 	data := adminData(c)
-	data["Title"] = "Thêm danh mục"
+	data["Title"] = i18n.T(c, "admin.page.category_create")
 	data["Active"] = "categories"
+	data["ParentOptions"] = categoryParentOptions(models.Category{})
 	return c.Render(http.StatusOK, "admin/categories/form", data)
 }
 
@@ -35,37 +55,57 @@ func CategoryStore(c echo.Context) error {
 	sortOrder, _ := strconv.Atoi(c.FormValue("sort_order"))
 	cat := models.Category{
 		Name:        c.FormValue("name"),
-		Slug:        utils.Slugify(c.FormValue("name")),
+		Slug:        formSlug(c.FormValue("slug"), c.FormValue("name")),
 		Description: c.FormValue("description"),
+		ParentID:    categoryParentID(c),
 		SortOrder:   sortOrder,
 		IsActive:    c.FormValue("is_active") == "on",
 	}
 
-	if err := database.DB.Create(&cat).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := validateCategoryParent(c, tx, cat); err != nil {
+			return err
+		}
+		if err := categorySlugs().settle(tx, "", "", &cat.Slug); err != nil {
+			return err
+		}
+		if err := tx.Create(&cat).Error; err != nil {
+			return err
+		}
+		translations := categoryTranslations(c, cat)
+		if err := settleCategorySlugs(tx, cat, translations); err != nil {
+			return err
+		}
+		return replaceTranslations(tx, "category_id", cat.ID, translations)
+	})
+	if err != nil {
 		data := adminData(c)
-		data["Title"] = "Thêm danh mục"
+		data["Title"] = i18n.T(c, "admin.page.category_create")
 		data["Active"] = "categories"
-		data["Error"] = "Không thể tạo danh mục: " + err.Error()
+		data["Error"] = i18n.T(c, "admin.category.create_failed", err.Error())
+		cat.Translations = categoryTranslations(c, cat)
 		data["Category"] = cat
+		data["ParentOptions"] = categoryParentOptions(cat)
 		return c.Render(http.StatusOK, "admin/categories/form", data)
 	}
 
 	sess := session.GetAdminSession(c)
-	session.SetFlash(c, sess, session.FlashSuccess, "Đã tạo danh mục thành công")
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.category.created"))
 	return c.Redirect(http.StatusFound, "/categories")
 }
 
 func CategoryEdit(c echo.Context) error {
 	data := adminData(c)
-	data["Title"] = "Sửa danh mục"
+	data["Title"] = i18n.T(c, "admin.page.category_edit")
 	data["Active"] = "categories"
 
 	var cat models.Category
-	if err := database.DB.First(&cat, "id = ?", c.Param("id")).Error; err != nil {
+	if err := database.DB.Preload("Translations").First(&cat, "id = ?", c.Param("id")).Error; err != nil {
 		return c.Redirect(http.StatusFound, "/categories")
 	}
 	data["Category"] = cat
 	data["IsEdit"] = true
+	data["ParentOptions"] = categoryParentOptions(cat)
 
 	return c.Render(http.StatusOK, "admin/categories/form", data)
 }
@@ -77,22 +117,156 @@ func CategoryUpdate(c echo.Context) error {
 	}
 
 	sortOrder, _ := strconv.Atoi(c.FormValue("sort_order"))
+	oldSlug := cat.Slug
 	cat.Name = c.FormValue("name")
-	cat.Slug = utils.Slugify(c.FormValue("name"))
+	cat.Slug = utils.Slugify(c.FormValue("slug"))
 	cat.Description = c.FormValue("description")
+	cat.ParentID = categoryParentID(c)
 	cat.SortOrder = sortOrder
 	cat.IsActive = c.FormValue("is_active") == "on"
 
-	database.DB.Save(&cat)
-
 	sess := session.GetAdminSession(c)
-	session.SetFlash(c, sess, session.FlashSuccess, "Đã cập nhật danh mục")
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := validateCategoryParent(c, tx, cat); err != nil {
+			return err
+		}
+		if err := categorySlugs().settle(tx, cat.ID, oldSlug, &cat.Slug); err != nil {
+			return err
+		}
+		if err := tx.Save(&cat).Error; err != nil {
+			return err
+		}
+		translations := categoryTranslations(c, cat)
+		if err := settleCategorySlugs(tx, cat, translations); err != nil {
+			return err
+		}
+		return replaceTranslations(tx, "category_id", cat.ID, translations)
+	})
+	if err != nil {
+		session.SetFlash(c, sess, session.FlashError, i18n.T(c, "admin.category.update_failed", err.Error()))
+		return c.Redirect(http.StatusFound, "/categories/"+cat.ID+"/edit")
+	}
+
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.category.updated"))
 	return c.Redirect(http.StatusFound, "/categories")
 }
 
+// CategoryDelete removes a category and moves its children up to its
+// parent. Its products keep pointing at it, as before.
 func CategoryDelete(c echo.Context) error {
-	database.DB.Where("id = ?", c.Param("id")).Delete(&models.Category{})
 	sess := session.GetAdminSession(c)
-	session.SetFlash(c, sess, session.FlashSuccess, "Đã xóa danh mục")
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var cat models.Category
+		if err := tx.First(&cat, "id = ?", c.Param("id")).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Category{}).Where("parent_id = ?", cat.ID).Update("parent_id", cat.ParentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("category_id = ?", cat.ID).Delete(&models.CategoryTranslation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("kind = ? AND target_id = ?", models.SlugCategory, cat.ID).Delete(&models.SlugRedirect{}).Error; err != nil {
+			return err
+		}
+		if err := deleteSEOBanners(tx, []string{models.SEOCategoryPage(cat.ID)}); err != nil {
+			return err
+		}
+		return tx.Delete(&cat).Error
+	})
+	if err != nil {
+		session.SetFlash(c, sess, session.FlashError, i18n.T(c, "admin.category.delete_failed", err.Error()))
+		return c.Redirect(http.StatusFound, "/categories")
+	}
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.category.deleted"))
 	return c.Redirect(http.StatusFound, "/categories")
 }
+
+func categoryParentID(c echo.Context) *string {
+	if id := c.FormValue("parent_id"); id != "" {
+		return &id
+	}
+	return nil
+}
+
+// validateCategoryParent refuses to put cat under its parent unless the
+// parent exists, is not cat or below it, and cat's subtree still fits
+// within models.MaxCategoryDepth.
+func validateCategoryParent(c echo.Context, tx *gorm.DB, cat models.Category) error {
+	if cat.ParentID == nil {
+		return nil
+	}
+	var all []models.Category
+	tx.Select("id", "parent_id").Find(&all)
+	if !slices.ContainsFunc(all, func(p models.Category) bool { return p.ID == *cat.ParentID }) {
+		return errors.New(i18n.T(c, "admin.category.invalid_parent"))
+	}
+	if cat.ID != "" && slices.Contains(models.CategoryDescendants(all, cat.ID), *cat.ParentID) {
+		return errors.New(i18n.T(c, "admin.category.parent_cycle"))
+	}
+	height := 1
+	if cat.ID != "" {
+		height = models.CategoryHeight(all, cat.ID)
+	}
+	if len(models.CategoryAncestors(all, *cat.ParentID))+1+height > models.MaxCategoryDepth {
+		return errors.New(i18n.T(c, "admin.category.too_deep", models.MaxCategoryDepth))
+	}
+	return nil
+}
+
+// categoryOption is a category offered as a parent, named by its path.
+type categoryOption struct {
+	ID       string
+	Label    string
+	Selected bool
+}
+
+// categoryParentOptions lists the categories cat can be moved under, in
+// tree order.
+func categoryParentOptions(cat models.Category) []categoryOption {
+	var all []models.Category
+	database.DB.Select("id", "name", "parent_id", "sort_order").Order("sort_order ASC, name ASC").Find(&all)
+	excluded := map[string]bool{}
+	height := 1
+	if cat.ID != "" {
+		for _, id := range models.CategoryDescendants(all, cat.ID) {
+			excluded[id] = true
+		}
+		height = models.CategoryHeight(all, cat.ID)
+	}
+
+	var options []categoryOption
+	var walk func(level []models.Category, path string, depth int)
+	walk = func(level []models.Category, path string, depth int) {
+		for _, c := range level {
+			if excluded[c.ID] || depth+height > models.MaxCategoryDepth {
+				continue
+			}
+			label := c.Name
+			if path != "" {
+				label = path + " › " + c.Name
+			}
+			selected := cat.ParentID != nil && *cat.ParentID == c.ID
+			options = append(options, categoryOption{ID: c.ID, Label: label, Selected: selected})
+			walk(c.Children, label, depth+1)
+		}
+	}
+	walk(models.CategoryTree(all), "", 1)
+	return options
+}
+
+// categoryParentNames maps category IDs to the path of their parents, for
+// lists: "Vòng tay › Thạch anh".
+func categoryParentNames() map[string]string {
+	var all []models.Category
+	database.DB.Select("id", "name", "parent_id").Find(&all)
+	names := make(map[string]string, len(all))
+	for _, c := range all {
+		var path []string
+		for _, a := range models.CategoryAncestors(all, c.ID) {
+			path = append(path, a.Name)
+		}
+		names[c.ID] = strings.Join(path, " › ")
+	}
+	return names
+}
@@ -5,6 +5,7 @@ import (
 
 	"shoop-golang/database"
 	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
 	"shoop-golang/pkg/session"
 
 	"github.com/labstack/echo/v4"
@@ -12,7 +13,7 @@ import (
 
 func CompanyEdit(c echo.Context) error {
 	data := adminData(c)
-	data["Title"] = "Thông tin công ty"
+	data["Title"] = i18n.T(c, "admin.page.company")
 	data["Active"] = "company"
 
 	var info models.CompanyInfo
@@ -38,6 +39,6 @@ func CompanyUpdate(c echo.Context) error {
 	database.DB.Save(&info)
 
 	sess := session.GetAdminSession(c)
-	session.SetFlash(c, sess, session.FlashSuccess, "Đã cập nhật thông tin công ty")
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.company.updated"))
 	return c.Redirect(http.StatusFound, "/company")
 }
@@ -5,6 +5,8 @@ import (
 
 	"shoop-golang/database"
 	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/money"
 	"shoop-golang/pkg/session"
 
 	"github.com/labstack/echo/v4"
@@ -13,7 +15,9 @@ import (
 func adminData(c echo.Context) map[string]any {
 	sess := session.GetAdminSession(c)
 	data := map[string]any{
-		"AdminName": sess.Values["admin_name"],
+		"AdminName":         sess.Values["admin_name"],
+		"Locales":           i18n.Locales,
+		"TranslatedLocales": i18n.Translated(),
 	}
 	flashes := session.GetFlash(c, sess, session.FlashSuccess)
 	if len(flashes) > 0 {
@@ -28,7 +32,7 @@ func adminData(c echo.Context) map[string]any {
 
 func Dashboard(c echo.Context) error {
 	data := adminData(c)
-	data["Title"] = "Dashboard"
+	data["Title"] = i18n.T(c, "admin.page.dashboard")
 	data["Active"] = "dashboard"
 
 	var productCount, orderCount, userCount, categoryCount int64
@@ -37,6 +41,13 @@ func Dashboard(c echo.Context) error {
 	database.DB.Model(&models.User{}).Count(&userCount)
 	database.DB.Model(&models.Category{}).Count(&categoryCount)
 
+	// Order totals are already net of refunds; the refunded sum is shown
+	// alongside so the gross figure can be read back.
+	var revenue, refunded money.Money
+	database.DB.Model(&models.Order{}).Where("status <> ?", "cancelled").
+		Select("COALESCE(SUM(total_amount), 0)").Scan(&revenue)
+	database.DB.Model(&models.Refund{}).Select("COALESCE(SUM(amount), 0)").Scan(&refunded)
+
 	var recentOrders []models.Order
 	database.DB.Preload("User").Order("created_at DESC").Limit(5).Find(&recentOrders)
 
@@ -44,6 +55,8 @@ func Dashboard(c echo.Context) error {
 	data["OrderCount"] = orderCount
 	data["UserCount"] = userCount
 	data["CategoryCount"] = categoryCount
+	data["Revenue"] = revenue
+	data["Refunded"] = refunded
 	data["RecentOrders"] = recentOrders
 
 	return c.Render(http.StatusOK, "admin/dashboard/index", data)
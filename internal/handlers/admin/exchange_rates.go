@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"net/http"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/money"
+	"shoop-golang/pkg/session"
+
+	"github.com/labstack/echo/v4"
+)
+
+// exchangeRates returns a rate for every foreign display currency, including
+// ones that were never saved.
+func exchangeRates() []models.ExchangeRate {
+	var saved []models.ExchangeRate
+	database.DB.Find(&saved)
+	byCode := make(map[string]models.ExchangeRate, len(saved))
+	for _, r := range saved {
+		byCode[r.Currency] = r
+	}
+	var rates []models.ExchangeRate
+	for _, code := range money.DisplayCodes {
+		if code == money.Currency {
+			continue
+		}
+		r, ok := byCode[code]
+		if !ok {
+			r = models.ExchangeRate{Currency: code}
+		}
+		rates = append(rates, r)
+	}
+	return rates
+}
+
+func ExchangeRateEdit(c echo.Context) error {
+	data := adminData(c)
+	data["Title"] = i18n.T(c, "admin.page.exchange_rates")
+	data["Active"] = "exchange_rates"
+	data["Rates"] = exchangeRates()
+	return c.Render(http.StatusOK, "admin/exchange_rates/index", data)
+}
+
+// ExchangeRateUpdate saves rate_<code> (đồng per unit) and active_<code> for
+// each display currency. A currency can only be offered with a rate.
+func ExchangeRateUpdate(c echo.Context) error {
+	sess := session.GetAdminSession(c)
+	for _, r := range exchangeRates() {
+		rate, err := money.Parse(c.FormValue("rate_" + r.Currency))
+		if err != nil || rate < 0 {
+			session.SetFlash(c, sess, session.FlashError, i18n.T(c, "admin.rates.invalid", r.Currency))
+			return c.Redirect(http.StatusFound, "/exchange-rates")
+		}
+		r.Rate = rate.Float()
+		r.IsActive = c.FormValue("active_"+r.Currency) != "" && rate > 0
+		database.DB.Save(&r)
+	}
+
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.rates.updated"))
+	return c.Redirect(http.StatusFound, "/exchange-rates")
+}
@@ -0,0 +1,184 @@
+package admin
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/money"
+
+	"github.com/labstack/echo/v4"
+)
+
+// productExportRow is the flattened shape of a product used by the CSV and
+// JSON catalog exports.
+type productExportRow struct {
+	ID            string      `json:"id"`
+	SKU           string      `json:"sku"`
+	Name          string      `json:"name"`
+	Slug          string      `json:"slug"`
+	Category      string      `json:"category"`
+	OriginalPrice money.Money `json:"original_price"`
+	SalePrice     money.Money `json:"sale_price"`
+	Stock         int         `json:"stock"`
+	IsActive      bool        `json:"is_active"`
+	IsFeatured    bool        `json:"is_featured"`
+	ImageURL      string      `json:"image_url"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+var productExportHeader = []string{
+	"id", "sku", "name", "slug", "category", "original_price", "sale_price",
+	"stock", "is_active", "is_featured", "image_url", "created_at", "updated_at",
+}
+
+func (r productExportRow) csvRecord() []string {
+	return []string{
+		r.ID,
+		r.SKU,
+		r.Name,
+		r.Slug,
+		r.Category,
+		strconv.FormatInt(int64(r.OriginalPrice), 10),
+		strconv.FormatInt(int64(r.SalePrice), 10),
+		strconv.Itoa(r.Stock),
+		strconv.FormatBool(r.IsActive),
+		strconv.FormatBool(r.IsFeatured),
+		r.ImageURL,
+		r.CreatedAt.Format(time.RFC3339),
+		r.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// ProductExport downloads the whole catalog as CSV (default) or JSON,
+// selected with ?format=csv|json.
+func ProductExport(c echo.Context) error {
+	var products []models.Product
+	database.DB.Preload("Category").Preload("Images").Order("created_at DESC").Find(&products)
+
+	rows := make([]productExportRow, 0, len(products))
+	for _, p := range products {
+		rows = append(rows, productExportRow{
+			ID:            p.ID,
+			SKU:           p.SKU,
+			Name:          p.Name,
+			Slug:          p.Slug,
+			Category:      p.Category.Name,
+			OriginalPrice: p.OriginalPrice,
+			SalePrice:     p.SalePrice,
+			Stock:         p.Stock,
+			IsActive:      p.IsActive,
+			IsFeatured:    p.IsFeatured,
+			ImageURL:      p.ImageURL(),
+			CreatedAt:     p.CreatedAt,
+			UpdatedAt:     p.UpdatedAt,
+		})
+	}
+
+	filename := "products-" + time.Now().Format("20060102")
+
+	switch c.QueryParam("format") {
+	case "json":
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		return c.JSON(http.StatusOK, rows)
+	case "", "csv":
+		c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.csv"`, filename))
+		c.Response().WriteHeader(http.StatusOK)
+
+		// UTF-8 BOM so spreadsheet apps pick up Vietnamese characters correctly
+		c.Response().Write([]byte("\xEF\xBB\xBF"))
+		w := csv.NewWriter(c.Response())
+		w.Write(productExportHeader)
+		for _, r := range rows {
+			w.Write(r.csvRecord())
+		}
+		w.Flush()
+		return w.Error()
+	default:
+		return c.String(http.StatusBadRequest, "unsupported export format")
+	}
+}
+
+var orderExportHeader = []string{
+	"order_number", "order_id", "invoice_number", "created_at", "status", "customer_name", "customer_email",
+	"phone", "province_code", "province", "district_code", "district", "ward_code", "ward", "address",
+	"note", "item_count", "items", "shipping_method",
+	"subtotal", "shipping_fee", "discount", "total_amount", "payment_method", "payment_status",
+	"refunded_amount", "currency", "display_currency", "display_rate",
+}
+
+// OrderExport downloads orders as CSV for the courier and accounting
+// systems. It accepts the same ?status=, ?from= and ?to= filters as the
+// order list.
+func OrderExport(c echo.Context) error {
+	query := database.DB.Model(&models.Order{})
+	if status := c.QueryParam("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query, _, _ = dateRange(query, "created_at", c.QueryParam("from"), c.QueryParam("to"))
+
+	var orders []models.Order
+	query.Preload("User").Preload("Items").Preload("Items.Product").Preload("Invoice").
+		Order("created_at ASC").Find(&orders)
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="orders-%s.csv"`, time.Now().Format("20060102")))
+	c.Response().WriteHeader(http.StatusOK)
+
+	c.Response().Write([]byte("\xEF\xBB\xBF"))
+	w := csv.NewWriter(c.Response())
+	w.Write(orderExportHeader)
+	for _, o := range orders {
+		var count int
+		lines := make([]string, 0, len(o.Items))
+		for _, item := range shippedItems(o) {
+			count += item.Quantity
+			lines = append(lines, fmt.Sprintf("%s x%d", item.Product.Name, item.Quantity))
+		}
+		invoiceNumber := ""
+		if o.Invoice != nil {
+			invoiceNumber = o.Invoice.Number
+		}
+		w.Write([]string{
+			o.Number,
+			o.ID,
+			invoiceNumber,
+			o.CreatedAt.Format(time.RFC3339),
+			o.Status,
+			o.Name,
+			o.User.Email,
+			o.Phone,
+			o.ProvinceCode,
+			o.Province,
+			o.DistrictCode,
+			o.District,
+			o.WardCode,
+			o.Ward,
+			o.Address,
+			o.Note,
+			strconv.Itoa(count),
+			strings.Join(lines, "; "),
+			o.Shipping,
+			strconv.FormatInt(int64(o.Subtotal), 10),
+			strconv.FormatInt(int64(o.ShippingFee), 10),
+			strconv.FormatInt(int64(o.Discount), 10),
+			strconv.FormatInt(int64(o.TotalAmount), 10),
+			o.PaymentMethod,
+			o.PaymentStatus,
+			strconv.FormatInt(int64(o.RefundedAmount), 10),
+			o.Currency,
+			o.DisplayCode,
+			strconv.FormatFloat(o.DisplayRate, 'f', -1, 64),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,330 @@
+package admin
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/pdf"
+	"shoop-golang/pkg/session"
+	"shoop-golang/pkg/utils"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const (
+	docInvoice     = "invoice"
+	docPackingSlip = "packing-slip"
+)
+
+// OrderInvoice serves the PDF invoice for one order, issuing an invoice
+// number the first time it is requested.
+func OrderInvoice(c echo.Context) error {
+	return orderDocuments(c, docInvoice, []string{c.Param("id")})
+}
+
+// OrderPackingSlip serves the PDF packing slip for one order.
+func OrderPackingSlip(c echo.Context) error {
+	return orderDocuments(c, docPackingSlip, []string{c.Param("id")})
+}
+
+// OrderDocumentsBatch prints invoices or packing slips for the orders
+// selected on the list page as a single PDF, one order per page.
+func OrderDocumentsBatch(c echo.Context) error {
+	form, _ := c.FormParams()
+	ids := form["ids"]
+	if len(ids) == 0 {
+		sess := session.GetAdminSession(c)
+		session.SetFlash(c, sess, session.FlashError, i18n.T(c, "admin.order.select_one"))
+		return c.Redirect(http.StatusFound, "/orders")
+	}
+
+	kind := c.FormValue("type")
+	if kind != docInvoice && kind != docPackingSlip {
+		return c.Redirect(http.StatusFound, "/orders")
+	}
+	return orderDocuments(c, kind, ids)
+}
+
+func orderDocuments(c echo.Context, kind string, ids []string) error {
+	var orders []models.Order
+	database.DB.Preload("User").Preload("Items").Preload("Items.Product").Preload("Invoice").
+		Where("id IN ?", ids).Order("created_at ASC").Find(&orders)
+	if len(orders) == 0 {
+		return c.Redirect(http.StatusFound, "/orders")
+	}
+
+	var company models.CompanyInfo
+	database.DB.First(&company)
+
+	doc := pdf.New()
+	for i := range orders {
+		if kind == docInvoice {
+			if err := ensureInvoice(&orders[i]); err != nil {
+				return err
+			}
+			drawInvoice(doc, company, orders[i])
+		} else {
+			drawPackingSlip(doc, company, orders[i])
+		}
+	}
+
+	filename := kind + "-" + time.Now().Format("20060102") + ".pdf"
+	if len(orders) == 1 {
+		filename = kind + "-" + orders[0].Number + ".pdf"
+	}
+
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s"`, filename))
+	return c.Blob(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// ensureInvoice issues the next invoice number for order if it has none.
+func ensureInvoice(order *models.Order) error {
+	if order.Invoice != nil {
+		return nil
+	}
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var existing models.Invoice
+		if err := tx.Where("order_id = ?", order.ID).First(&existing).Error; err == nil {
+			order.Invoice = &existing
+			return nil
+		}
+
+		now := time.Now()
+		n, err := models.NextSequence(tx, "invoice-"+strconv.Itoa(now.Year()))
+		if err != nil {
+			return err
+		}
+		inv := models.Invoice{
+			OrderID:  order.ID,
+			Number:   fmt.Sprintf("INV-%d-%06d", now.Year(), n),
+			IssuedAt: now,
+		}
+		if err := tx.Create(&inv).Error; err != nil {
+			return err
+		}
+		order.Invoice = &inv
+		return nil
+	})
+}
+
+const (
+	docMargin   = 40.0
+	docRight    = pdf.PageWidth - docMargin
+	docBottom   = pdf.PageHeight - 60
+	docRowGap   = 18.0
+	docFontSize = 10.0
+)
+
+// docHeader draws the company block and document title and returns the y
+// position below it.
+func docHeader(p *pdf.Page, company models.CompanyInfo, title string, meta [][2]string) float64 {
+	p.Text(docMargin, 60, 18, true, company.Name)
+	y := 78.0
+	for _, line := range []string{company.Address, company.Phone, company.Email} {
+		if line == "" {
+			continue
+		}
+		p.Text(docMargin, y, 9, false, line)
+		y += 13
+	}
+
+	p.TextRight(docRight, 60, 16, true, title)
+	my := 78.0
+	for _, m := range meta {
+		p.TextRight(docRight, my, 9, false, m[0]+": "+m[1])
+		my += 13
+	}
+	if my > y {
+		y = my
+	}
+	y += 10
+	p.Line(docMargin, y, docRight, y, 1)
+	return y + 24
+}
+
+func docCustomer(p *pdf.Page, o models.Order, y float64) float64 {
+	p.Text(docMargin, y, docFontSize, true, i18n.Translate(o.Locale, "document.customer"))
+	y += 16
+	for _, line := range []string{o.Name, o.Phone, o.FullAddress()} {
+		if line == "" {
+			continue
+		}
+		p.Text(docMargin, y, docFontSize, false, line)
+		y += 14
+	}
+	if o.Note != "" {
+		p.Text(docMargin, y, docFontSize, false, i18n.Translate(o.Locale, "document.note", o.Note))
+		y += 14
+	}
+	return y + 16
+}
+
+// docColumn is one column of the line-item table. Right-aligned columns are
+// anchored on X + Width.
+type docColumn struct {
+	Title string
+	X     float64
+	Width float64
+	Right bool
+}
+
+func docTableHeader(p *pdf.Page, cols []docColumn, y float64) float64 {
+	p.FillRect(docMargin, y-13, docRight-docMargin, 19, 0.92)
+	for _, col := range cols {
+		docCell(p, col, y, true, col.Title)
+	}
+	return y + docRowGap + 4
+}
+
+func docCell(p *pdf.Page, col docColumn, y float64, bold bool, s string) {
+	s = fitText(s, col.Width, docFontSize, bold)
+	if col.Right {
+		p.TextRight(col.X+col.Width, y, docFontSize, bold, s)
+	} else {
+		p.Text(col.X, y, docFontSize, bold, s)
+	}
+}
+
+// fitText shortens s with "..." so it fits in width points.
+func fitText(s string, width, size float64, bold bool) string {
+	if pdf.TextWidth(s, size, bold) <= width {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && pdf.TextWidth(string(r)+"...", size, bold) > width {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "..."
+}
+
+// drawInvoice adds the invoice for o, labelled in the language the
+// customer ordered in.
+func drawInvoice(doc *pdf.Document, company models.CompanyInfo, o models.Order) {
+	tr := func(key string) string { return i18n.Translate(o.Locale, key) }
+	cols := []docColumn{
+		{"#", docMargin, 20, false},
+		{tr("document.product"), docMargin + 24, 250, false},
+		{tr("document.quantity"), docMargin + 278, 40, true},
+		{tr("document.unit_price"), docMargin + 322, 90, true},
+		{tr("document.line_total"), docMargin + 416, docRight - docMargin - 416, true},
+	}
+	meta := [][2]string{
+		{tr("document.invoice_number"), o.Invoice.Number},
+		{tr("document.date"), o.Invoice.IssuedAt.Format("02/01/2006")},
+		{tr("document.order_number"), o.Number},
+	}
+	title := tr("document.invoice")
+
+	p := doc.AddPage()
+	y := docHeader(p, company, title, meta)
+	y = docCustomer(p, o, y)
+	y = docTableHeader(p, cols, y)
+
+	for i, item := range shippedItems(o) {
+		if y > docBottom {
+			p = doc.AddPage()
+			y = docHeader(p, company, title, meta)
+			y = docTableHeader(p, cols, y)
+		}
+		docCell(p, cols[0], y, false, strconv.Itoa(i+1))
+		docCell(p, cols[1], y, false, item.Product.Name)
+		docCell(p, cols[2], y, false, strconv.Itoa(item.Quantity))
+		docCell(p, cols[3], y, false, utils.FormatPrice(item.Price))
+		docCell(p, cols[4], y, false, utils.FormatPrice(item.Subtotal()))
+		y += docRowGap
+	}
+
+	p.Line(docMargin, y-8, docRight, y-8, 0.5)
+	y += 8
+	totals := [][2]string{
+		{tr("document.subtotal"), utils.FormatPrice(o.Subtotal)},
+		{tr("document.shipping_fee"), utils.FormatPrice(o.ShippingFee)},
+	}
+	if o.Discount > 0 {
+		totals = append(totals, [2]string{tr("document.discount"), "-" + utils.FormatPrice(o.Discount)})
+	}
+	for _, t := range totals {
+		p.Text(cols[3].X, y, docFontSize, false, t[0])
+		p.TextRight(docRight, y, docFontSize, false, t[1])
+		y += docRowGap
+	}
+	y += 4
+	p.Text(cols[3].X, y, 12, true, tr("document.total"))
+	p.TextRight(docRight, y, 12, true, utils.FormatPrice(o.TotalAmount))
+
+	docFooter(p, company, o.Locale)
+}
+
+// shippedItems leaves out lines fully cancelled by refunds.
+func shippedItems(o models.Order) []models.OrderItem {
+	items := make([]models.OrderItem, 0, len(o.Items))
+	for _, item := range o.Items {
+		if item.Quantity > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// drawPackingSlip adds the packing slip for o, labelled in the language
+// the customer ordered in.
+func drawPackingSlip(doc *pdf.Document, company models.CompanyInfo, o models.Order) {
+	tr := func(key string) string { return i18n.Translate(o.Locale, key) }
+	cols := []docColumn{
+		{"#", docMargin, 20, false},
+		{tr("document.product"), docMargin + 24, 330, false},
+		{"SKU", docMargin + 358, 90, false},
+		{tr("document.quantity"), docMargin + 452, docRight - docMargin - 452, true},
+	}
+	meta := [][2]string{
+		{tr("document.order_number"), o.Number},
+		{tr("document.order_date"), o.CreatedAt.Format("02/01/2006")},
+	}
+	title := tr("document.packing_slip")
+
+	p := doc.AddPage()
+	y := docHeader(p, company, title, meta)
+	y = docCustomer(p, o, y)
+	y = docTableHeader(p, cols, y)
+
+	var count int
+	for i, item := range shippedItems(o) {
+		if y > docBottom {
+			p = doc.AddPage()
+			y = docHeader(p, company, title, meta)
+			y = docTableHeader(p, cols, y)
+		}
+		docCell(p, cols[0], y, false, strconv.Itoa(i+1))
+		docCell(p, cols[1], y, false, item.Product.Name)
+		docCell(p, cols[2], y, false, item.Product.SKU)
+		docCell(p, cols[3], y, false, strconv.Itoa(item.Quantity))
+		count += item.Quantity
+		y += docRowGap
+	}
+
+	p.Line(docMargin, y-8, docRight, y-8, 0.5)
+	y += 8
+	p.Text(cols[2].X, y, 12, true, tr("document.total_quantity"))
+	p.TextRight(docRight, y, 12, true, strconv.Itoa(count))
+
+	docFooter(p, company, o.Locale)
+}
+
+func docFooter(p *pdf.Page, company models.CompanyInfo, locale string) {
+	p.Line(docMargin, pdf.PageHeight-45, docRight, pdf.PageHeight-45, 0.5)
+	p.Text(docMargin, pdf.PageHeight-30, 8, false, i18n.Translate(locale, "document.thanks", company.Name))
+	if company.Copyright != "" {
+		p.TextRight(docRight, pdf.PageHeight-30, 8, false, company.Copyright)
+	}
+}
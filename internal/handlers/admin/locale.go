@@ -0,0 +1,23 @@
+package admin
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"shoop-golang/pkg/i18n"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SetLanguage switches the admin locale and returns to the previous page.
+func SetLanguage(c echo.Context) error {
+	if locale := c.Param("code"); i18n.Supported(locale) {
+		i18n.SetCookie(c, locale)
+	}
+	back := "/dashboard"
+	if ref, err := url.Parse(c.Request().Referer()); err == nil && strings.HasPrefix(ref.Path, "/") && !strings.HasPrefix(ref.Path, "//") {
+		back = ref.RequestURI()
+	}
+	return c.Redirect(http.StatusFound, back)
+}
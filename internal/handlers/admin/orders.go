@@ -1,60 +1,180 @@
 package admin
 
 import (
+	"errors"
 	"net/http"
+	"slices"
 
 	"shoop-golang/database"
 	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/payments"
 	"shoop-golang/pkg/session"
 
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 func OrderList(c echo.Context) error {
 	data := adminData(c)
-	data["Title"] = "Đơn hàng"
+	data["Title"] = i18n.T(c, "admin.page.orders")
 	data["Active"] = "orders"
 
 	status := c.QueryParam("status")
-	query := database.DB.Preload("User").Preload("Items").Preload("Items.Product").Order("created_at DESC")
+	query := database.DB.Model(&models.Order{})
 	if status != "" {
 		query = query.Where("status = ?", status)
 	}
 
+	query, pagination := listQuery(c, query, ListOptions{
+		SearchColumns: []string{"number", "id", "name", "phone"},
+		SortColumns: map[string]string{
+			"number":     "number",
+			"created_at": "created_at",
+			"total":      "total_amount",
+			"status":     "status",
+		},
+		DefaultSort: "created_at",
+		DefaultDir:  "desc",
+		DateColumn:  "created_at",
+	})
+
 	var orders []models.Order
-	query.Find(&orders)
+	query.Preload("User").Find(&orders)
 	data["Orders"] = orders
 	data["FilterStatus"] = status
+	data["Pagination"] = pagination
 
 	return c.Render(http.StatusOK, "admin/orders/index", data)
 }
 
 func OrderDetail(c echo.Context) error {
 	data := adminData(c)
-	data["Title"] = "Chi tiết đơn hàng"
+	data["Title"] = i18n.T(c, "admin.page.order_detail")
 	data["Active"] = "orders"
 
 	var order models.Order
-	if err := database.DB.Preload("User").Preload("Items").Preload("Items.Product").First(&order, "id = ?", c.Param("id")).Error; err != nil {
+	if err := database.DB.Preload("User").Preload("Items").Preload("Items.Product").Preload("Invoice").
+		Preload("Payments", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Refunds", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Refunds.Items").Preload("Refunds.Items.OrderItem.Product").
+		First(&order, "id = ?", c.Param("id")).Error; err != nil {
 		return c.Redirect(http.StatusFound, "/orders")
 	}
 	data["Order"] = order
+	data["NextStatuses"] = orderTransitions[order.Status]
+	// Goods that have left the warehouse only go back into stock when
+	// staff say they came back.
+	data["RestockByDefault"] = order.Status == "pending" || order.Status == "confirmed"
+
+	if order.Shipping != "" {
+		var method models.ShippingMethod
+		if database.DB.Unscoped().First(&method, "code = ?", order.Shipping).Error == nil {
+			data["ShippingMethod"] = method
+		}
+	}
+	if p, ok := payments.Get(order.PaymentMethod); ok {
+		data["PaymentName"] = p.Name()
+	}
 
 	return c.Render(http.StatusOK, "admin/orders/detail", data)
 }
 
+// orderTransitions lists the statuses an order can move to from each
+// status. Cancelled orders are final, and orders that have left the
+// warehouse are cancelled through the refund form, which asks for a reason
+// and whether the goods came back.
+var orderTransitions = map[string][]string{
+	"pending":   {"confirmed", "shipping", "cancelled"},
+	"confirmed": {"pending", "shipping", "cancelled"},
+	"shipping":  {"delivered"},
+	"delivered": {},
+	"cancelled": {},
+}
+
+// orderTransitionError is the message key for moving an order from one
+// status to another, or "" when the move is allowed.
+func orderTransitionError(from, to string) string {
+	if slices.Contains(orderTransitions[from], to) {
+		return ""
+	}
+	if to == "cancelled" && (from == "shipping" || from == "delivered") {
+		return "admin.order.cancel_via_refund"
+	}
+	return "admin.order.invalid_transition"
+}
+
 func OrderUpdateStatus(c echo.Context) error {
 	newStatus := c.FormValue("status")
-	validStatuses := map[string]bool{
-		"pending": true, "confirmed": true, "shipping": true, "delivered": true, "cancelled": true,
-	}
-	if !validStatuses[newStatus] {
+	if _, ok := orderTransitions[newStatus]; !ok {
 		return c.Redirect(http.StatusFound, "/orders")
 	}
 
-	database.DB.Model(&models.Order{}).Where("id = ?", c.Param("id")).Update("status", newStatus)
+	// Cancelling cancels every line still to deliver, as a refund that
+	// puts them back in stock and gives back what was paid.
+	var refund models.Refund
+	var send *providerRefund
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Preload("Items").First(&order, "id = ?", c.Param("id")).Error; err != nil {
+			return errRefund("order.not_found")
+		}
+		if order.Status == newStatus {
+			return nil
+		}
+		if key := orderTransitionError(order.Status, newStatus); key != "" {
+			return errRefund(key)
+		}
+		if newStatus == "cancelled" {
+			quantities := map[string]int{}
+			for _, item := range order.Items {
+				quantities[item.ID] = item.Quantity
+			}
+			var err error
+			refund, send, err = cancelLines(tx, &order, quantities, i18n.T(c, "admin.refund.order_cancel_reason"), true)
+			if !errors.Is(err, errRefund("admin.refund.select_one")) {
+				return err
+			}
+		}
+		if err := tx.Model(&order).Update("status", newStatus).Error; err != nil {
+			return err
+		}
+		if newStatus == "delivered" {
+			return collectCashOnDelivery(tx, order)
+		}
+		return nil
+	})
 
 	sess := session.GetAdminSession(c)
-	session.SetFlash(c, sess, session.FlashSuccess, "Đã cập nhật trạng thái đơn hàng")
+	if err != nil {
+		session.SetFlash(c, sess, session.FlashError, refundError(c, err))
+		return c.Redirect(http.StatusFound, "/orders/"+c.Param("id"))
+	}
+	if refund.ID != "" {
+		return refundDone(c, c.Param("id"), refund, send)
+	}
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.order.status_updated"))
 	return c.Redirect(http.StatusFound, "/orders/"+c.Param("id"))
 }
+
+// collectCashOnDelivery settles a COD order once it has been delivered: the
+// courier has collected the total.
+func collectCashOnDelivery(tx *gorm.DB, order models.Order) error {
+	if order.PaymentMethod != (payments.COD{}).Code() || order.PaymentStatus == models.PaymentPaid {
+		return nil
+	}
+	var charge models.Payment
+	err := tx.Where("order_id = ? AND provider = ? AND kind = ? AND status = ?",
+		order.ID, order.PaymentMethod, models.PaymentCharge, payments.StatusPending).First(&charge).Error
+	if err == nil {
+		return models.CompletePayment(tx, &charge)
+	}
+	return models.RecordPayment(tx, &models.Payment{
+		OrderID:   order.ID,
+		Provider:  order.PaymentMethod,
+		Kind:      models.PaymentCharge,
+		Status:    payments.StatusSucceeded,
+		Amount:    order.TotalAmount,
+		Reference: order.Number,
+	})
+}
@@ -0,0 +1,207 @@
+package admin
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const defaultPerPage = 20
+
+// perPageOptions are the page sizes offered by the pager.
+var perPageOptions = []int{10, 20, 50, 100}
+
+// ListOptions configures listQuery for one admin list page.
+type ListOptions struct {
+	// SearchColumns are matched with LIKE against ?q=.
+	SearchColumns []string
+	// SortColumns maps the ?sort= keys accepted by the page to SQL columns.
+	SortColumns map[string]string
+	DefaultSort string
+	DefaultDir  string
+	// DateColumn is filtered by ?from= and ?to= (YYYY-MM-DD, inclusive).
+	DateColumn string
+}
+
+// Pagination describes the current page of an admin list and how to link to
+// its neighbours while keeping the active filters.
+type Pagination struct {
+	Page       int
+	PerPage    int
+	Total      int64
+	TotalPages int
+	Search     string
+	Sort       string
+	Dir        string
+	From       string
+	To         string
+	Query      url.Values
+}
+
+func (p Pagination) HasPrev() bool   { return p.Page > 1 }
+func (p Pagination) HasNext() bool   { return p.Page < p.TotalPages }
+func (p Pagination) PrevURL() string { return p.URL(p.Page - 1) }
+func (p Pagination) NextURL() string { return p.URL(p.Page + 1) }
+
+// URL returns the query string (including the leading "?") for page n.
+func (p Pagination) URL(n int) string {
+	return p.with(map[string]string{"page": strconv.Itoa(n)})
+}
+
+// PerPageURL links to the first page with a different page size.
+func (p Pagination) PerPageURL(n int) string {
+	return p.with(map[string]string{"per_page": strconv.Itoa(n), "page": "1"})
+}
+
+// SortURL links to the list sorted by key, flipping the direction when key
+// is already the active sort.
+func (p Pagination) SortURL(key string) string {
+	dir := "asc"
+	if p.Sort == key && p.Dir == "asc" {
+		dir = "desc"
+	}
+	return p.with(map[string]string{"sort": key, "dir": dir, "page": "1"})
+}
+
+// SortIcon returns the Font Awesome icon class for a sortable column header.
+func (p Pagination) SortIcon(key string) string {
+	if p.Sort != key {
+		return "fa-sort"
+	}
+	if p.Dir == "asc" {
+		return "fa-sort-up"
+	}
+	return "fa-sort-down"
+}
+
+// PerPageOptions lists the selectable page sizes.
+func (p Pagination) PerPageOptions() []int { return perPageOptions }
+
+// Pages returns the page numbers to show around the current page; zero
+// marks a gap.
+func (p Pagination) Pages() []int {
+	const window = 2
+	var pages []int
+	for n := 1; n <= p.TotalPages; n++ {
+		if n == 1 || n == p.TotalPages || (n >= p.Page-window && n <= p.Page+window) {
+			pages = append(pages, n)
+		} else if len(pages) > 0 && pages[len(pages)-1] != 0 {
+			pages = append(pages, 0)
+		}
+	}
+	return pages
+}
+
+// FirstRow and LastRow are the 1-based positions of the rows shown.
+func (p Pagination) FirstRow() int64 {
+	if p.Total == 0 {
+		return 0
+	}
+	return int64((p.Page-1)*p.PerPage) + 1
+}
+
+func (p Pagination) LastRow() int64 {
+	to := int64(p.Page * p.PerPage)
+	if to > p.Total {
+		return p.Total
+	}
+	return to
+}
+
+func (p Pagination) with(set map[string]string) string {
+	q := url.Values{}
+	for k, v := range p.Query {
+		q[k] = v
+	}
+	for k, v := range set {
+		q.Set(k, v)
+	}
+	return "?" + q.Encode()
+}
+
+// listQuery applies the search, date range and sort requested in the query
+// string to query, then limits it to the requested page.
+func listQuery(c echo.Context, query *gorm.DB, opts ListOptions) (*gorm.DB, Pagination) {
+	p := Pagination{
+		Search: strings.TrimSpace(c.QueryParam("q")),
+		From:   c.QueryParam("from"),
+		To:     c.QueryParam("to"),
+		Query:  url.Values{},
+	}
+	for k, v := range c.QueryParams() {
+		p.Query[k] = v
+	}
+
+	if p.Search != "" && len(opts.SearchColumns) > 0 {
+		conds := make([]string, len(opts.SearchColumns))
+		args := make([]any, len(opts.SearchColumns))
+		for i, col := range opts.SearchColumns {
+			conds[i] = col + " LIKE ?"
+			args[i] = "%" + p.Search + "%"
+		}
+		query = query.Where(strings.Join(conds, " OR "), args...)
+	}
+
+	if opts.DateColumn != "" {
+		query, p.From, p.To = dateRange(query, opts.DateColumn, p.From, p.To)
+	}
+
+	p.Sort, p.Dir = c.QueryParam("sort"), strings.ToLower(c.QueryParam("dir"))
+	if _, ok := opts.SortColumns[p.Sort]; !ok {
+		p.Sort = opts.DefaultSort
+	}
+	if p.Dir != "asc" && p.Dir != "desc" {
+		p.Dir = opts.DefaultDir
+	}
+	if col, ok := opts.SortColumns[p.Sort]; ok {
+		query = query.Order(col + " " + strings.ToUpper(p.Dir))
+	}
+
+	p.PerPage = defaultPerPage
+	if n, err := strconv.Atoi(c.QueryParam("per_page")); err == nil {
+		for _, opt := range perPageOptions {
+			if n == opt {
+				p.PerPage = n
+			}
+		}
+	}
+
+	p.Page, _ = strconv.Atoi(c.QueryParam("page"))
+	if p.Page < 1 {
+		p.Page = 1
+	}
+
+	query.Count(&p.Total)
+
+	p.TotalPages = int(math.Ceil(float64(p.Total) / float64(p.PerPage)))
+	if p.TotalPages < 1 {
+		p.TotalPages = 1
+	}
+	if p.Page > p.TotalPages {
+		p.Page = p.TotalPages
+	}
+
+	p.Query.Del("page")
+	return query.Offset((p.Page - 1) * p.PerPage).Limit(p.PerPage), p
+}
+
+// dateRange filters column to the days between from and to (YYYY-MM-DD,
+// both inclusive). Bounds that fail to parse are ignored and returned empty.
+func dateRange(query *gorm.DB, column, from, to string) (*gorm.DB, string, string) {
+	if t, err := time.ParseInLocation("2006-01-02", from, time.Local); err == nil {
+		query = query.Where(column+" >= ?", t)
+	} else {
+		from = ""
+	}
+	if t, err := time.ParseInLocation("2006-01-02", to, time.Local); err == nil {
+		query = query.Where(column+" < ?", t.AddDate(0, 0, 1))
+	} else {
+		to = ""
+	}
+	return query, from, to
+}
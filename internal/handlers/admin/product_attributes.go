@@ -0,0 +1,151 @@
+package admin
+
+import (
+	"strings"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/utils"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// attributeField is an attribute as the product form shows it.
+type attributeField struct {
+	models.Attribute
+	// Categories lists the IDs of the categories the attribute applies to,
+	// subcategories included, for the form to hide it elsewhere. Empty
+	// means every category.
+	Categories string
+	Selected   map[string]bool
+	Text       string
+}
+
+func loadAttributes(db *gorm.DB) []models.Attribute {
+	var attrs []models.Attribute
+	db.Preload("Values", orderValues).Preload("Categories").Order("sort_order ASC, name ASC").Find(&attrs)
+	return attrs
+}
+
+// productFormOptions adds what the product form needs to pick attributes
+// and tags, filled in from the product's current ones.
+func productFormOptions(data map[string]any, product models.Product) {
+	var all []models.Category
+	database.DB.Select("id", "parent_id").Find(&all)
+
+	var fields []attributeField
+	for _, attr := range loadAttributes(database.DB) {
+		f := attributeField{Attribute: attr, Selected: map[string]bool{}}
+		var ids []string
+		for _, cat := range attr.Categories {
+			ids = append(ids, models.CategoryDescendants(all, cat.ID)...)
+		}
+		f.Categories = strings.Join(ids, " ")
+		for _, row := range product.Attributes {
+			if row.AttributeID != attr.ID {
+				continue
+			}
+			if row.ValueID != nil {
+				f.Selected[*row.ValueID] = true
+			}
+			f.Text = row.Text
+		}
+		fields = append(fields, f)
+	}
+	data["AttributeFields"] = fields
+
+	var names []string
+	for _, tag := range product.Tags {
+		names = append(names, tag.Name)
+	}
+	data["TagNames"] = strings.Join(names, ", ")
+	var tags []models.Tag
+	database.DB.Order("name ASC").Find(&tags)
+	data["AllTags"] = tags
+}
+
+// formProductAttributes reads the attr_<id> fields of the attributes that
+// apply to the category path, or of every attribute when path is nil.
+// Values not allowed for their attribute are dropped.
+func formProductAttributes(c echo.Context, attrs []models.Attribute, path []string) []models.ProductAttribute {
+	form, _ := c.FormParams()
+	var rows []models.ProductAttribute
+	for _, attr := range attrs {
+		if path != nil && !attr.AppliesTo(path) {
+			continue
+		}
+		submitted := form["attr_"+attr.ID]
+		if !attr.HasValues() {
+			if len(submitted) > 0 && strings.TrimSpace(submitted[0]) != "" {
+				rows = append(rows, models.ProductAttribute{AttributeID: attr.ID, Text: strings.TrimSpace(submitted[0])})
+			}
+			continue
+		}
+		for _, v := range attr.Values {
+			for _, id := range submitted {
+				if id == v.ID {
+					rows = append(rows, models.ProductAttribute{AttributeID: attr.ID, ValueID: &v.ID})
+					break
+				}
+			}
+			if attr.Type == models.AttributeSelect && len(rows) > 0 && rows[len(rows)-1].AttributeID == attr.ID {
+				break
+			}
+		}
+	}
+	return rows
+}
+
+// saveProductAttributes replaces the product's attributes with those
+// submitted for its category. Attributes that no longer apply after a
+// category change are dropped.
+func saveProductAttributes(c echo.Context, tx *gorm.DB, product models.Product) error {
+	var all []models.Category
+	tx.Select("id", "parent_id").Find(&all)
+	path := []string{}
+	for _, cat := range models.CategoryAncestors(all, product.CategoryID) {
+		path = append(path, cat.ID)
+	}
+	path = append(path, product.CategoryID)
+
+	rows := formProductAttributes(c, loadAttributes(tx), path)
+	if err := tx.Unscoped().Where("product_id = ?", product.ID).Delete(&models.ProductAttribute{}).Error; err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	for i := range rows {
+		rows[i].ProductID = product.ID
+	}
+	return tx.Create(&rows).Error
+}
+
+// formTags reads the comma-separated tags field, one tag per slug.
+func formTags(c echo.Context) []models.Tag {
+	var tags []models.Tag
+	seen := map[string]bool{}
+	for _, name := range strings.Split(c.FormValue("tags"), ",") {
+		name = strings.TrimSpace(name)
+		slug := utils.Slugify(name)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		tags = append(tags, models.Tag{Name: name, Slug: slug})
+	}
+	return tags
+}
+
+// saveProductTags sets the product's tags, creating the new ones. A tag
+// typed with another spelling of an existing slug joins that tag.
+func saveProductTags(c echo.Context, tx *gorm.DB, product models.Product) error {
+	tags := formTags(c)
+	for i := range tags {
+		if err := tx.Where("slug = ?", tags[i].Slug).FirstOrCreate(&tags[i]).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Model(&product).Association("Tags").Replace(tags)
+}
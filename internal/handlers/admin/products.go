@@ -7,67 +7,251 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"shoop-golang/database"
 	"shoop-golang/internal/models"
+	"shoop-golang/internal/notifications"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/money"
 	"shoop-golang/pkg/session"
 	"shoop-golang/pkg/utils"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
+// Products with stock at or below this level are reported as "low stock".
+const lowStockThreshold = 5
+
 func ProductList(c echo.Context) error {
 	data := adminData(c)
-	data["Title"] = "Sản phẩm"
+	data["Title"] = i18n.T(c, "admin.page.products")
 	data["Active"] = "products"
 
+	query := database.DB.Model(&models.Product{})
+
+	if categoryID := c.QueryParam("category_id"); categoryID != "" {
+		query = query.Where("category_id = ?", categoryID)
+	}
+	switch c.QueryParam("active") {
+	case "1":
+		query = query.Where("is_active = ?", true)
+	case "0":
+		query = query.Where("is_active = ?", false)
+	}
+	switch c.QueryParam("featured") {
+	case "1":
+		query = query.Where("is_featured = ?", true)
+	case "0":
+		query = query.Where("is_featured = ?", false)
+	}
+	switch c.QueryParam("stock") {
+	case "out":
+		query = query.Where("stock <= 0")
+	case "low":
+		query = query.Where("stock > 0 AND stock <= ?", lowStockThreshold)
+	case "in":
+		query = query.Where("stock > ?", lowStockThreshold)
+	}
+
+	query, pagination := listQuery(c, query, ListOptions{
+		SearchColumns: []string{"name", "sku"},
+		SortColumns: map[string]string{
+			"name":       "name",
+			"price":      "original_price",
+			"stock":      "stock",
+			"created_at": "created_at",
+		},
+		DefaultSort: "created_at",
+		DefaultDir:  "desc",
+		DateColumn:  "created_at",
+	})
+
 	var products []models.Product
-	database.DB.Preload("Category").Preload("Images").Order("created_at DESC").Find(&products)
+	query.Preload("Category").Preload("Images").Find(&products)
 	data["Products"] = products
+	data["Pagination"] = pagination
+	data["Filter"] = map[string]string{
+		"CategoryID": c.QueryParam("category_id"),
+		"Active":     c.QueryParam("active"),
+		"Featured":   c.QueryParam("featured"),
+		"Stock":      c.QueryParam("stock"),
+	}
+
+	var categories []models.Category
+	database.DB.Order("sort_order ASC").Find(&categories)
+	data["Categories"] = categories
 
 	return c.Render(http.StatusOK, "admin/products/index", data)
 }
 
+// ProductBulk applies one action to every product selected on the list
+// page. All updates run in a single transaction.
+func ProductBulk(c echo.Context) error {
+	sess := session.GetAdminSession(c)
+
+	redirect := "/products"
+	if ret := c.FormValue("return"); strings.HasPrefix(ret, "?") {
+		redirect += ret
+	}
+
+	form, _ := c.FormParams()
+	ids := form["ids"]
+	if len(ids) == 0 {
+		session.SetFlash(c, sess, session.FlashError, i18n.T(c, "admin.product.select_one"))
+		return c.Redirect(http.StatusFound, redirect)
+	}
+
+	action := c.FormValue("action")
+	var summary, detail string
+	var args []any
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		products := tx.Model(&models.Product{}).Where("id IN ?", ids)
+
+		var res *gorm.DB
+		switch action {
+		case "activate":
+			res = products.Update("is_active", true)
+			summary = "admin.product.bulk.activated"
+		case "deactivate":
+			res = products.Update("is_active", false)
+			summary = "admin.product.bulk.deactivated"
+		case "feature":
+			res = products.Update("is_featured", true)
+			summary = "admin.product.bulk.featured"
+		case "unfeature":
+			res = products.Update("is_featured", false)
+			summary = "admin.product.bulk.unfeatured"
+		case "move_category":
+			var cat models.Category
+			if err := tx.First(&cat, "id = ?", c.FormValue("category_id")).Error; err != nil {
+				return errBulk("admin.product.bulk.category_not_found")
+			}
+			res = products.Update("category_id", cat.ID)
+			summary = "admin.product.bulk.moved"
+			args = append(args, cat.Name)
+		case "adjust_price":
+			percent, err := strconv.ParseFloat(c.FormValue("percent"), 64)
+			if err != nil || percent == 0 || percent <= -100 || percent > 1000 {
+				return errBulk("admin.product.bulk.invalid_percent")
+			}
+			factor := 1 + percent/100
+			err = notifications.Track(tx, ids, func() error {
+				res = products.Updates(map[string]any{
+					"original_price": gorm.Expr("CAST(ROUND(original_price * ?) AS INTEGER)", factor),
+					"sale_price":     gorm.Expr("CAST(ROUND(sale_price * ?) AS INTEGER)", factor),
+				})
+				return res.Error
+			})
+			if err != nil {
+				return err
+			}
+			summary = "admin.product.bulk.repriced"
+			detail = fmt.Sprintf(" (%+g%%)", percent)
+		case "delete":
+			if err := deleteProductData(tx, ids); err != nil {
+				return err
+			}
+			res = tx.Where("id IN ?", ids).Delete(&models.Product{})
+			summary = "admin.product.bulk.deleted"
+		default:
+			return errBulk("admin.product.bulk.invalid_action")
+		}
+		if res.Error != nil {
+			return res.Error
+		}
+		summary = i18n.T(c, summary, append([]any{res.RowsAffected}, args...)...) + detail
+		return nil
+	})
+
+	if err != nil {
+		msg := i18n.T(c, "admin.product.bulk.failed", err.Error())
+		if be, ok := err.(errBulk); ok {
+			msg = i18n.T(c, string(be))
+		}
+		session.SetFlash(c, sess, session.FlashError, msg)
+		return c.Redirect(http.StatusFound, redirect)
+	}
+
+	session.SetFlash(c, sess, session.FlashSuccess, summary)
+	return c.Redirect(http.StatusFound, redirect)
+}
+
+// errBulk is a validation failure in a bulk action, carrying the key of the
+// message shown to the admin.
+type errBulk string
+
+func (e errBulk) Error() string { return string(e) }
+
 func ProductCreate(c echo.Context) error {
 	data := adminData(c)
-	data["Title"] = "Thêm sản phẩm"
+	data["Title"] = i18n.T(c, "admin.page.product_create")
 	data["Active"] = "products"
 
 	var categories []models.Category
 	database.DB.Where("is_active = ?", true).Order("sort_order ASC").Find(&categories)
 	data["Categories"] = categories
+	data["CategoryParents"] = categoryParentNames()
+	productFormOptions(data, models.Product{})
 
 	return c.Render(http.StatusOK, "admin/products/form", data)
 }
 
 func ProductStore(c echo.Context) error {
-	originalPrice, _ := strconv.ParseFloat(c.FormValue("original_price"), 64)
-	salePrice, _ := strconv.ParseFloat(c.FormValue("sale_price"), 64)
+	originalPrice, _ := money.Parse(c.FormValue("original_price"))
+	salePrice, _ := money.Parse(c.FormValue("sale_price"))
 	stock, _ := strconv.Atoi(c.FormValue("stock"))
+	weight, _ := strconv.Atoi(c.FormValue("weight"))
 
 	product := models.Product{
 		Name:          c.FormValue("name"),
-		Slug:          utils.Slugify(c.FormValue("name")),
+		Slug:          formSlug(c.FormValue("slug"), c.FormValue("name")),
 		Description:   c.FormValue("description"),
 		Content:       c.FormValue("content"),
 		OriginalPrice: originalPrice,
 		SalePrice:     salePrice,
 		SKU:           c.FormValue("sku"),
 		Stock:         stock,
+		Weight:        weight,
 		CategoryID:    c.FormValue("category_id"),
 		IsActive:      c.FormValue("is_active") == "on",
 		IsFeatured:    c.FormValue("is_featured") == "on",
 	}
 
-	if err := database.DB.Create(&product).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := productSlugs().settle(tx, "", "", &product.Slug); err != nil {
+			return err
+		}
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		translations := productTranslations(c, product)
+		if err := settleProductSlugs(tx, product, translations); err != nil {
+			return err
+		}
+		if err := replaceTranslations(tx, "product_id", product.ID, translations); err != nil {
+			return err
+		}
+		if err := saveProductAttributes(c, tx, product); err != nil {
+			return err
+		}
+		return saveProductTags(c, tx, product)
+	})
+	if err != nil {
 		data := adminData(c)
-		data["Title"] = "Thêm sản phẩm"
+		data["Title"] = i18n.T(c, "admin.page.product_create")
 		data["Active"] = "products"
-		data["Error"] = "Không thể tạo sản phẩm: " + err.Error()
+		data["Error"] = i18n.T(c, "admin.product.create_failed", err.Error())
 		var categories []models.Category
 		database.DB.Where("is_active = ?", true).Find(&categories)
 		data["Categories"] = categories
+		data["CategoryParents"] = categoryParentNames()
+		product.Translations = productTranslations(c, product)
+		product.Attributes = formProductAttributes(c, loadAttributes(database.DB), nil)
+		product.Tags = formTags(c)
+		productFormOptions(data, product)
 		data["Product"] = product
 		return c.Render(http.StatusOK, "admin/products/form", data)
 	}
@@ -75,17 +259,17 @@ func ProductStore(c echo.Context) error {
 	handleProductImages(c, product.ID)
 
 	sess := session.GetAdminSession(c)
-	session.SetFlash(c, sess, session.FlashSuccess, "Đã tạo sản phẩm thành công")
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.product.created"))
 	return c.Redirect(http.StatusFound, "/products")
 }
 
 func ProductEdit(c echo.Context) error {
 	data := adminData(c)
-	data["Title"] = "Sửa sản phẩm"
+	data["Title"] = i18n.T(c, "admin.page.product_edit")
 	data["Active"] = "products"
 
 	var product models.Product
-	if err := database.DB.Preload("Images").First(&product, "id = ?", c.Param("id")).Error; err != nil {
+	if err := database.DB.Preload("Images").Preload("Translations").Preload("Attributes").Preload("Tags").First(&product, "id = ?", c.Param("id")).Error; err != nil {
 		return c.Redirect(http.StatusFound, "/products")
 	}
 	data["Product"] = product
@@ -94,6 +278,8 @@ func ProductEdit(c echo.Context) error {
 	var categories []models.Category
 	database.DB.Where("is_active = ?", true).Order("sort_order ASC").Find(&categories)
 	data["Categories"] = categories
+	data["CategoryParents"] = categoryParentNames()
+	productFormOptions(data, product)
 
 	return c.Render(http.StatusOK, "admin/products/form", data)
 }
@@ -104,38 +290,113 @@ func ProductUpdate(c echo.Context) error {
 		return c.Redirect(http.StatusFound, "/products")
 	}
 
-	originalPrice, _ := strconv.ParseFloat(c.FormValue("original_price"), 64)
-	salePrice, _ := strconv.ParseFloat(c.FormValue("sale_price"), 64)
+	originalPrice, _ := money.Parse(c.FormValue("original_price"))
+	salePrice, _ := money.Parse(c.FormValue("sale_price"))
 	stock, _ := strconv.Atoi(c.FormValue("stock"))
+	weight, _ := strconv.Atoi(c.FormValue("weight"))
 
+	oldSlug := product.Slug
 	product.Name = c.FormValue("name")
-	product.Slug = utils.Slugify(c.FormValue("name"))
+	product.Slug = utils.Slugify(c.FormValue("slug"))
 	product.Description = c.FormValue("description")
 	product.Content = c.FormValue("content")
 	product.OriginalPrice = originalPrice
 	product.SalePrice = salePrice
 	product.SKU = c.FormValue("sku")
 	product.Stock = stock
+	product.Weight = weight
 	product.CategoryID = c.FormValue("category_id")
 	product.IsActive = c.FormValue("is_active") == "on"
 	product.IsFeatured = c.FormValue("is_featured") == "on"
 
-	database.DB.Save(&product)
+	sess := session.GetAdminSession(c)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := productSlugs().settle(tx, product.ID, oldSlug, &product.Slug); err != nil {
+			return err
+		}
+		err := notifications.Track(tx, []string{product.ID}, func() error {
+			return tx.Save(&product).Error
+		})
+		if err != nil {
+			return err
+		}
+		translations := productTranslations(c, product)
+		if err := settleProductSlugs(tx, product, translations); err != nil {
+			return err
+		}
+		if err := replaceTranslations(tx, "product_id", product.ID, translations); err != nil {
+			return err
+		}
+		if err := saveProductAttributes(c, tx, product); err != nil {
+			return err
+		}
+		return saveProductTags(c, tx, product)
+	})
+	if err != nil {
+		session.SetFlash(c, sess, session.FlashError, i18n.T(c, "admin.product.update_failed", err.Error()))
+		return c.Redirect(http.StatusFound, "/products/"+product.ID+"/edit")
+	}
 	handleProductImages(c, product.ID)
 
-	sess := session.GetAdminSession(c)
-	session.SetFlash(c, sess, session.FlashSuccess, "Đã cập nhật sản phẩm")
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.product.updated"))
 	return c.Redirect(http.StatusFound, "/products")
 }
 
 func ProductDelete(c echo.Context) error {
-	database.DB.Where("product_id = ?", c.Param("id")).Delete(&models.Image{})
-	database.DB.Where("id = ?", c.Param("id")).Delete(&models.Product{})
 	sess := session.GetAdminSession(c)
-	session.SetFlash(c, sess, session.FlashSuccess, "Đã xóa sản phẩm")
+	ids := []string{c.Param("id")}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := deleteProductData(tx, ids); err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Delete(&models.Product{}).Error
+	})
+	if err != nil {
+		session.SetFlash(c, sess, session.FlashError, i18n.T(c, "admin.product.delete_failed", err.Error()))
+		return c.Redirect(http.StatusFound, "/products")
+	}
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.product.deleted"))
 	return c.Redirect(http.StatusFound, "/products")
 }
 
+// deleteProductData removes what only matters while the products ids are
+// for sale: images, translations, old slugs, attributes, tags, wishlist
+// entries, stock subscriptions, unsent emails, views and recommendations.
+// Orders and reviews keep pointing at the soft-deleted products.
+func deleteProductData(tx *gorm.DB, ids []string) error {
+	if err := tx.Where("product_id IN ?", ids).Delete(&models.Image{}).Error; err != nil {
+		return err
+	}
+	args := map[string]any{"ids": ids, "kind": models.SlugProduct, "queued": models.NotificationQueued}
+	related := []struct {
+		model any
+		where string
+	}{
+		{&models.ProductTranslation{}, "product_id IN @ids"},
+		{&models.SlugRedirect{}, "kind = @kind AND target_id IN @ids"},
+		{&models.ProductAttribute{}, "product_id IN @ids"},
+		{&models.Wishlist{}, "product_id IN @ids"},
+		{&models.StockSubscription{}, "product_id IN @ids"},
+		{&models.Notification{}, "product_id IN @ids AND status = @queued"},
+		{&models.RecentView{}, "product_id IN @ids"},
+		{&models.ProductView{}, "product_id IN @ids"},
+		{&models.ProductAffinity{}, "product_id IN @ids OR related_id IN @ids"},
+	}
+	for _, r := range related {
+		if err := tx.Unscoped().Where(r.where, args).Delete(r.model).Error; err != nil {
+			return err
+		}
+	}
+	pages := make([]string, len(ids))
+	for i, id := range ids {
+		pages[i] = models.SEOProductPage(id)
+	}
+	if err := deleteSEOBanners(tx, pages); err != nil {
+		return err
+	}
+	return tx.Exec("DELETE FROM product_tags WHERE product_id IN ?", ids).Error
+}
+
 func ImageDelete(c echo.Context) error {
 	database.DB.Where("id = ?", c.Param("id")).Delete(&models.Image{})
 	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
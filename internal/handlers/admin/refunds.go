@@ -0,0 +1,277 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/internal/notifications"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/money"
+	"shoop-golang/pkg/payments"
+	"shoop-golang/pkg/session"
+	"shoop-golang/pkg/utils"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// errRefund carries the key of a message meant for the admin out of the
+// refund transaction.
+type errRefund string
+
+func (e errRefund) Error() string { return string(e) }
+
+// OrderRefund cancels or refunds quantities of individual order lines. The
+// form posts qty_<order item id> for each line, a reason and whether the
+// goods go back into stock.
+func OrderRefund(c echo.Context) error {
+	orderID := c.Param("id")
+	sess := session.GetAdminSession(c)
+	fail := func(msg string) error {
+		session.SetFlash(c, sess, session.FlashError, msg)
+		return c.Redirect(http.StatusFound, "/orders/"+orderID)
+	}
+
+	reason := strings.TrimSpace(c.FormValue("reason"))
+	if reason == "" {
+		return fail(i18n.T(c, "admin.refund.reason_required"))
+	}
+	restock := c.FormValue("restock") != ""
+
+	var refund models.Refund
+	var send *providerRefund
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Preload("Items").First(&order, "id = ?", orderID).Error; err != nil {
+			return errRefund("order.not_found")
+		}
+		if order.Status == "cancelled" {
+			return errRefund("admin.refund.order_cancelled")
+		}
+		quantities := map[string]int{}
+		for _, item := range order.Items {
+			qty, _ := strconv.Atoi(c.FormValue("qty_" + item.ID))
+			quantities[item.ID] = qty
+		}
+		var err error
+		refund, send, err = cancelLines(tx, &order, quantities, reason, restock)
+		return err
+	})
+	if err != nil {
+		return fail(refundError(c, err))
+	}
+	return refundDone(c, orderID, refund, send)
+}
+
+// refundError is the message for an error out of a refund transaction.
+func refundError(c echo.Context, err error) string {
+	var msg errRefund
+	if errors.As(err, &msg) {
+		return i18n.T(c, string(msg))
+	}
+	return i18n.T(c, "admin.refund.failed", err.Error())
+}
+
+// refundDone sends the provider refund recorded by a committed refund
+// transaction, if any, and reports the outcome.
+func refundDone(c echo.Context, orderID string, refund models.Refund, send *providerRefund) error {
+	sess := session.GetAdminSession(c)
+	msg := i18n.T(c, "admin.refund.done", utils.FormatPrice(refund.Amount))
+	if send != nil {
+		result, err := send.send(database.DB)
+		if err != nil {
+			session.SetFlash(c, sess, session.FlashError, i18n.T(c, "admin.refund.provider_failed", utils.FormatPrice(send.payment.Amount), err.Error()))
+			return c.Redirect(http.StatusFound, "/orders/"+orderID)
+		}
+		if result.Manual {
+			msg = i18n.T(c, "admin.refund.manual", utils.FormatPrice(refund.Amount), utils.FormatPrice(send.payment.Amount))
+		}
+	}
+	session.SetFlash(c, sess, session.FlashSuccess, msg)
+	return c.Redirect(http.StatusFound, "/orders/"+orderID)
+}
+
+// cancelLines takes quantities, keyed by order item ID, off the lines of
+// order within tx, puts them back in stock when restock is set and records
+// the refund. An order with nothing left is cancelled. A refund owed
+// through the payment provider is recorded as pending and returned, to be
+// sent once tx has committed.
+func cancelLines(tx *gorm.DB, order *models.Order, quantities map[string]int, reason string, restock bool) (models.Refund, *providerRefund, error) {
+	refund := models.Refund{OrderID: order.ID, Reason: reason, Restocked: restock}
+	var remaining int
+	for i := range order.Items {
+		item := &order.Items[i]
+		qty := quantities[item.ID]
+		if qty < 0 || qty > item.Quantity {
+			return refund, nil, errRefund("admin.refund.invalid_quantity")
+		}
+		remaining += item.Quantity - qty
+		if qty == 0 {
+			continue
+		}
+		item.Quantity -= qty
+		item.CancelledQuantity += qty
+		if err := tx.Model(item).Updates(map[string]any{
+			"quantity": item.Quantity, "cancelled_quantity": item.CancelledQuantity,
+		}).Error; err != nil {
+			return refund, nil, err
+		}
+		if restock {
+			err := notifications.Track(tx, []string{item.ProductID}, func() error {
+				return tx.Model(&models.Product{}).Where("id = ?", item.ProductID).
+					UpdateColumns(models.StockChange(qty)).Error
+			})
+			if err != nil {
+				return refund, nil, err
+			}
+		}
+		refund.Items = append(refund.Items, models.RefundItem{
+			OrderItemID: item.ID,
+			Quantity:    qty,
+			Amount:      item.Price.Mul(qty),
+		})
+	}
+	if len(refund.Items) == 0 {
+		return refund, nil, errRefund("admin.refund.select_one")
+	}
+
+	previous := order.TotalAmount
+	recomputeOrderTotals(order, remaining == 0)
+	refund.Amount = previous - order.TotalAmount
+	order.RefundedAmount += refund.Amount
+
+	send, err := refundPayment(tx, order, &refund)
+	if err != nil {
+		return refund, nil, err
+	}
+	if err := tx.Create(&refund).Error; err != nil {
+		return refund, nil, err
+	}
+	return refund, send, tx.Model(order).Updates(map[string]any{
+		"status":          order.Status,
+		"subtotal":        order.Subtotal,
+		"shipping_fee":    order.ShippingFee,
+		"discount":        order.Discount,
+		"total_amount":    order.TotalAmount,
+		"refunded_amount": order.RefundedAmount,
+		"payment_status":  order.PaymentStatus,
+	}).Error
+}
+
+// recomputeOrderTotals recalculates the order after its lines changed. An
+// order with nothing left is cancelled and its shipping fee dropped too.
+func recomputeOrderTotals(order *models.Order, empty bool) {
+	order.Subtotal = 0
+	for _, item := range order.Items {
+		order.Subtotal += item.Subtotal()
+	}
+	if empty {
+		order.Status = "cancelled"
+		order.ShippingFee = 0
+	}
+	if order.Discount > order.Subtotal {
+		order.Discount = order.Subtotal
+	}
+	order.TotalAmount = max(order.Subtotal+order.ShippingFee-order.Discount, 0)
+}
+
+// providerRefund is a refund payment recorded as pending, to be sent to
+// the provider once the refund has been committed. Sending it from inside
+// the transaction could give money back that a rollback then forgets.
+type providerRefund struct {
+	provider payments.Provider
+	chargeID string // transaction ID of the charge refunded
+	payment  *models.Payment
+}
+
+// send asks the provider for the refund and records its answer. A failed
+// refund stays on the order as failed for staff to settle by hand.
+func (r *providerRefund) send(db *gorm.DB) (payments.RefundResult, error) {
+	result, err := r.provider.Refund(r.chargeID, r.payment.Amount)
+	if err != nil {
+		db.Model(r.payment).Update("status", payments.StatusFailed)
+		db.Model(&models.Order{}).Where("id = ? AND payment_status = ?", r.payment.OrderID, models.PaymentRefunded).
+			Update("payment_status", models.PaymentPaid)
+		return result, err
+	}
+	updates := map[string]any{"status": result.Status}
+	if result.TransactionID != "" {
+		updates["transaction_id"] = result.TransactionID
+	}
+	return result, db.Model(r.payment).Updates(updates).Error
+}
+
+// refundPayment gives back whatever the customer paid beyond the new total
+// through the order's payment provider, returning the refund to send once
+// tx has committed. Pending charges of an unpaid order are re-issued for the
+// new total instead, so the customer is asked for the right amount.
+func refundPayment(tx *gorm.DB, order *models.Order, refund *models.Refund) (*providerRefund, error) {
+	provider, ok := payments.Get(order.PaymentMethod)
+
+	var paid, refunded money.Money
+	tx.Model(&models.Payment{}).Where("order_id = ? AND kind = ? AND status = ?", order.ID, models.PaymentCharge, payments.StatusSucceeded).
+		Select("COALESCE(SUM(amount), 0)").Scan(&paid)
+	tx.Model(&models.Payment{}).Where("order_id = ? AND kind = ? AND status <> ?", order.ID, models.PaymentRefund, payments.StatusFailed).
+		Select("COALESCE(SUM(amount), 0)").Scan(&refunded)
+
+	if owed := paid - refunded - order.TotalAmount; owed > 0 {
+		if !ok {
+			return nil, errRefund("admin.refund.provider_inactive")
+		}
+		var charge models.Payment
+		if err := tx.Where("order_id = ? AND kind = ? AND status = ?", order.ID, models.PaymentCharge, payments.StatusSucceeded).
+			Order("created_at DESC").First(&charge).Error; err != nil {
+			return nil, err
+		}
+		payment := models.Payment{
+			OrderID:   order.ID,
+			Provider:  provider.Code(),
+			Kind:      models.PaymentRefund,
+			Status:    payments.StatusPending,
+			Amount:    owed,
+			Reference: order.Number,
+			Payload:   refund.Reason,
+		}
+		if err := models.RecordPayment(tx, &payment); err != nil {
+			return nil, err
+		}
+		refund.PaymentID = &payment.ID
+		refund.Payment = &payment
+		if order.TotalAmount == 0 {
+			order.PaymentStatus = models.PaymentRefunded
+		}
+		return &providerRefund{provider: provider, chargeID: charge.TransactionID, payment: &payment}, nil
+	}
+
+	if order.PaymentStatus == models.PaymentPaid || !ok {
+		return nil, nil
+	}
+	var pending []models.Payment
+	tx.Where("order_id = ? AND kind = ? AND status = ?", order.ID, models.PaymentCharge, payments.StatusPending).Find(&pending)
+	for _, p := range pending {
+		if order.TotalAmount == 0 {
+			if err := tx.Model(&p).Update("status", payments.StatusFailed).Error; err != nil {
+				return nil, err
+			}
+			continue
+		}
+		instruction, err := provider.Initiate(payments.Request{
+			OrderID:     order.ID,
+			OrderNumber: order.Number,
+			Amount:      order.TotalAmount,
+		})
+		if err != nil {
+			return nil, err
+		}
+		payload, _ := json.Marshal(instruction)
+		if err := tx.Model(&p).Updates(map[string]any{"amount": order.TotalAmount, "payload": string(payload)}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,118 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/session"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// ReviewList is the moderation queue: pending reviews unless another status
+// (or "all") is picked.
+func ReviewList(c echo.Context) error {
+	data := adminData(c)
+	data["Title"] = i18n.T(c, "admin.page.reviews")
+	data["Active"] = "reviews"
+
+	status := c.QueryParam("status")
+	if status == "" {
+		status = models.ReviewPending
+	}
+	query := database.DB.Model(&models.Review{})
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	query, pagination := listQuery(c, query, ListOptions{
+		SearchColumns: []string{"title", "body"},
+		SortColumns: map[string]string{
+			"created_at": "created_at",
+			"rating":     "rating",
+		},
+		DefaultSort: "created_at",
+		DefaultDir:  "desc",
+		DateColumn:  "created_at",
+	})
+
+	var reviews []models.Review
+	query.Preload("Product").Preload("User").
+		Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order ASC") }).
+		Find(&reviews)
+	data["Reviews"] = reviews
+	data["FilterStatus"] = status
+	data["Pagination"] = pagination
+
+	return c.Render(http.StatusOK, "admin/reviews/index", data)
+}
+
+// ReviewUpdateStatus approves or rejects a review and refreshes the rating
+// cached on its product.
+func ReviewUpdateStatus(c echo.Context) error {
+	sess := session.GetAdminSession(c)
+	redirect := reviewReturn(c)
+
+	status := c.FormValue("status")
+	if status != models.ReviewApproved && status != models.ReviewRejected {
+		return c.Redirect(http.StatusFound, redirect)
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var review models.Review
+		if err := tx.First(&review, "id = ?", c.Param("id")).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&review).Update("status", status).Error; err != nil {
+			return err
+		}
+		return models.RefreshRating(tx, review.ProductID)
+	})
+	if err != nil {
+		session.SetFlash(c, sess, session.FlashError, i18n.T(c, "admin.review.update_failed", err.Error()))
+		return c.Redirect(http.StatusFound, redirect)
+	}
+
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.review."+status))
+	return c.Redirect(http.StatusFound, redirect)
+}
+
+// ReviewReply saves the shop's public answer to a review; an empty reply
+// removes it.
+func ReviewReply(c echo.Context) error {
+	sess := session.GetAdminSession(c)
+	redirect := reviewReturn(c)
+
+	var review models.Review
+	if err := database.DB.First(&review, "id = ?", c.Param("id")).Error; err != nil {
+		return c.Redirect(http.StatusFound, redirect)
+	}
+
+	reply := strings.TrimSpace(c.FormValue("reply"))
+	var repliedAt *time.Time
+	if reply != "" {
+		now := time.Now()
+		repliedAt = &now
+	}
+	if err := database.DB.Model(&review).Updates(map[string]any{"reply": reply, "replied_at": repliedAt}).Error; err != nil {
+		session.SetFlash(c, sess, session.FlashError, i18n.T(c, "admin.review.update_failed", err.Error()))
+		return c.Redirect(http.StatusFound, redirect)
+	}
+
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.review.replied"))
+	return c.Redirect(http.StatusFound, redirect)
+}
+
+// reviewReturn is the queue page the moderation forms were posted from.
+func reviewReturn(c echo.Context) string {
+	redirect := "/reviews"
+	if ret := c.FormValue("return"); strings.HasPrefix(ret, "?") {
+		redirect += ret
+	}
+	return redirect
+}
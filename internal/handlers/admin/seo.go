@@ -0,0 +1,274 @@
+package admin
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/session"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// seoEntry is an SEOBanner with a readable name for the page it targets.
+type seoEntry struct {
+	models.SEOBanner
+	Label string
+}
+
+func SEOList(c echo.Context) error {
+	data := adminData(c)
+	data["Title"] = i18n.T(c, "admin.page.seo")
+	data["Active"] = "seo"
+
+	var banners []models.SEOBanner
+	database.DB.Order("page ASC").Find(&banners)
+	byPage := make(map[string]models.SEOBanner, len(banners))
+	for _, b := range banners {
+		byPage[b.Page] = b
+	}
+
+	// Every page is listed, configured or not; overrides only once set.
+	all := slices.Clone(models.SEOPages)
+	for _, b := range banners {
+		all = append(all, b.Page)
+	}
+	labels := seoLabels(c, all)
+	var pages, overrides []seoEntry
+	for _, page := range models.SEOPages {
+		pages = append(pages, seoEntry{SEOBanner: byPage[page], Label: labels[page]})
+	}
+	for _, b := range banners {
+		if !slices.Contains(models.SEOPages, b.Page) {
+			overrides = append(overrides, seoEntry{SEOBanner: b, Label: labels[b.Page]})
+		}
+	}
+	data["Pages"] = pages
+	data["Overrides"] = overrides
+
+	var robots models.RobotsConfig
+	database.DB.First(&robots)
+	if robots.Rules == "" {
+		robots.Rules = models.DefaultRobotsRules
+	}
+	data["Robots"] = robots
+
+	return c.Render(http.StatusOK, "admin/seo/index", data)
+}
+
+func SEOCreate(c echo.Context) error {
+	page := c.QueryParam("page")
+	var existing models.SEOBanner
+	if database.DB.First(&existing, "page = ?", page).Error == nil {
+		return c.Redirect(http.StatusFound, "/seo/"+existing.ID+"/edit")
+	}
+	return renderSEOForm(c, models.SEOBanner{Page: page}, false, "")
+}
+
+func SEOStore(c echo.Context) error {
+	banner := models.SEOBanner{Page: strings.TrimSpace(c.FormValue("page"))}
+	bindSEOBanner(c, &banner)
+
+	if msg := validateSEOBanner(banner); msg != "" {
+		return renderSEOForm(c, banner, false, i18n.T(c, msg))
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&banner).Error; err != nil {
+			return err
+		}
+		return replaceTranslations(tx, "seo_banner_id", banner.ID, seoTranslations(c, banner))
+	})
+	if err != nil {
+		return renderSEOForm(c, banner, false, i18n.T(c, "admin.seo.save_failed", err.Error()))
+	}
+
+	sess := session.GetAdminSession(c)
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.seo.saved"))
+	return c.Redirect(http.StatusFound, "/seo")
+}
+
+func SEOEdit(c echo.Context) error {
+	var banner models.SEOBanner
+	if err := database.DB.Preload("Translations").First(&banner, "id = ?", c.Param("id")).Error; err != nil {
+		return c.Redirect(http.StatusFound, "/seo")
+	}
+	return renderSEOForm(c, banner, true, "")
+}
+
+func SEOUpdate(c echo.Context) error {
+	var banner models.SEOBanner
+	if err := database.DB.First(&banner, "id = ?", c.Param("id")).Error; err != nil {
+		return c.Redirect(http.StatusFound, "/seo")
+	}
+	bindSEOBanner(c, &banner)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&banner).Error; err != nil {
+			return err
+		}
+		return replaceTranslations(tx, "seo_banner_id", banner.ID, seoTranslations(c, banner))
+	})
+	if err != nil {
+		return renderSEOForm(c, banner, true, i18n.T(c, "admin.seo.save_failed", err.Error()))
+	}
+
+	sess := session.GetAdminSession(c)
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.seo.saved"))
+	return c.Redirect(http.StatusFound, "/seo")
+}
+
+// SEODelete removes an entry, so the page goes back to the default tags.
+func SEODelete(c echo.Context) error {
+	database.DB.Transaction(func(tx *gorm.DB) error {
+		tx.Unscoped().Where("seo_banner_id = ?", c.Param("id")).Delete(&models.SEOBannerTranslation{})
+		return tx.Unscoped().Where("id = ?", c.Param("id")).Delete(&models.SEOBanner{}).Error
+	})
+	sess := session.GetAdminSession(c)
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.seo.deleted"))
+	return c.Redirect(http.StatusFound, "/seo")
+}
+
+// deleteSEOBanners removes the entries for pages and their translations,
+// for pages that no longer exist.
+func deleteSEOBanners(tx *gorm.DB, pages []string) error {
+	entries := tx.Model(&models.SEOBanner{}).Select("id").Where("page IN ?", pages)
+	if err := tx.Unscoped().Where("seo_banner_id IN (?)", entries).Delete(&models.SEOBannerTranslation{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("page IN ?", pages).Delete(&models.SEOBanner{}).Error
+}
+
+// SEORobotsUpdate saves the rules served at /robots.txt. Emptying the
+// field restores the defaults.
+func SEORobotsUpdate(c echo.Context) error {
+	var robots models.RobotsConfig
+	database.DB.First(&robots)
+	robots.Rules = strings.TrimSpace(strings.ReplaceAll(c.FormValue("rules"), "\r\n", "\n"))
+
+	sess := session.GetAdminSession(c)
+	if err := database.DB.Save(&robots).Error; err != nil {
+		session.SetFlash(c, sess, session.FlashError, i18n.T(c, "admin.seo.save_failed", err.Error()))
+		return c.Redirect(http.StatusFound, "/seo")
+	}
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.seo.robots_saved"))
+	return c.Redirect(http.StatusFound, "/seo#robots")
+}
+
+func renderSEOForm(c echo.Context, banner models.SEOBanner, isEdit bool, errMsg string) error {
+	data := adminData(c)
+	data["Title"] = i18n.T(c, "admin.page.seo_edit")
+	data["Active"] = "seo"
+	data["IsEdit"] = isEdit
+	data["Banner"] = banner
+	data["PageLabel"] = seoLabel(c, banner.Page)
+	if errMsg != "" {
+		data["Error"] = errMsg
+	}
+	return c.Render(http.StatusOK, "admin/seo/form", data)
+}
+
+// seoTargetLimit caps the matches SEOTargets returns for each kind.
+const seoTargetLimit = 10
+
+// seoTarget is a category or product an override can be added for.
+type seoTarget struct {
+	Page  string `json:"page"`
+	Label string `json:"label"`
+}
+
+// SEOTargets returns the categories and products whose name matches ?q=,
+// for the picker that adds an override.
+func SEOTargets(c echo.Context) error {
+	q := "%" + strings.TrimSpace(c.QueryParam("q")) + "%"
+	targets := []seoTarget{}
+
+	var categories []models.Category
+	database.DB.Select("id", "name").Where("name LIKE ?", q).Order("name ASC").Limit(seoTargetLimit).Find(&categories)
+	for _, cat := range categories {
+		targets = append(targets, seoTarget{Page: models.SEOCategoryPage(cat.ID), Label: i18n.T(c, "admin.seo.category_page", cat.Name)})
+	}
+	var products []models.Product
+	database.DB.Select("id", "name").Where("name LIKE ?", q).Order("name ASC").Limit(seoTargetLimit).Find(&products)
+	for _, product := range products {
+		targets = append(targets, seoTarget{Page: models.SEOProductPage(product.ID), Label: i18n.T(c, "admin.seo.product_page", product.Name)})
+	}
+	return c.JSON(http.StatusOK, targets)
+}
+
+func bindSEOBanner(c echo.Context, b *models.SEOBanner) {
+	b.Title = strings.TrimSpace(c.FormValue("title"))
+	b.Description = strings.TrimSpace(c.FormValue("description"))
+	b.Keywords = strings.TrimSpace(c.FormValue("keywords"))
+	b.OGImage = strings.TrimSpace(c.FormValue("og_image"))
+}
+
+// validateSEOBanner returns a message key when b cannot be saved: its page
+// must exist and not have an entry yet.
+func validateSEOBanner(b models.SEOBanner) string {
+	kind, id, _ := strings.Cut(b.Page, ":")
+	var found int64
+	switch {
+	case slices.Contains(models.SEOPages, b.Page):
+		found = 1
+	case kind == "category":
+		database.DB.Model(&models.Category{}).Where("id = ?", id).Count(&found)
+	case kind == "product":
+		database.DB.Model(&models.Product{}).Where("id = ?", id).Count(&found)
+	}
+	if found == 0 {
+		return "admin.seo.invalid_page"
+	}
+	var count int64
+	database.DB.Model(&models.SEOBanner{}).Where("page = ?", b.Page).Count(&count)
+	if count > 0 {
+		return "admin.seo.page_taken"
+	}
+	return ""
+}
+
+// seoLabel names the page an entry targets.
+func seoLabel(c echo.Context, page string) string {
+	return seoLabels(c, []string{page})[page]
+}
+
+// seoLabels names the pages entries target, looking up the categories and
+// products among them with one query each.
+func seoLabels(c echo.Context, pages []string) map[string]string {
+	ids := map[string][]string{}
+	for _, page := range pages {
+		if kind, id, ok := strings.Cut(page, ":"); ok {
+			ids[kind] = append(ids[kind], id)
+		}
+	}
+	names := map[string]string{}
+	if len(ids["category"]) > 0 {
+		var categories []models.Category
+		database.DB.Unscoped().Select("id", "name").Where("id IN ?", ids["category"]).Find(&categories)
+		for _, cat := range categories {
+			names[models.SEOCategoryPage(cat.ID)] = cat.Name
+		}
+	}
+	if len(ids["product"]) > 0 {
+		var products []models.Product
+		database.DB.Unscoped().Select("id", "name").Where("id IN ?", ids["product"]).Find(&products)
+		for _, product := range products {
+			names[models.SEOProductPage(product.ID)] = product.Name
+		}
+	}
+
+	labels := make(map[string]string, len(pages))
+	for _, page := range pages {
+		kind, _, _ := strings.Cut(page, ":")
+		switch {
+		case kind == "category" || kind == "product":
+			labels[page] = i18n.T(c, "admin.seo."+kind+"_page", names[page])
+		case page != "":
+			labels[page] = i18n.T(c, "admin.seo.page."+page)
+		}
+	}
+	return labels
+}
@@ -0,0 +1,192 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/money"
+	"shoop-golang/pkg/session"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+func ShippingList(c echo.Context) error {
+	data := adminData(c)
+	data["Title"] = i18n.T(c, "admin.page.shipping")
+	data["Active"] = "shipping"
+
+	var methods []models.ShippingMethod
+	database.DB.Preload("Rules", func(db *gorm.DB) *gorm.DB {
+		return db.Order("province ASC")
+	}).Order("sort_order ASC").Find(&methods)
+	data["Methods"] = methods
+
+	return c.Render(http.StatusOK, "admin/shipping/index", data)
+}
+
+func ShippingCreate(c echo.Context) error {
+	data := adminData(c)
+	data["Title"] = i18n.T(c, "admin.page.shipping_create")
+	data["Active"] = "shipping"
+	data["Method"] = models.ShippingMethod{IsActive: true, Rules: []models.ShippingRule{{}}}
+	data["Provinces"] = provinceNames()
+	return c.Render(http.StatusOK, "admin/shipping/form", data)
+}
+
+func ShippingStore(c echo.Context) error {
+	method := models.ShippingMethod{Rules: parseShippingRules(c)}
+	bindShippingMethod(c, &method)
+
+	if msg := validateShippingMethod(method); msg != "" {
+		return renderShippingForm(c, method, false, i18n.T(c, msg))
+	}
+	if err := database.DB.Create(&method).Error; err != nil {
+		return renderShippingForm(c, method, false, i18n.T(c, "admin.shipping.create_failed", err.Error()))
+	}
+
+	sess := session.GetAdminSession(c)
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.shipping.created"))
+	return c.Redirect(http.StatusFound, "/shipping")
+}
+
+func ShippingEdit(c echo.Context) error {
+	var method models.ShippingMethod
+	if err := database.DB.Preload("Rules", func(db *gorm.DB) *gorm.DB {
+		return db.Order("province ASC")
+	}).First(&method, "id = ?", c.Param("id")).Error; err != nil {
+		return c.Redirect(http.StatusFound, "/shipping")
+	}
+	return renderShippingForm(c, method, true, "")
+}
+
+func ShippingUpdate(c echo.Context) error {
+	var method models.ShippingMethod
+	if err := database.DB.First(&method, "id = ?", c.Param("id")).Error; err != nil {
+		return c.Redirect(http.StatusFound, "/shipping")
+	}
+	bindShippingMethod(c, &method)
+	rules := parseShippingRules(c)
+
+	if msg := validateShippingMethod(method); msg != "" {
+		method.Rules = rules
+		return renderShippingForm(c, method, true, i18n.T(c, msg))
+	}
+
+	// Rules have no identity of their own, so the submitted set replaces
+	// the stored one.
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&method).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("method_id = ?", method.ID).Delete(&models.ShippingRule{}).Error; err != nil {
+			return err
+		}
+		for i := range rules {
+			rules[i].MethodID = method.ID
+		}
+		if len(rules) > 0 {
+			return tx.Create(&rules).Error
+		}
+		return nil
+	})
+	if err != nil {
+		method.Rules = rules
+		return renderShippingForm(c, method, true, i18n.T(c, "admin.shipping.update_failed", err.Error()))
+	}
+
+	sess := session.GetAdminSession(c)
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.shipping.updated"))
+	return c.Redirect(http.StatusFound, "/shipping")
+}
+
+func ShippingDelete(c echo.Context) error {
+	database.DB.Transaction(func(tx *gorm.DB) error {
+		tx.Where("method_id = ?", c.Param("id")).Delete(&models.ShippingRule{})
+		return tx.Where("id = ?", c.Param("id")).Delete(&models.ShippingMethod{}).Error
+	})
+	sess := session.GetAdminSession(c)
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "admin.shipping.deleted"))
+	return c.Redirect(http.StatusFound, "/shipping")
+}
+
+func renderShippingForm(c echo.Context, method models.ShippingMethod, isEdit bool, errMsg string) error {
+	data := adminData(c)
+	data["Title"] = i18n.T(c, "admin.page.shipping_create")
+	if isEdit {
+		data["Title"] = i18n.T(c, "admin.page.shipping_edit")
+		data["IsEdit"] = true
+	}
+	data["Active"] = "shipping"
+	data["Method"] = method
+	data["Provinces"] = provinceNames()
+	if errMsg != "" {
+		data["Error"] = errMsg
+	}
+	return c.Render(http.StatusOK, "admin/shipping/form", data)
+}
+
+func bindShippingMethod(c echo.Context, m *models.ShippingMethod) {
+	sortOrder, _ := strconv.Atoi(c.FormValue("sort_order"))
+	m.Code = strings.ToLower(strings.TrimSpace(c.FormValue("code")))
+	m.Name = strings.TrimSpace(c.FormValue("name"))
+	m.Description = c.FormValue("description")
+	m.SortOrder = sortOrder
+	m.IsActive = c.FormValue("is_active") == "on"
+}
+
+// validateShippingMethod returns a message key when m cannot be saved.
+func validateShippingMethod(m models.ShippingMethod) string {
+	if m.Code == "" || m.Name == "" {
+		return "admin.shipping.required"
+	}
+	var count int64
+	database.DB.Model(&models.ShippingMethod{}).Where("code = ? AND id <> ?", m.Code, m.ID).Count(&count)
+	if count > 0 {
+		return "admin.shipping.code_taken"
+	}
+	return ""
+}
+
+// provinceNames suggests destinations for rules; rules match orders by the
+// province name from the division dataset.
+func provinceNames() []models.Province {
+	var provinces []models.Province
+	database.DB.Select("code", "name").Order("code ASC").Find(&provinces)
+	return provinces
+}
+
+// parseShippingRules reads the rule rows of the form, submitted as parallel
+// rule_* arrays with one entry per row.
+func parseShippingRules(c echo.Context) []models.ShippingRule {
+	form, _ := c.FormParams()
+	provinces := form["rule_province"]
+	field := func(name string, i int) string {
+		if v := form[name]; i < len(v) {
+			return strings.TrimSpace(v[i])
+		}
+		return ""
+	}
+
+	rules := make([]models.ShippingRule, 0, len(provinces))
+	for i := range provinces {
+		baseFee, _ := money.Parse(field("rule_base_fee", i))
+		perKg, _ := money.Parse(field("rule_per_kg_fee", i))
+		freeOver, _ := money.Parse(field("rule_free_over", i))
+		included, _ := strconv.Atoi(field("rule_included_weight", i))
+		maxWeight, _ := strconv.Atoi(field("rule_max_weight", i))
+		rules = append(rules, models.ShippingRule{
+			Province:       field("rule_province", i),
+			BaseFee:        baseFee,
+			IncludedWeight: included,
+			PerKgFee:       perKg,
+			MaxWeight:      maxWeight,
+			FreeOver:       freeOver,
+		})
+	}
+	return rules
+}
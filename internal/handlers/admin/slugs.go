@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"strconv"
+
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// Slugs are typed into the form or built from the name when a product or
+// category is created, then kept until the admin edits them: renaming
+// does not move the page. A slug that changes leaves a models.SlugRedirect
+// behind so old links still work.
+
+// slugScope is where a slug has to be unique: the slug column of a model,
+// or one locale of a translation table.
+type slugScope struct {
+	kind   string // models.SlugProduct, models.SlugCategory
+	model  any
+	owner  string // the column holding the owner's ID
+	locale string
+}
+
+func productSlugs() slugScope {
+	return slugScope{kind: models.SlugProduct, model: &models.Product{}, owner: "id", locale: i18n.Default}
+}
+
+func categorySlugs() slugScope {
+	return slugScope{kind: models.SlugCategory, model: &models.Category{}, owner: "id", locale: i18n.Default}
+}
+
+// taken reports whether another row uses slug. Deleted rows count, as the
+// unique index still holds them.
+func (s slugScope) taken(tx *gorm.DB, slug, ownerID string) bool {
+	var count int64
+	q := tx.Unscoped().Model(s.model).Where("slug = ? AND "+s.owner+" <> ?", slug, ownerID)
+	if s.owner != "id" {
+		q = q.Where("locale = ?", s.locale)
+	}
+	q.Count(&count)
+	return count > 0
+}
+
+// unique returns slug, or slug-2, slug-3… when it is taken.
+func (s slugScope) unique(tx *gorm.DB, slug, ownerID string) string {
+	if slug == "" {
+		slug = s.kind
+	}
+	candidate := slug
+	for n := 2; s.taken(tx, candidate, ownerID); n++ {
+		candidate = slug + "-" + strconv.Itoa(n)
+	}
+	return candidate
+}
+
+// settle makes *slug the owner's unique slug in place of old, which then
+// redirects to it. An empty *slug keeps old.
+func (s slugScope) settle(tx *gorm.DB, ownerID, old string, slug *string) error {
+	if *slug == "" {
+		*slug = old
+	}
+	if *slug != "" && *slug == old {
+		return nil
+	}
+	*slug = s.unique(tx, *slug, ownerID)
+
+	// The new slug is live now, so an older redirect from it goes.
+	if err := tx.Unscoped().Where("kind = ? AND locale = ? AND slug = ?", s.kind, s.locale, *slug).Delete(&models.SlugRedirect{}).Error; err != nil {
+		return err
+	}
+	if old == "" || ownerID == "" {
+		return nil
+	}
+	if err := tx.Unscoped().Where("kind = ? AND locale = ? AND slug = ?", s.kind, s.locale, old).Delete(&models.SlugRedirect{}).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.SlugRedirect{Kind: s.kind, Locale: s.locale, Slug: old, TargetID: ownerID}).Error
+}
+
+// settleProductSlugs settles the slugs of a product's translations. One
+// left empty keeps the translation's current slug, or else is built from
+// its name or the product's slug.
+func settleProductSlugs(tx *gorm.DB, product models.Product, rows []models.ProductTranslation) error {
+	var existing []models.ProductTranslation
+	tx.Where("product_id = ?", product.ID).Find(&existing)
+	old := make(map[string]string, len(existing))
+	for _, t := range existing {
+		old[t.Locale] = t.Slug
+	}
+	for i := range rows {
+		t := &rows[i]
+		if t.Slug == "" && old[t.Locale] == "" {
+			t.Slug = translationSlug(t.Name, product.Slug)
+		}
+		scope := slugScope{kind: models.SlugProduct, model: &models.ProductTranslation{}, owner: "product_id", locale: t.Locale}
+		if err := scope.settle(tx, product.ID, old[t.Locale], &t.Slug); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func settleCategorySlugs(tx *gorm.DB, cat models.Category, rows []models.CategoryTranslation) error {
+	var existing []models.CategoryTranslation
+	tx.Where("category_id = ?", cat.ID).Find(&existing)
+	old := make(map[string]string, len(existing))
+	for _, t := range existing {
+		old[t.Locale] = t.Slug
+	}
+	for i := range rows {
+		t := &rows[i]
+		if t.Slug == "" && old[t.Locale] == "" {
+			t.Slug = translationSlug(t.Name, cat.Slug)
+		}
+		scope := slugScope{kind: models.SlugCategory, model: &models.CategoryTranslation{}, owner: "category_id", locale: t.Locale}
+		if err := scope.settle(tx, cat.ID, old[t.Locale], &t.Slug); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formSlug is the slug typed into the form, or else one built from name.
+func formSlug(field, name string) string {
+	if slug := utils.Slugify(field); slug != "" {
+		return slug
+	}
+	return utils.Slugify(name)
+}
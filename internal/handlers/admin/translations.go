@@ -0,0 +1,128 @@
+package admin
+
+import (
+	"strings"
+
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/utils"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// Forms carry one tab per translated locale with fields named
+// <field>_<locale> (name_en). A locale whose fields are all empty has no
+// translation, and the storefront shows the default text.
+
+func translatedValue(c echo.Context, field, locale string) string {
+	return strings.TrimSpace(c.FormValue(field + "_" + locale))
+}
+
+// translationSlug is the slug of a translated name, or the default slug
+// when the name is not translated.
+func translationSlug(name, fallback string) string {
+	if slug := utils.Slugify(name); slug != "" {
+		return slug
+	}
+	return fallback
+}
+
+func productTranslations(c echo.Context, product models.Product) []models.ProductTranslation {
+	var out []models.ProductTranslation
+	for _, locale := range i18n.Translated() {
+		t := models.ProductTranslation{
+			ProductID:   product.ID,
+			Locale:      locale,
+			Name:        translatedValue(c, "name", locale),
+			Slug:        utils.Slugify(translatedValue(c, "slug", locale)),
+			Description: translatedValue(c, "description", locale),
+			Content:     translatedValue(c, "content", locale),
+		}
+		if t.Name == "" && t.Description == "" && t.Content == "" && t.Slug == "" {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func categoryTranslations(c echo.Context, cat models.Category) []models.CategoryTranslation {
+	var out []models.CategoryTranslation
+	for _, locale := range i18n.Translated() {
+		t := models.CategoryTranslation{
+			CategoryID:  cat.ID,
+			Locale:      locale,
+			Name:        translatedValue(c, "name", locale),
+			Slug:        utils.Slugify(translatedValue(c, "slug", locale)),
+			Description: translatedValue(c, "description", locale),
+		}
+		if t.Name == "" && t.Description == "" && t.Slug == "" {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func bannerTranslations(c echo.Context, banner models.Banner) []models.BannerTranslation {
+	var out []models.BannerTranslation
+	for _, locale := range i18n.Translated() {
+		t := models.BannerTranslation{
+			BannerID: banner.ID,
+			Locale:   locale,
+			Title:    translatedValue(c, "title", locale),
+			Subtitle: translatedValue(c, "subtitle", locale),
+		}
+		if t.Title != "" || t.Subtitle != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func aboutTranslations(c echo.Context, about models.AboutPage) []models.AboutPageTranslation {
+	var out []models.AboutPageTranslation
+	for _, locale := range i18n.Translated() {
+		t := models.AboutPageTranslation{
+			AboutPageID: about.ID,
+			Locale:      locale,
+			Title:       translatedValue(c, "title", locale),
+			Content:     translatedValue(c, "content", locale),
+		}
+		if t.Title != "" || t.Content != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func seoTranslations(c echo.Context, b models.SEOBanner) []models.SEOBannerTranslation {
+	var out []models.SEOBannerTranslation
+	for _, locale := range i18n.Translated() {
+		t := models.SEOBannerTranslation{
+			SEOBannerID: b.ID,
+			Locale:      locale,
+			Title:       translatedValue(c, "title", locale),
+			Description: translatedValue(c, "description", locale),
+			Keywords:    translatedValue(c, "keywords", locale),
+		}
+		if t.Title != "" || t.Description != "" || t.Keywords != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// replaceTranslations swaps the stored translations of the row whose
+// foreign key column fk is ownerID for rows. Like shipping rules, they have
+// no identity of their own.
+func replaceTranslations[T any](tx *gorm.DB, fk, ownerID string, rows []T) error {
+	if err := tx.Unscoped().Where(fk+" = ?", ownerID).Delete(new(T)).Error; err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
@@ -5,25 +5,39 @@ import (
 
 	"shoop-golang/database"
 	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
 
 	"github.com/labstack/echo/v4"
 )
 
 func UserList(c echo.Context) error {
 	data := adminData(c)
-	data["Title"] = "Khách hàng"
+	data["Title"] = i18n.T(c, "admin.page.users")
 	data["Active"] = "users"
 
+	query, pagination := listQuery(c, database.DB.Model(&models.User{}), ListOptions{
+		SearchColumns: []string{"name", "email", "phone"},
+		SortColumns: map[string]string{
+			"name":       "name",
+			"email":      "email",
+			"created_at": "created_at",
+		},
+		DefaultSort: "created_at",
+		DefaultDir:  "desc",
+		DateColumn:  "created_at",
+	})
+
 	var users []models.User
-	database.DB.Order("created_at DESC").Find(&users)
+	query.Find(&users)
 	data["Users"] = users
+	data["Pagination"] = pagination
 
 	return c.Render(http.StatusOK, "admin/users/index", data)
 }
 
 func UserDetail(c echo.Context) error {
 	data := adminData(c)
-	data["Title"] = "Chi tiết khách hàng"
+	data["Title"] = i18n.T(c, "admin.page.user_detail")
 	data["Active"] = "users"
 
 	var user models.User
@@ -32,5 +46,9 @@ func UserDetail(c echo.Context) error {
 	}
 	data["User"] = user
 
+	var addresses []models.UserAddress
+	database.DB.Where("user_id = ?", user.ID).Order("is_default DESC, created_at DESC").Find(&addresses)
+	data["Addresses"] = addresses
+
 	return c.Render(http.StatusOK, "admin/users/detail", data)
 }
@@ -0,0 +1,207 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/session"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+func AddressList(c echo.Context) error {
+	userID, _ := c.Get("user_id").(string)
+	data := webData(c)
+	data["Title"] = i18n.T(c, "page.addresses")
+	data["Addresses"] = userAddresses(userID)
+	data["Address"] = models.UserAddress{}
+	data["Provinces"] = allProvinces()
+	return c.Render(http.StatusOK, "web/account/addresses", data)
+}
+
+func AddressStore(c echo.Context) error {
+	userID, _ := c.Get("user_id").(string)
+	addr := models.UserAddress{UserID: userID}
+	bindAddress(c, &addr)
+
+	if msg := validateAddress(&addr); msg != "" {
+		return renderAddressList(c, addr, msg)
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		tx.Model(&models.UserAddress{}).Where("user_id = ?", userID).Count(&count)
+		if count == 0 {
+			addr.IsDefault = true
+		}
+		if err := tx.Create(&addr).Error; err != nil {
+			return err
+		}
+		if addr.IsDefault {
+			return clearOtherDefaults(tx, addr)
+		}
+		return nil
+	})
+	if err != nil {
+		return renderAddressList(c, addr, "address.save_failed")
+	}
+
+	sess := session.GetWebSession(c)
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "address.added"))
+	return c.Redirect(http.StatusFound, "/account/addresses")
+}
+
+func AddressEdit(c echo.Context) error {
+	addr, ok := findUserAddress(c)
+	if !ok {
+		return c.Redirect(http.StatusFound, "/account/addresses")
+	}
+	return renderAddressForm(c, addr, "")
+}
+
+func AddressUpdate(c echo.Context) error {
+	addr, ok := findUserAddress(c)
+	if !ok {
+		return c.Redirect(http.StatusFound, "/account/addresses")
+	}
+	wasDefault := addr.IsDefault
+	bindAddress(c, &addr)
+	// The only default address can't be unset, just replaced.
+	addr.IsDefault = addr.IsDefault || wasDefault
+
+	if msg := validateAddress(&addr); msg != "" {
+		return renderAddressForm(c, addr, msg)
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&addr).Error; err != nil {
+			return err
+		}
+		if addr.IsDefault {
+			return clearOtherDefaults(tx, addr)
+		}
+		return nil
+	})
+	if err != nil {
+		return renderAddressForm(c, addr, "address.update_failed")
+	}
+
+	sess := session.GetWebSession(c)
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "address.updated"))
+	return c.Redirect(http.StatusFound, "/account/addresses")
+}
+
+func AddressDelete(c echo.Context) error {
+	addr, ok := findUserAddress(c)
+	if !ok {
+		return c.Redirect(http.StatusFound, "/account/addresses")
+	}
+
+	database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&addr).Error; err != nil {
+			return err
+		}
+		if !addr.IsDefault {
+			return nil
+		}
+		// Hand the default over to the most recently added address left.
+		var next models.UserAddress
+		if tx.Where("user_id = ?", addr.UserID).Order("created_at DESC").First(&next).Error != nil {
+			return nil
+		}
+		return tx.Model(&next).Update("is_default", true).Error
+	})
+
+	sess := session.GetWebSession(c)
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "address.deleted"))
+	return c.Redirect(http.StatusFound, "/account/addresses")
+}
+
+func AddressSetDefault(c echo.Context) error {
+	addr, ok := findUserAddress(c)
+	if !ok {
+		return c.Redirect(http.StatusFound, "/account/addresses")
+	}
+
+	addr.IsDefault = true
+	database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&addr).Update("is_default", true).Error; err != nil {
+			return err
+		}
+		return clearOtherDefaults(tx, addr)
+	})
+
+	sess := session.GetWebSession(c)
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "address.default_set"))
+	return c.Redirect(http.StatusFound, "/account/addresses")
+}
+
+// renderAddressList shows the address book again with errKey, a message key,
+// above the new address form.
+func renderAddressList(c echo.Context, addr models.UserAddress, errKey string) error {
+	data := webData(c)
+	data["Title"] = i18n.T(c, "page.addresses")
+	data["Addresses"] = userAddresses(addr.UserID)
+	data["Address"] = addr
+	data["Provinces"] = allProvinces()
+	data["Error"] = i18n.T(c, errKey)
+	return c.Render(http.StatusOK, "web/account/addresses", data)
+}
+
+func renderAddressForm(c echo.Context, addr models.UserAddress, errKey string) error {
+	data := webData(c)
+	data["Title"] = i18n.T(c, "page.edit_address")
+	data["Address"] = addr
+	data["Provinces"] = allProvinces()
+	if errKey != "" {
+		data["Error"] = i18n.T(c, errKey)
+	}
+	return c.Render(http.StatusOK, "web/account/address_form", data)
+}
+
+func bindAddress(c echo.Context, a *models.UserAddress) {
+	a.Name = strings.TrimSpace(c.FormValue("name"))
+	a.Phone = strings.TrimSpace(c.FormValue("phone"))
+	a.ProvinceCode = c.FormValue("province_code")
+	a.DistrictCode = c.FormValue("district_code")
+	a.WardCode = c.FormValue("ward_code")
+	a.Address = strings.TrimSpace(c.FormValue("address"))
+	a.IsDefault = c.FormValue("is_default") == "on"
+}
+
+// validateAddress returns a message key when the address is incomplete.
+func validateAddress(a *models.UserAddress) string {
+	if a.Name == "" || a.Phone == "" || a.Address == "" {
+		return "address.required"
+	}
+	return fillDivisions(a)
+}
+
+func clearOtherDefaults(tx *gorm.DB, a models.UserAddress) error {
+	return tx.Model(&models.UserAddress{}).
+		Where("user_id = ? AND id <> ? AND is_default = ?", a.UserID, a.ID, true).
+		Update("is_default", false).Error
+}
+
+func findUserAddress(c echo.Context) (models.UserAddress, bool) {
+	userID, _ := c.Get("user_id").(string)
+	var addr models.UserAddress
+	err := database.DB.First(&addr, "id = ? AND user_id = ?", c.Param("id"), userID).Error
+	return addr, err == nil
+}
+
+func userAddresses(userID string) []models.UserAddress {
+	var addresses []models.UserAddress
+	database.DB.Where("user_id = ?", userID).Order("is_default DESC, created_at DESC").Find(&addresses)
+	return addresses
+}
+
+func allProvinces() []models.Province {
+	var provinces []models.Province
+	database.DB.Order("code ASC").Find(&provinces)
+	return provinces
+}
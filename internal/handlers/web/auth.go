@@ -5,6 +5,7 @@ import (
 
 	"shoop-golang/database"
 	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
 	"shoop-golang/pkg/session"
 
 	"github.com/labstack/echo/v4"
@@ -19,21 +20,21 @@ func Register(c echo.Context) error {
 		Redirect string `json:"redirect" form:"redirect"`
 	}
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "message": "Dữ liệu không hợp lệ"})
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "message": i18n.T(c, "auth.invalid_data")})
 	}
 
 	if req.Name == "" || req.Email == "" || req.Password == "" {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "message": "Vui lòng điền đầy đủ thông tin"})
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "message": i18n.T(c, "auth.missing_fields")})
 	}
 
 	var existing models.User
 	if err := database.DB.Where("email = ?", req.Email).First(&existing).Error; err == nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "message": "Email đã được sử dụng"})
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "message": i18n.T(c, "auth.email_taken")})
 	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"success": false, "message": "Lỗi hệ thống"})
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"success": false, "message": i18n.T(c, "auth.system_error")})
 	}
 
 	user := models.User{
@@ -42,12 +43,13 @@ func Register(c echo.Context) error {
 		Password: string(hash),
 	}
 	if err := database.DB.Create(&user).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"success": false, "message": "Không thể tạo tài khoản"})
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"success": false, "message": i18n.T(c, "auth.register_failed")})
 	}
 
 	sess := session.GetWebSession(c)
 	sess.Values["user_id"] = user.ID
 	sess.Values["user_name"] = user.Name
+	keepRecentViews(sess, user.ID)
 	sess.Save(c.Request(), c.Response())
 
 	return c.JSON(http.StatusOK, map[string]interface{}{"success": true, "redirect": req.Redirect})
@@ -60,21 +62,22 @@ func Login(c echo.Context) error {
 		Redirect string `json:"redirect" form:"redirect"`
 	}
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "message": "Dữ liệu không hợp lệ"})
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "message": i18n.T(c, "auth.invalid_data")})
 	}
 
 	var user models.User
 	if err := database.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "message": "Email hoặc mật khẩu không đúng"})
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "message": i18n.T(c, "auth.invalid_credentials")})
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "message": "Email hoặc mật khẩu không đúng"})
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "message": i18n.T(c, "auth.invalid_credentials")})
 	}
 
 	sess := session.GetWebSession(c)
 	sess.Values["user_id"] = user.ID
 	sess.Values["user_name"] = user.Name
+	keepRecentViews(sess, user.ID)
 	sess.Save(c.Request(), c.Response())
 
 	return c.JSON(http.StatusOK, map[string]interface{}{"success": true, "redirect": req.Redirect})
@@ -2,36 +2,86 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"shoop-golang/database"
 	"shoop-golang/internal/models"
+	"shoop-golang/internal/recommendations"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/money"
+	"shoop-golang/pkg/payments"
 	"shoop-golang/pkg/session"
 
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 func CartPage(c echo.Context) error {
 	data := webData(c)
-	data["Title"] = "Giỏ hàng"
+	data["Title"] = i18n.T(c, "page.cart")
 
 	items := getCartItems(c)
-	data["CartItems"] = items
+	data["CartItems"] = localizeCartItems(c, items)
+
+	// Logged-in customers start from their default address; an explicit
+	// destination in the query wins.
+	var addr models.UserAddress
+	if userID, _ := c.Get("user_id").(string); userID != "" {
+		addresses := userAddresses(userID)
+		if len(addresses) > 0 && addresses[0].IsDefault {
+			addr = addresses[0]
+		}
+		data["Addresses"] = addresses
+	}
+	code, name := quoteProvince(c)
+	if code == "" && name == "" {
+		code, name = addr.ProvinceCode, addr.Province
+	} else if code != addr.ProvinceCode {
+		addr = models.UserAddress{ProvinceCode: code, Province: name}
+	}
 
-	var total float64
-	for _, item := range items {
-		total += item.Price * float64(item.Quantity)
+	quote := quoteCart(items, name, c.QueryParam("method"))
+	quote.ProvinceCode = code
+	data["CartTotal"] = quote.Subtotal
+	data["Quote"] = quote
+	data["Address"] = addr
+	data["Provinces"] = allProvinces()
+	data["PaymentMethods"] = payments.All()
+
+	if len(items) > 0 {
+		ids := make([]string, len(items))
+		for i, item := range items {
+			ids[i] = item.ProductID
+		}
+		together := inStock(productsByIDs(c, recommendations.BoughtTogether(database.DB, ids, 8)), 4)
+		data["BoughtTogether"] = together
+		shown := make([]string, len(together))
+		for i, p := range together {
+			shown[i] = p.ID
+		}
+		relatedProducts(c, data, ids, shown, 4)
 	}
-	data["CartTotal"] = total
 
 	return c.Render(http.StatusOK, "web/cart/index", data)
 }
 
+// loginRequired answers a JSON request from a guest that needs an account.
+// error and code stay "login_required" for scripts to check; message,
+// from the catalog key, is for people.
+func loginRequired(c echo.Context, key string) error {
+	return c.JSON(http.StatusUnauthorized, map[string]string{
+		"error":   "login_required",
+		"code":    "login_required",
+		"message": i18n.T(c, key),
+	})
+}
+
 func AddToCart(c echo.Context) error {
 	isLoggedIn, _ := c.Get("is_logged_in").(bool)
 	if !isLoggedIn {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "login_required"})
+		return loginRequired(c, "cart.add_login_required")
 	}
 
 	productID := c.FormValue("product_id")
@@ -41,13 +91,21 @@ func AddToCart(c echo.Context) error {
 	}
 	var product models.Product
 	if err := database.DB.Preload("Images").First(&product, "id = ?", productID).Error; err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Sản phẩm không tồn tại"})
+		return c.JSON(http.StatusNotFound, map[string]string{"error": i18n.T(c, "product.not_found")})
 	}
 
-	price := product.SalePrice
-	if price <= 0 {
-		price = product.OriginalPrice
-	}
+	items := addCartItem(c, product, qty)
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"status":    "ok",
+		"cartCount": cartCount(items),
+	})
+}
+
+// addCartItem adds qty of product to the session cart and returns the
+// updated cart. product must have its images loaded.
+func addCartItem(c echo.Context, product models.Product, qty int) []models.CartItem {
+	price := product.Price()
 
 	imageURL := "/static/images/placeholder.jpg"
 	for _, img := range product.Images {
@@ -64,7 +122,7 @@ func AddToCart(c echo.Context) error {
 
 	found := false
 	for i, item := range items {
-		if item.ProductID == productID {
+		if item.ProductID == product.ID {
 			items[i].Quantity += qty
 			found = true
 			break
@@ -72,7 +130,7 @@ func AddToCart(c echo.Context) error {
 	}
 	if !found {
 		items = append(items, models.CartItem{
-			ProductID: productID,
+			ProductID: product.ID,
 			Name:      product.Name,
 			Image:     imageURL,
 			Price:     price,
@@ -81,11 +139,7 @@ func AddToCart(c echo.Context) error {
 	}
 
 	saveCartItems(c, items)
-
-	return c.JSON(http.StatusOK, map[string]any{
-		"status":    "ok",
-		"cartCount": cartCount(items),
-	})
+	return items
 }
 
 func UpdateCart(c echo.Context) error {
@@ -112,9 +166,9 @@ func UpdateCart(c echo.Context) error {
 
 	saveCartItems(c, items)
 
-	var total float64
+	var total money.Money
 	for _, item := range items {
-		total += item.Price * float64(item.Quantity)
+		total += item.Price.Mul(item.Quantity)
 	}
 
 	return c.JSON(http.StatusOK, map[string]any{
@@ -127,20 +181,18 @@ func UpdateCart(c echo.Context) error {
 func Checkout(c echo.Context) error {
 	isLoggedIn, _ := c.Get("is_logged_in").(bool)
 	if !isLoggedIn {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "login_required"})
+		return loginRequired(c, "cart.login_required")
 	}
 
 	items := getCartItems(c)
 	if len(items) == 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Giỏ hàng trống"})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": i18n.T(c, "cart.empty")})
 	}
 
 	userID, _ := c.Get("user_id").(string)
 
-	var total float64
 	var orderItems []models.OrderItem
 	for _, item := range items {
-		total += item.Price * float64(item.Quantity)
 		orderItems = append(orderItems, models.OrderItem{
 			ProductID: item.ProductID,
 			Quantity:  item.Quantity,
@@ -149,59 +201,188 @@ func Checkout(c echo.Context) error {
 	}
 
 	// Support both JSON and form
-	var name, phone, address, note string
+	var body struct {
+		Name         string `json:"name"`
+		Phone        string `json:"phone"`
+		Address      string `json:"address"`
+		Note         string `json:"note"`
+		Province     string `json:"province"`
+		ProvinceCode string `json:"province_code"`
+		DistrictCode string `json:"district_code"`
+		WardCode     string `json:"ward_code"`
+		AddressID    string `json:"address_id"`
+		SaveAddress  string `json:"save_address"`
+		Shipping     string `json:"shipping_method"`
+		Payment      string `json:"payment_method"`
+	}
 	if c.Request().Header.Get("Content-Type") == "application/json" {
-		var body struct {
-			Name    string `json:"name"`
-			Phone   string `json:"phone"`
-			Address string `json:"address"`
-			Note    string `json:"note"`
-		}
-		if err := c.Bind(&body); err == nil {
-			name, phone, address, note = body.Name, body.Phone, body.Address, body.Note
+		c.Bind(&body)
+	}
+	formValue := func(v *string, name string) {
+		if *v == "" {
+			*v = c.FormValue(name)
 		}
 	}
-	if name == "" {
-		name = c.FormValue("name")
+	formValue(&body.Name, "name")
+	formValue(&body.Phone, "phone")
+	formValue(&body.Address, "address")
+	formValue(&body.Note, "note")
+	formValue(&body.Province, "province")
+	formValue(&body.ProvinceCode, "province_code")
+	formValue(&body.DistrictCode, "district_code")
+	formValue(&body.WardCode, "ward_code")
+	formValue(&body.AddressID, "address_id")
+	formValue(&body.SaveAddress, "save_address")
+	formValue(&body.Shipping, "shipping_method")
+	formValue(&body.Payment, "payment_method")
+	method := body.Shipping
+
+	// The destination is a saved address, a set of division codes, or, for
+	// older clients, a free-text address with an optional province name.
+	addr := models.UserAddress{
+		UserID:       userID,
+		Name:         body.Name,
+		Phone:        body.Phone,
+		Province:     body.Province,
+		ProvinceCode: body.ProvinceCode,
+		DistrictCode: body.DistrictCode,
+		WardCode:     body.WardCode,
+		Address:      body.Address,
 	}
-	if phone == "" {
-		phone = c.FormValue("phone")
+	if body.AddressID != "" {
+		if err := database.DB.First(&addr, "id = ? AND user_id = ?", body.AddressID, userID).Error; err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": i18n.T(c, "address.not_found")})
+		}
+	} else if addr.ProvinceCode != "" {
+		if msg := fillDivisions(&addr); msg != "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": i18n.T(c, msg)})
+		}
 	}
-	if address == "" {
-		address = c.FormValue("address")
+
+	quote := quoteCart(items, addr.Province, method)
+	if len(quote.Options) > 0 && (quote.Selected == "" || (method != "" && method != quote.Selected)) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": i18n.T(c, "checkout.shipping_unavailable")})
 	}
-	if note == "" {
-		note = c.FormValue("note")
+
+	provider, ok := paymentMethod(body.Payment)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": i18n.T(c, "checkout.payment_invalid")})
 	}
 
+	// Prices may have been shown in another currency; the order is charged
+	// in VND and keeps the rate the shopper saw.
+	display := displayCurrency(c)
 	order := models.Order{
-		UserID:      userID,
-		Status:      "pending",
-		TotalAmount: total,
-		Name:        name,
-		Phone:       phone,
-		Address:     address,
-		Note:        note,
-		Items:       orderItems,
+		UserID:        userID,
+		Status:        "pending",
+		Subtotal:      quote.Subtotal,
+		ShippingFee:   quote.ShippingFee,
+		Discount:      quote.Discount,
+		TotalAmount:   quote.Total,
+		Currency:      money.Currency,
+		DisplayCode:   display.Code,
+		DisplayRate:   display.Rate,
+		Name:          addr.Name,
+		Phone:         addr.Phone,
+		ProvinceCode:  addr.ProvinceCode,
+		Province:      addr.Province,
+		DistrictCode:  addr.DistrictCode,
+		District:      addr.District,
+		WardCode:      addr.WardCode,
+		Ward:          addr.Ward,
+		Address:       addr.Address,
+		Shipping:      quote.Selected,
+		PaymentMethod: provider.Code(),
+		Note:          body.Note,
+		Locale:        i18n.Locale(c),
+		Items:         orderItems,
+	}
+
+	var instruction payments.Instruction
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+		// Take the items out of stock; refunds put cancelled quantities back.
+		// A line that no longer fits in stock undoes the whole order.
+		for i, item := range order.Items {
+			res := tx.Model(&models.Product{}).Where("id = ? AND stock >= ?", item.ProductID, item.Quantity).
+				UpdateColumns(models.StockChange(-item.Quantity))
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected != 1 {
+				return errOutOfStock(items[i].Name)
+			}
+		}
+		var err error
+		instruction, err = startPayment(tx, &order, provider)
+		return err
+	})
+	if err != nil {
+		var name errOutOfStock
+		if errors.As(err, &name) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": i18n.T(c, "checkout.out_of_stock", string(name))})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": i18n.T(c, "checkout.failed")})
 	}
 
-	if err := database.DB.Create(&order).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Không thể tạo đơn hàng"})
+	if body.AddressID == "" && addr.ProvinceCode != "" && isChecked(body.SaveAddress) {
+		saveCheckoutAddress(addr)
 	}
 
 	saveCartItems(c, []models.CartItem{})
 
 	sess := session.GetWebSession(c)
-	session.SetFlash(c, sess, session.FlashSuccess, "Đặt hàng thành công! Mã đơn: "+order.ID[:8])
+	message := i18n.T(c, "checkout.success", order.Number)
+	session.SetFlash(c, sess, session.FlashSuccess, message)
 
 	return c.JSON(http.StatusOK, map[string]any{
-		"success":  true,
-		"order_id": order.ID,
-		"redirect": "/",
-		"message":  "Đặt hàng thành công! Mã đơn: " + order.ID[:8],
+		"success":      true,
+		"order_id":     order.ID,
+		"order_number": order.Number,
+		"payment":      instruction,
+		"redirect":     checkoutRedirect(order, instruction),
+		"message":      message,
 	})
 }
 
+// errOutOfStock names a cart item there is no longer enough stock for.
+type errOutOfStock string
+
+func (e errOutOfStock) Error() string { return "out of stock: " + string(e) }
+
+// checkoutRedirect sends the customer to the provider when it has its own
+// payment page, to the order's payment page when there is something left to
+// do, and back to the store for cash on delivery.
+func checkoutRedirect(order models.Order, instruction payments.Instruction) string {
+	switch {
+	case instruction.RedirectURL != "":
+		return instruction.RedirectURL
+	case order.PaymentMethod == (payments.COD{}).Code():
+		return "/"
+	default:
+		return "/payments/" + order.Number
+	}
+}
+
+// saveCheckoutAddress adds a checkout address to the customer's address
+// book, as the default when it is their first one.
+func saveCheckoutAddress(addr models.UserAddress) {
+	var count int64
+	database.DB.Model(&models.UserAddress{}).Where("user_id = ?", addr.UserID).Count(&count)
+	addr.IsDefault = count == 0
+	database.DB.Create(&addr)
+}
+
+func isChecked(v string) bool {
+	switch v {
+	case "on", "true", "1":
+		return true
+	}
+	return false
+}
+
 func getCartItems(c echo.Context) []models.CartItem {
 	sess := session.GetWebSession(c)
 	data, ok := sess.Values["cart"].(string)
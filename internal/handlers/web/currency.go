@@ -0,0 +1,78 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/money"
+
+	"github.com/labstack/echo/v4"
+)
+
+// currencyCookie remembers the currency a shopper browses in.
+const currencyCookie = "currency"
+
+// displayCurrency returns the shopper's chosen currency with its current
+// rate, falling back to VND when none is chosen or the rate was withdrawn.
+func displayCurrency(c echo.Context) money.Display {
+	cookie, err := c.Cookie(currencyCookie)
+	if err != nil || cookie.Value == money.Currency {
+		return money.VND
+	}
+	var rate models.ExchangeRate
+	if err := database.DB.Where("currency = ? AND is_active = ? AND rate > 0", cookie.Value, true).First(&rate).Error; err != nil {
+		return money.VND
+	}
+	return rate.Display()
+}
+
+// displayCurrencies lists the codes shown in the currency switcher.
+func displayCurrencies() []string {
+	codes := []string{money.Currency}
+	var rates []models.ExchangeRate
+	database.DB.Where("is_active = ? AND rate > 0", true).Find(&rates)
+	for _, code := range money.DisplayCodes {
+		for _, r := range rates {
+			if r.Currency == code {
+				codes = append(codes, code)
+			}
+		}
+	}
+	return codes
+}
+
+// SetCurrency switches the display currency and returns to the page the
+// shopper came from.
+func SetCurrency(c echo.Context) error {
+	code := c.Param("code")
+	if !money.IsDisplayCode(code) {
+		return c.Redirect(http.StatusFound, "/")
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     currencyCookie,
+		Value:    code,
+		Path:     "/",
+		Expires:  time.Now().AddDate(1, 0, 0),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return c.Redirect(http.StatusFound, refererPath(c))
+}
+
+// refererPath returns the local path of the page the visitor came from, or
+// the home page. Only the path is kept so the redirect stays on this site.
+func refererPath(c echo.Context) string {
+	ref, err := url.Parse(c.Request().Referer())
+	if err != nil || !strings.HasPrefix(ref.Path, "/") || strings.HasPrefix(ref.Path, "//") {
+		return "/"
+	}
+	if ref.RawQuery != "" {
+		return ref.Path + "?" + ref.RawQuery
+	}
+	return ref.Path
+}
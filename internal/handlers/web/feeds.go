@@ -0,0 +1,186 @@
+package web
+
+import (
+	"encoding/xml"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/money"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// Google Merchant Center RSS 2.0 product feed.
+// See https://support.google.com/merchants/answer/7052112
+type merchantFeed struct {
+	XMLName xml.Name        `xml:"rss"`
+	Version string          `xml:"version,attr"`
+	XMLNSG  string          `xml:"xmlns:g,attr"`
+	Channel merchantChannel `xml:"channel"`
+}
+
+type merchantChannel struct {
+	Title       string         `xml:"title"`
+	Link        string         `xml:"link"`
+	Description string         `xml:"description"`
+	Items       []merchantItem `xml:"item"`
+}
+
+type merchantItem struct {
+	ID                   string   `xml:"g:id"`
+	Title                string   `xml:"g:title"`
+	Description          string   `xml:"g:description"`
+	Link                 string   `xml:"g:link"`
+	ImageLink            string   `xml:"g:image_link,omitempty"`
+	AdditionalImageLinks []string `xml:"g:additional_image_link,omitempty"`
+	Availability         string   `xml:"g:availability"`
+	Price                string   `xml:"g:price"`
+	SalePrice            string   `xml:"g:sale_price,omitempty"`
+	Condition            string   `xml:"g:condition"`
+	Brand                string   `xml:"g:brand,omitempty"`
+	ProductType          string   `xml:"g:product_type,omitempty"`
+	IdentifierExists     string   `xml:"g:identifier_exists"`
+}
+
+const feedMaxAge = 3600
+
+// GoogleMerchantFeed serves the public product feed at /feeds/google.xml.
+// The response is cacheable and revalidated against everything it is built
+// from: products and their images, categories, the company info and the
+// base URL.
+func GoogleMerchantFeed(c echo.Context) error {
+	stamp := fnv.New64a()
+	io.WriteString(stamp, catalogStamp()+"|"+tableStamp(&models.Image{})+"|"+tableStamp(&models.CompanyInfo{})+"|"+baseURL)
+	etag := fmt.Sprintf(`W/"%x"`, stamp.Sum64())
+	lastModified := lastChange(&models.Product{}, &models.Image{}, &models.Category{}, &models.CompanyInfo{})
+
+	h := c.Response().Header()
+	h.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", feedMaxAge))
+	h.Set("ETag", etag)
+	if !lastModified.IsZero() {
+		h.Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	if match := c.Request().Header.Get("If-None-Match"); match != "" {
+		if match == etag {
+			return c.NoContent(http.StatusNotModified)
+		}
+	} else if since, err := http.ParseTime(c.Request().Header.Get("If-Modified-Since")); err == nil && !lastModified.After(since) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	var company models.CompanyInfo
+	database.DB.First(&company)
+
+	var products []models.Product
+	database.DB.Preload("Images", func(db *gorm.DB) *gorm.DB {
+		return db.Order("is_primary DESC, sort_order ASC")
+	}).Preload("Category").Where("is_active = ?", true).Order("created_at DESC").Find(&products)
+
+	feed := merchantFeed{
+		Version: "2.0",
+		XMLNSG:  "http://base.google.com/ns/1.0",
+		Channel: merchantChannel{
+			Title:       company.Name,
+			Link:        baseURL,
+			Description: company.Tagline,
+		},
+	}
+	for _, p := range products {
+		if p.OriginalPrice <= 0 {
+			// Google rejects items without a price ("Liên hệ" products)
+			continue
+		}
+		item := merchantItem{
+			ID:               p.SKU,
+			Title:            p.Name,
+			Description:      p.Description,
+			Link:             baseURL + "/products/" + p.Slug,
+			Availability:     "out_of_stock",
+			Price:            merchantPrice(p.OriginalPrice),
+			Condition:        "new",
+			Brand:            company.Name,
+			ProductType:      p.Category.Name,
+			IdentifierExists: "no",
+		}
+		if item.ID == "" {
+			item.ID = p.ID
+		}
+		if p.Stock > 0 {
+			item.Availability = "in_stock"
+		}
+		if p.SalePrice > 0 && p.SalePrice < p.OriginalPrice {
+			item.SalePrice = merchantPrice(p.SalePrice)
+		}
+		for i, img := range p.Images {
+			if i == 0 {
+				item.ImageLink = absoluteURL(baseURL, img.URL)
+				continue
+			}
+			item.AdditionalImageLinks = append(item.AdditionalImageLinks, absoluteURL(baseURL, img.URL))
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	out, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return c.Blob(http.StatusOK, echo.MIMEApplicationXMLCharsetUTF8, append([]byte(xml.Header), out...))
+}
+
+func merchantPrice(amount money.Money) string {
+	return fmt.Sprintf("%d %s", amount, money.Currency)
+}
+
+// absoluteURL turns an uploaded path such as /uploads/products/x.jpg into a
+// full URL; values that are already absolute are returned unchanged.
+func absoluteURL(baseURL, u string) string {
+	if strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") {
+		return u
+	}
+	return baseURL + "/" + strings.TrimPrefix(u, "/")
+}
+
+// lastChange returns when a row of any of tables was last updated or
+// deleted, to the second.
+func lastChange(tables ...any) time.Time {
+	var latest time.Time
+	for _, model := range tables {
+		var s struct {
+			UpdatedAt string
+			DeletedAt string
+		}
+		database.DB.Unscoped().Model(model).
+			Select("MAX(updated_at) AS updated_at, MAX(deleted_at) AS deleted_at").
+			Scan(&s)
+		for _, v := range []string{s.UpdatedAt, s.DeletedAt} {
+			if t := parseDBTime(v); t.After(latest) {
+				latest = t
+			}
+		}
+	}
+	return latest.UTC().Truncate(time.Second)
+}
+
+// parseDBTime parses a timestamp returned from an aggregate query, where
+// SQLite hands back text instead of a time.Time.
+func parseDBTime(s string) time.Time {
+	for _, layout := range []string{
+		"2006-01-02 15:04:05.999999999-07:00",
+		"2006-01-02 15:04:05.999999999",
+		time.RFC3339Nano,
+	} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
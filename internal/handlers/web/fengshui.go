@@ -0,0 +1,98 @@
+package web
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/fengshui"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/session"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// elementInfo is a fengshui.Profile with the elements that suit it, as
+// the product list and home page show it.
+type elementInfo struct {
+	fengshui.Profile
+	Compatible  []string
+	Conflicting string
+	// Query is the product list query for the profile:
+	// birth_year=1990&gender=male.
+	Query template.URL
+}
+
+func newElementInfo(p fengshui.Profile) elementInfo {
+	q := url.Values{"birth_year": {strconv.Itoa(p.Year)}}
+	if p.Gender != "" {
+		q.Set("gender", p.Gender)
+	}
+	return elementInfo{
+		Profile:     p,
+		Compatible:  fengshui.Compatible(p.Element),
+		Conflicting: fengshui.Conflicting(p.Element),
+		Query:       template.URL(q.Encode()),
+	}
+}
+
+// parseElementProfile reads a birth year and gender as typed into the
+// element form.
+func parseElementProfile(year, gender string) (fengshui.Profile, bool) {
+	y, err := strconv.Atoi(strings.TrimSpace(year))
+	if err != nil {
+		return fengshui.Profile{}, false
+	}
+	return fengshui.Lookup(y, gender)
+}
+
+// userElementProfile returns the profile the logged-in user saved.
+func userElementProfile(c echo.Context) (fengshui.Profile, bool) {
+	userID, _ := c.Get("user_id").(string)
+	if userID == "" {
+		return fengshui.Profile{}, false
+	}
+	var user models.User
+	if database.DB.Select("id", "birth_year", "gender").First(&user, "id = ?", userID).Error != nil {
+		return fengshui.Profile{}, false
+	}
+	return fengshui.Lookup(user.BirthYear, user.Gender)
+}
+
+// elementProducts selects the IDs of the products given any of elements
+// through the element attribute.
+func elementProducts(elements []string) *gorm.DB {
+	values := database.DB.Model(&models.AttributeValue{}).Select("attribute_values.id").
+		Joins("JOIN attributes ON attributes.id = attribute_values.attribute_id").
+		Where("attributes.code = ? AND attribute_values.slug IN ?", models.ElementAttribute, elements)
+	return database.DB.Model(&models.ProductAttribute{}).Select("product_id").Where("value_id IN (?)", values)
+}
+
+// SaveElementProfile keeps the birth year and gender on the user's
+// account for the home page, or forgets them when the year is empty.
+func SaveElementProfile(c echo.Context) error {
+	userID, _ := c.Get("user_id").(string)
+	sess := session.GetWebSession(c)
+
+	if strings.TrimSpace(c.FormValue("birth_year")) == "" {
+		database.DB.Model(&models.User{}).Where("id = ?", userID).
+			Updates(map[string]any{"birth_year": 0, "gender": ""})
+		session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "element.cleared"))
+		return c.Redirect(http.StatusFound, localePath(c, "/"))
+	}
+
+	profile, ok := parseElementProfile(c.FormValue("birth_year"), c.FormValue("gender"))
+	if !ok {
+		session.SetFlash(c, sess, session.FlashError, i18n.T(c, "element.invalid_year", fengshui.MinYear, fengshui.MaxYear))
+		return c.Redirect(http.StatusFound, localePath(c, "/products"))
+	}
+	database.DB.Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]any{"birth_year": profile.Year, "gender": profile.Gender})
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "element.saved"))
+	return c.Redirect(http.StatusFound, localePath(c, "/products?"+string(newElementInfo(profile).Query)))
+}
@@ -0,0 +1,107 @@
+package web
+
+import (
+	"html/template"
+	"net/url"
+	"strconv"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/fengshui"
+	"shoop-golang/pkg/i18n"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// attributeFilter is a filterable attribute in the product list sidebar.
+type attributeFilter struct {
+	Code    string
+	Name    string
+	Options []filterOption
+}
+
+type filterOption struct {
+	Slug    string
+	Name    string
+	Checked bool
+}
+
+// applyFilters narrows query by the attribute and tag parameters of the
+// product list: ?element=kim&element=thuy&tag=qua-tang, or a birth year
+// for the elements suiting it: ?birth_year=1990&gender=male. Values of one
+// attribute match any, different attributes must all match. scope holds
+// the IDs of the current category with its ancestors and subcategories,
+// or is nil for the whole catalogue, and picks the attributes offered.
+func applyFilters(c echo.Context, data map[string]any, query *gorm.DB, scope []string) *gorm.DB {
+	params := c.QueryParams()
+	kept := url.Values{}
+
+	var attrs []models.Attribute
+	database.DB.Preload("Values", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order ASC") }).
+		Preload("Categories").
+		Where("filterable = ? AND type IN ?", true, []string{models.AttributeSelect, models.AttributeMulti}).
+		Order("sort_order ASC, name ASC").Find(&attrs)
+
+	var filters []attributeFilter
+	for _, attr := range attrs {
+		if scope != nil && !attr.AppliesTo(scope) {
+			continue
+		}
+		wanted := map[string]bool{}
+		for _, slug := range params[attr.Code] {
+			wanted[slug] = true
+		}
+		f := attributeFilter{Code: attr.Code, Name: attr.Name}
+		var ids []string
+		for _, v := range attr.Values {
+			f.Options = append(f.Options, filterOption{Slug: v.Slug, Name: v.Name, Checked: wanted[v.Slug]})
+			if wanted[v.Slug] {
+				ids = append(ids, v.ID)
+				kept.Add(attr.Code, v.Slug)
+			}
+		}
+		if len(ids) > 0 {
+			matching := database.DB.Model(&models.ProductAttribute{}).Select("product_id").
+				Where("attribute_id = ? AND value_id IN ?", attr.ID, ids)
+			query = query.Where("id IN (?)", matching)
+		}
+		if len(f.Options) > 0 {
+			filters = append(filters, f)
+		}
+	}
+	data["Filters"] = filters
+
+	if slug := c.QueryParam("tag"); slug != "" {
+		var tag models.Tag
+		if database.DB.First(&tag, "slug = ?", slug).Error == nil {
+			tagged := database.DB.Table("product_tags").Select("product_id").Where("tag_id = ?", tag.ID)
+			query = query.Where("id IN (?)", tagged)
+			data["CurrentTag"] = tag
+			kept.Set("tag", tag.Slug)
+		}
+	}
+
+	// A birth year keeps the products suiting its element.
+	if year := c.QueryParam("birth_year"); year != "" {
+		if profile, ok := parseElementProfile(year, c.QueryParam("gender")); ok {
+			info := newElementInfo(profile)
+			query = query.Where("id IN (?)", elementProducts(info.Compatible))
+			data["Element"] = info
+			kept.Set("birth_year", strconv.Itoa(profile.Year))
+			if profile.Gender != "" {
+				kept.Set("gender", profile.Gender)
+			}
+		} else {
+			data["ElementError"] = i18n.T(c, "element.invalid_year", fengshui.MinYear, fengshui.MaxYear)
+		}
+	} else if profile, ok := userElementProfile(c); ok {
+		data["SavedElement"] = newElementInfo(profile)
+	}
+
+	// Pagination links carry the filters along.
+	if len(kept) > 0 {
+		data["FilterQuery"] = template.URL("&" + kept.Encode())
+	}
+	return query
+}
@@ -5,6 +5,7 @@ import (
 
 	"shoop-golang/database"
 	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
 
 	"github.com/labstack/echo/v4"
 )
@@ -25,29 +26,53 @@ func webData(c echo.Context) map[string]any {
 	var company models.CompanyInfo
 	database.DB.First(&company)
 	data["Company"] = company
+	data["SEO"] = defaultSEO(c, company)
+	addStructuredData(data, organizationLD(c, company))
 
-	var categories []models.Category
-	database.DB.Where("is_active = ?", true).Order("sort_order ASC").Find(&categories)
-	data["NavCategories"] = categories
+	data["NavCategories"] = models.CategoryTree(activeCategories(c))
+
+	data["Currency"] = displayCurrency(c)
+	data["Currencies"] = displayCurrencies()
+	data["Locales"] = i18n.Locales
+
+	userID, _ := c.Get("user_id").(string)
+	data["WishlistIDs"] = wishlistIDs(userID)
 
 	return data
 }
 
 func Home(c echo.Context) error {
 	data := webData(c)
-	data["Title"] = "Trang chủ"
+	data["Title"] = i18n.T(c, "page.home")
+	applySEO(c, data, "home", seoMeta{})
 
 	var banners []models.Banner
-	database.DB.Where("is_active = ?", true).Order("sort_order ASC").Find(&banners)
+	withTranslations(c, database.DB, "Translations").Where("is_active = ?", true).Order("sort_order ASC").Find(&banners)
+	localizeBanners(c, banners)
 	data["Banners"] = banners
 
 	var featured []models.Product
-	database.DB.Preload("Images").Where("is_active = ? AND is_featured = ?", true, true).Limit(8).Find(&featured)
+	withTranslations(c, database.DB, "Translations").Preload("Images").Where("is_active = ? AND is_featured = ?", true, true).Limit(8).Find(&featured)
+	localizeProducts(c, featured)
 	data["FeaturedProducts"] = featured
 
 	var latest []models.Product
-	database.DB.Preload("Images").Where("is_active = ?", true).Order("created_at DESC").Limit(8).Find(&latest)
+	withTranslations(c, database.DB, "Translations").Preload("Images").Where("is_active = ?", true).Order("created_at DESC").Limit(8).Find(&latest)
+	localizeProducts(c, latest)
 	data["LatestProducts"] = latest
 
+	data["RecentlyViewed"] = recentlyViewed(c, recentViewIDs(c), "")
+
+	if profile, ok := userElementProfile(c); ok {
+		info := newElementInfo(profile)
+		var suited []models.Product
+		withTranslations(c, database.DB, "Translations").Preload("Images").
+			Where("is_active = ? AND id IN (?)", true, elementProducts(info.Compatible)).
+			Order("is_featured DESC, created_at DESC").Limit(8).Find(&suited)
+		localizeProducts(c, suited)
+		data["Element"] = info
+		data["ElementProducts"] = suited
+	}
+
 	return c.Render(http.StatusOK, "web/home/index", data)
 }
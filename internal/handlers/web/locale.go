@@ -0,0 +1,29 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	"shoop-golang/pkg/i18n"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SetLanguage switches the storefront locale and returns to the page the
+// shopper came from, without a locale prefix that would override it.
+func SetLanguage(c echo.Context) error {
+	locale := c.Param("code")
+	if !i18n.Supported(locale) {
+		return c.Redirect(http.StatusFound, "/")
+	}
+	i18n.SetCookie(c, locale)
+
+	back := refererPath(c)
+	for _, l := range i18n.Locales {
+		if back == "/"+l || strings.HasPrefix(back, "/"+l+"/") || strings.HasPrefix(back, "/"+l+"?") {
+			back = "/" + strings.TrimLeft(strings.TrimPrefix(back, "/"+l), "/")
+			break
+		}
+	}
+	return c.Redirect(http.StatusFound, back)
+}
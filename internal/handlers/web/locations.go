@@ -0,0 +1,78 @@
+package web
+
+import (
+	"net/http"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ProvinceList, DistrictList and WardList feed the cascading address selects.
+func ProvinceList(c echo.Context) error {
+	return c.JSON(http.StatusOK, allProvinces())
+}
+
+func DistrictList(c echo.Context) error {
+	var districts []models.District
+	database.DB.Where("province_code = ?", c.Param("code")).Order("code ASC").Find(&districts)
+	return c.JSON(http.StatusOK, districts)
+}
+
+func WardList(c echo.Context) error {
+	var wards []models.Ward
+	database.DB.Where("district_code = ?", c.Param("code")).Order("code ASC").Find(&wards)
+	return c.JSON(http.StatusOK, wards)
+}
+
+func lookupProvince(code string) (models.Province, bool) {
+	var p models.Province
+	if code == "" || database.DB.First(&p, "code = ?", code).Error != nil {
+		return p, false
+	}
+	return p, true
+}
+
+// fillDivisions resolves the division codes on a to their names, checking
+// that each level belongs to the one above it. District and ward are only
+// required where the dataset lists any for the parent. Problems are returned
+// as message keys.
+func fillDivisions(a *models.UserAddress) string {
+	province, ok := lookupProvince(a.ProvinceCode)
+	if !ok {
+		return "address.province_required"
+	}
+	a.Province = province.Name
+	a.District, a.Ward = "", ""
+
+	var district models.District
+	if a.DistrictCode == "" {
+		var count int64
+		database.DB.Model(&models.District{}).Where("province_code = ?", province.Code).Count(&count)
+		if count > 0 {
+			return "address.district_required"
+		}
+		a.WardCode = ""
+		return ""
+	}
+	if database.DB.First(&district, "code = ? AND province_code = ?", a.DistrictCode, province.Code).Error != nil {
+		return "address.district_mismatch"
+	}
+	a.District = district.Name
+
+	var ward models.Ward
+	if a.WardCode == "" {
+		var count int64
+		database.DB.Model(&models.Ward{}).Where("district_code = ?", district.Code).Count(&count)
+		if count > 0 {
+			return "address.ward_required"
+		}
+		return ""
+	}
+	if database.DB.First(&ward, "code = ? AND district_code = ?", a.WardCode, district.Code).Error != nil {
+		return "address.ward_mismatch"
+	}
+	a.Ward = ward.Name
+	return ""
+}
@@ -0,0 +1,194 @@
+package web
+
+import (
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/session"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// stockSubscription tells the product page whether the signed-in customer
+// already asked to hear about a restock.
+func stockSubscription(c echo.Context, data map[string]any, productID string) {
+	userID, _ := c.Get("user_id").(string)
+	if userID == "" {
+		return
+	}
+	var count int64
+	database.DB.Model(&models.StockSubscription{}).
+		Where("product_id = ? AND user_id = ? AND notified_at IS NULL", productID, userID).
+		Count(&count)
+	data["NotifySubscribed"] = count > 0
+}
+
+// NotifyMeStore subscribes to a one-off email when an out-of-stock product
+// is back. Customers use their account address; guests type one in.
+func NotifyMeStore(c echo.Context) error {
+	userID, _ := c.Get("user_id").(string)
+	sess := session.GetWebSession(c)
+
+	var product models.Product
+	id, ok := productBySlug(c, c.Param("slug"))
+	if !ok || database.DB.First(&product, "id = ? AND is_active = ?", id, true).Error != nil {
+		return c.Redirect(http.StatusFound, "/products")
+	}
+	back := "/products/" + c.Param("slug")
+	fail := func(key string) error {
+		session.SetFlash(c, sess, session.FlashError, i18n.T(c, key))
+		return c.Redirect(http.StatusFound, back)
+	}
+	if product.Stock > 0 {
+		return fail("notify.in_stock")
+	}
+
+	// A customer's account address needs no confirming; a guest's does,
+	// before it gets anything but the confirmation email.
+	now := time.Now()
+	sub := models.StockSubscription{ProductID: product.ID, Locale: i18n.Locale(c)}
+	if userID != "" {
+		var user models.User
+		if database.DB.First(&user, "id = ?", userID).Error != nil {
+			return fail("common.error")
+		}
+		sub.UserID = &userID
+		sub.Email = user.Email
+		sub.ConfirmedAt = &now
+	} else {
+		email := strings.ToLower(strings.TrimSpace(c.FormValue("email")))
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			return fail("notify.invalid_email")
+		}
+		sub.Email = email
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var existing models.StockSubscription
+		if tx.Where("product_id = ? AND email = ?", product.ID, sub.Email).First(&existing).Error == nil {
+			updates := map[string]any{"user_id": sub.UserID, "locale": sub.Locale, "notified_at": nil}
+			if sub.ConfirmedAt != nil {
+				updates["confirmed_at"] = now
+			} else {
+				sub.ConfirmedAt = existing.ConfirmedAt
+			}
+			sub.ID, sub.Token = existing.ID, existing.Token
+			if err := tx.Model(&existing).Updates(updates).Error; err != nil {
+				return err
+			}
+		} else {
+			sub.Token = uuid.New().String()
+			if err := tx.Create(&sub).Error; err != nil {
+				return err
+			}
+		}
+		if sub.ConfirmedAt != nil {
+			return nil
+		}
+		return queueConfirmation(tx, sub)
+	})
+	if err != nil {
+		return fail("common.error")
+	}
+
+	if sub.ConfirmedAt == nil {
+		session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "notify.confirm_sent", sub.Email))
+	} else {
+		session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "notify.subscribed", sub.Email))
+	}
+	return c.Redirect(http.StatusFound, back)
+}
+
+// queueConfirmation queues the email asking a guest to confirm sub, unless
+// one is already waiting to be sent.
+func queueConfirmation(tx *gorm.DB, sub models.StockSubscription) error {
+	var pending int64
+	tx.Model(&models.Notification{}).
+		Where("unsubscribe_token = ? AND kind = ? AND status = ?", sub.Token, models.NotifyConfirm, models.NotificationQueued).
+		Count(&pending)
+	if pending > 0 {
+		return nil
+	}
+	return tx.Create(&models.Notification{
+		Email:            sub.Email,
+		Locale:           sub.Locale,
+		Kind:             models.NotifyConfirm,
+		ProductID:        sub.ProductID,
+		Status:           models.NotificationQueued,
+		UnsubscribeToken: sub.Token,
+	}).Error
+}
+
+// NotifyConfirmPage is the link in the confirmation email. It only asks:
+// confirming takes the POST from its button, so mail scanners and link
+// prefetchers that open the link change nothing.
+func NotifyConfirmPage(c echo.Context) error {
+	return subscriptionPage(c, "confirm")
+}
+
+// NotifyUnsubscribePage is the link at the bottom of restock emails; like
+// the confirm page it asks before unsubscribing.
+func NotifyUnsubscribePage(c echo.Context) error {
+	return subscriptionPage(c, "unsubscribe")
+}
+
+func subscriptionPage(c echo.Context, action string) error {
+	sub, ok := subscriptionByToken(c, action)
+	if !ok {
+		return c.Redirect(http.StatusFound, "/")
+	}
+	sub.Product.Localize(i18n.Locale(c))
+
+	data := webData(c)
+	data["Title"] = i18n.T(c, "notify."+action+".title")
+	data["Message"] = i18n.T(c, "notify."+action+".prompt", sub.Email, sub.Product.Name)
+	data["Action"] = "/notify/" + action + "/" + sub.Token
+	data["Button"] = i18n.T(c, "notify."+action+".submit")
+	return c.Render(http.StatusOK, "web/notify/confirm", data)
+}
+
+// subscriptionByToken loads the subscription a confirm or unsubscribe link
+// names, flashing an error when there is none.
+func subscriptionByToken(c echo.Context, action string) (models.StockSubscription, bool) {
+	var sub models.StockSubscription
+	err := withTranslations(c, database.DB, "Product.Translations").Preload("Product").
+		First(&sub, "token = ?", c.Param("token")).Error
+	if err != nil {
+		session.SetFlash(c, session.GetWebSession(c), session.FlashError, i18n.T(c, "notify."+action+"_invalid"))
+		return sub, false
+	}
+	return sub, true
+}
+
+// NotifyConfirm confirms a guest's subscription.
+func NotifyConfirm(c echo.Context) error {
+	sub, ok := subscriptionByToken(c, "confirm")
+	if !ok {
+		return c.Redirect(http.StatusFound, "/")
+	}
+	if sub.ConfirmedAt == nil {
+		database.DB.Model(&sub).Update("confirmed_at", time.Now())
+	}
+	session.SetFlash(c, session.GetWebSession(c), session.FlashSuccess, i18n.T(c, "notify.subscribed", sub.Email))
+	return c.Redirect(http.StatusFound, "/")
+}
+
+// NotifyUnsubscribe removes a subscription and any email still queued for it.
+func NotifyUnsubscribe(c echo.Context) error {
+	sub, ok := subscriptionByToken(c, "unsubscribe")
+	if !ok {
+		return c.Redirect(http.StatusFound, "/")
+	}
+	database.DB.Unscoped().Delete(&sub)
+	database.DB.Unscoped().Where("unsubscribe_token = ? AND status = ?", sub.Token, models.NotificationQueued).Delete(&models.Notification{})
+
+	session.SetFlash(c, session.GetWebSession(c), session.FlashSuccess, i18n.T(c, "notify.unsubscribed"))
+	return c.Redirect(http.StatusFound, "/")
+}
@@ -0,0 +1,46 @@
+package web
+
+import (
+	"net/http"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/payments"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+func AccountOrderList(c echo.Context) error {
+	userID, _ := c.Get("user_id").(string)
+	var orders []models.Order
+	database.DB.Preload("Items").Where("user_id = ?", userID).Order("created_at DESC").Find(&orders)
+
+	data := webData(c)
+	data["Title"] = i18n.T(c, "page.my_orders")
+	data["Orders"] = orders
+	return c.Render(http.StatusOK, "web/account/orders", data)
+}
+
+// AccountOrderDetail shows one of the customer's orders, including lines
+// cancelled after checkout and the refunds made for them.
+func AccountOrderDetail(c echo.Context) error {
+	userID, _ := c.Get("user_id").(string)
+	var order models.Order
+	err := database.DB.Preload("Items").Preload("Items.Product").
+		Preload("Refunds", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Refunds.Items").Preload("Refunds.Items.OrderItem.Product").Preload("Refunds.Payment").
+		First(&order, "number = ? AND user_id = ?", c.Param("number"), userID).Error
+	if err != nil {
+		return c.Redirect(http.StatusFound, "/account/orders")
+	}
+
+	data := webData(c)
+	data["Title"] = i18n.T(c, "page.order", order.Number)
+	data["Order"] = order
+	if p, ok := payments.Get(order.PaymentMethod); ok {
+		data["PaymentName"] = p.Name()
+	}
+	return c.Render(http.StatusOK, "web/account/order_detail", data)
+}
@@ -5,23 +5,27 @@ import (
 
 	"shoop-golang/database"
 	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
 
 	"github.com/labstack/echo/v4"
 )
 
 func AboutPage(c echo.Context) error {
 	data := webData(c)
-	data["Title"] = "Giới thiệu"
+	data["Title"] = i18n.T(c, "page.about")
 
 	var about models.AboutPage
-	database.DB.First(&about)
+	withTranslations(c, database.DB, "Translations").First(&about)
+	about.Localize(i18n.Locale(c))
 	data["About"] = about
+	applySEO(c, data, "about", seoMeta{})
 
 	return c.Render(http.StatusOK, "web/about/index", data)
 }
 
 func ContactPage(c echo.Context) error {
 	data := webData(c)
-	data["Title"] = "Liên hệ"
+	data["Title"] = i18n.T(c, "page.contact")
+	applySEO(c, data, "contact", seoMeta{})
 	return c.Render(http.StatusOK, "web/contact/index", data)
 }
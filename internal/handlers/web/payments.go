@@ -0,0 +1,173 @@
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/payments"
+
+	"github.com/labstack/echo/v4"
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+)
+
+// startPayment initiates the order's payment with provider and records the
+// pending charge along with the instructions shown to the customer.
+func startPayment(tx *gorm.DB, order *models.Order, provider payments.Provider) (payments.Instruction, error) {
+	instruction, err := provider.Initiate(payments.Request{
+		OrderID:     order.ID,
+		OrderNumber: order.Number,
+		Amount:      order.TotalAmount,
+	})
+	if err != nil {
+		return instruction, err
+	}
+	payload, _ := json.Marshal(instruction)
+	err = models.RecordPayment(tx, &models.Payment{
+		OrderID:   order.ID,
+		Provider:  provider.Code(),
+		Kind:      models.PaymentCharge,
+		Status:    instruction.Status,
+		Amount:    order.TotalAmount,
+		Reference: instruction.Reference,
+		Payload:   string(payload),
+	})
+	return instruction, err
+}
+
+// paymentMethod picks the provider requested at checkout, defaulting to the
+// first one offered.
+func paymentMethod(code string) (payments.Provider, bool) {
+	if code == "" {
+		if all := payments.All(); len(all) > 0 {
+			return all[0], true
+		}
+	}
+	return payments.Get(code)
+}
+
+// PaymentPage shows how to pay for an order and its payment status.
+func PaymentPage(c echo.Context) error {
+	order, ok := findCustomerOrder(c)
+	if !ok {
+		return c.Redirect(http.StatusFound, "/")
+	}
+
+	data := webData(c)
+	data["Title"] = i18n.T(c, "page.payment", order.Number)
+	data["Order"] = order
+	if p, ok := payments.Get(order.PaymentMethod); ok {
+		data["PaymentName"] = p.Name()
+	}
+	if instruction, ok := pendingInstruction(order.ID); ok {
+		data["Instruction"] = instruction
+	}
+	return c.Render(http.StatusOK, "web/payments/show", data)
+}
+
+// PaymentStatus lets the payment page poll until the transfer arrives.
+func PaymentStatus(c echo.Context) error {
+	order, ok := findCustomerOrder(c)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": i18n.T(c, "order.not_found")})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"payment_status": order.PaymentStatus})
+}
+
+// PaymentQR renders the QR code of the order's pending bank transfer.
+func PaymentQR(c echo.Context) error {
+	order, ok := findCustomerOrder(c)
+	if !ok {
+		return c.NoContent(http.StatusNotFound)
+	}
+	instruction, ok := pendingInstruction(order.ID)
+	if !ok || instruction.QRPayload == "" {
+		return c.NoContent(http.StatusNotFound)
+	}
+	png, err := qrcode.Encode(instruction.QRPayload, qrcode.Medium, 320)
+	if err != nil {
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	return c.Blob(http.StatusOK, "image/png", png)
+}
+
+// PaymentWebhook receives provider notifications. Deliveries are
+// idempotent: a transaction already recorded is acknowledged again without
+// being applied twice.
+func PaymentWebhook(c echo.Context) error {
+	provider, ok := payments.Get(c.Param("provider"))
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "unknown provider"})
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, 1<<20))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid body"})
+	}
+	if err := provider.VerifySignature(body, c.Request().Header); err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid signature"})
+	}
+	event, err := provider.HandleCallback(body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	result := "recorded"
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		tx.Model(&models.Payment{}).Where("provider = ? AND transaction_id = ?", provider.Code(), event.TransactionID).Count(&count)
+		if count > 0 {
+			result = "duplicate"
+			return nil
+		}
+
+		var order models.Order
+		if event.Reference == "" || tx.First(&order, "number = ?", event.Reference).Error != nil {
+			// Transfers unrelated to an order reach the shop account too.
+			result = "ignored"
+			return nil
+		}
+		return models.RecordPayment(tx, &models.Payment{
+			OrderID:       order.ID,
+			Provider:      provider.Code(),
+			TransactionID: event.TransactionID,
+			Kind:          models.PaymentCharge,
+			Status:        event.Status,
+			Amount:        event.Amount,
+			Reference:     event.Reference,
+			Payload:       string(body),
+		})
+	})
+	if err != nil {
+		// A concurrent delivery of the same transaction loses the race on
+		// the unique index after the other request has recorded it.
+		var count int64
+		database.DB.Model(&models.Payment{}).Where("provider = ? AND transaction_id = ?", provider.Code(), event.TransactionID).Count(&count)
+		if count > 0 {
+			return c.JSON(http.StatusOK, map[string]string{"status": "duplicate"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "could not record payment"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": result})
+}
+
+func findCustomerOrder(c echo.Context) (models.Order, bool) {
+	userID, _ := c.Get("user_id").(string)
+	var order models.Order
+	err := database.DB.First(&order, "number = ? AND user_id = ?", c.Param("number"), userID).Error
+	return order, err == nil
+}
+
+func pendingInstruction(orderID string) (payments.Instruction, bool) {
+	var p models.Payment
+	var instruction payments.Instruction
+	err := database.DB.Where("order_id = ? AND kind = ? AND status = ?", orderID, models.PaymentCharge, payments.StatusPending).
+		Order("created_at DESC").First(&p).Error
+	if err != nil || json.Unmarshal([]byte(p.Payload), &instruction) != nil {
+		return instruction, false
+	}
+	return instruction, true
+}
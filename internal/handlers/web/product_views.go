@@ -0,0 +1,126 @@
+package web
+
+import (
+	"encoding/json"
+	"time"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/session"
+
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// recentLimit is how many recently viewed products a visitor keeps.
+	recentLimit = 8
+	// trendingDays is how far back the trending sort counts views.
+	trendingDays = 7
+)
+
+// recentViewIDs returns the IDs of the products the visitor viewed last,
+// most recent first: from the account when logged in, else the session.
+func recentViewIDs(c echo.Context) []string {
+	var ids []string
+	if userID, _ := c.Get("user_id").(string); userID != "" {
+		database.DB.Model(&models.RecentView{}).Where("user_id = ?", userID).
+			Order("viewed_at DESC").Limit(recentLimit).Pluck("product_id", &ids)
+		return ids
+	}
+	if raw, ok := session.GetWebSession(c).Values["recently_viewed"].(string); ok {
+		json.Unmarshal([]byte(raw), &ids)
+	}
+	return ids
+}
+
+// recentlyViewed loads the products the visitor viewed last, leaving out
+// the one being shown.
+func recentlyViewed(c echo.Context, ids []string, exclude string) []models.Product {
+	kept := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != exclude {
+			kept = append(kept, id)
+		}
+	}
+	return productsByIDs(c, kept)
+}
+
+// trackView records a visit to the product page of productID, given the
+// visitor's recent views before it. Reloading the page counts once.
+func trackView(c echo.Context, ids []string, productID string) {
+	if len(ids) > 0 && ids[0] == productID {
+		return
+	}
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]any{"views": gorm.Expr("product_views.views + 1"), "updated_at": time.Now()}),
+	}).Create(&models.ProductView{ProductID: productID, Day: day, Views: 1})
+
+	if userID, _ := c.Get("user_id").(string); userID != "" {
+		saveRecentViews(userID, []string{productID})
+		return
+	}
+	ids = append([]string{productID}, ids...)
+	sess := session.GetWebSession(c)
+	setSessionViews(sess, ids)
+	sess.Save(c.Request(), c.Response())
+}
+
+// saveRecentViews marks ids, most recent first, as viewed by the user now
+// and forgets the views past recentLimit.
+func saveRecentViews(userID string, ids []string) {
+	now := time.Now()
+	for i, id := range ids {
+		database.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "product_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"viewed_at", "updated_at"}),
+		}).Create(&models.RecentView{UserID: userID, ProductID: id, ViewedAt: now.Add(-time.Duration(i) * time.Second)})
+	}
+	kept := database.DB.Model(&models.RecentView{}).Select("id").
+		Where("user_id = ?", userID).Order("viewed_at DESC").Limit(recentLimit)
+	database.DB.Unscoped().Where("user_id = ? AND id NOT IN (?)", userID, kept).Delete(&models.RecentView{})
+}
+
+func setSessionViews(sess *sessions.Session, ids []string) {
+	seen := map[string]bool{}
+	var kept []string
+	for _, id := range ids {
+		if !seen[id] && len(kept) < recentLimit {
+			seen[id] = true
+			kept = append(kept, id)
+		}
+	}
+	raw, _ := json.Marshal(kept)
+	sess.Values["recently_viewed"] = string(raw)
+}
+
+// keepRecentViews moves the products a guest viewed onto the account they
+// just signed in to. The caller saves the session.
+func keepRecentViews(sess *sessions.Session, userID string) {
+	raw, ok := sess.Values["recently_viewed"].(string)
+	if !ok {
+		return
+	}
+	delete(sess.Values, "recently_viewed")
+	var ids []string
+	if json.Unmarshal([]byte(raw), &ids) == nil && len(ids) > 0 {
+		saveRecentViews(userID, ids)
+	}
+}
+
+// trendingOrder sorts products by their page views over the last
+// trendingDays, then newest first.
+func trendingOrder() clause.OrderBy {
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-trendingDays)
+	views := database.DB.Model(&models.ProductView{}).Select("COALESCE(SUM(views), 0)").
+		Where("product_views.product_id = products.id AND product_views.day >= ?", since)
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:                "(?) DESC, created_at DESC",
+		Vars:               []any{views},
+		WithoutParentheses: true,
+	}}
+}
@@ -1,19 +1,23 @@
 package web
 
 import (
+	"html/template"
 	"math"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"shoop-golang/database"
 	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
 
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 func ProductList(c echo.Context) error {
 	data := webData(c)
-	data["Title"] = "Sản phẩm"
+	data["Title"] = i18n.T(c, "page.products")
 
 	page, _ := strconv.Atoi(c.QueryParam("page"))
 	if page < 1 {
@@ -24,26 +28,70 @@ func ProductList(c echo.Context) error {
 
 	query := database.DB.Model(&models.Product{}).Where("is_active = ?", true)
 
+	// scope picks the attribute filters offered, see applyFilters.
+	var scope []string
 	categorySlug := c.QueryParam("category")
 	if categorySlug != "" {
 		var cat models.Category
-		if err := database.DB.Where("slug = ?", categorySlug).First(&cat).Error; err == nil {
-			query = query.Where("category_id = ?", cat.ID)
+		id, ok := categoryBySlug(c, categorySlug)
+		moved := false
+		if !ok {
+			id, moved = movedSlug(c, models.SlugCategory, categorySlug)
+		}
+		if (ok || moved) && withTranslations(c, database.DB, "Translations").First(&cat, "id = ?", id).Error == nil {
+			cat.Localize(i18n.Locale(c))
+			if moved {
+				q := c.QueryParams()
+				q.Set("category", cat.Slug)
+				return c.Redirect(http.StatusMovedPermanently, localePath(c, "/products?"+q.Encode()))
+			}
+			// A category lists the products of its subcategories too.
+			categories := activeCategories(c)
+			descendants := models.CategoryDescendants(categories, cat.ID)
+			query = query.Where("category_id IN ?", descendants)
 			data["CurrentCategory"] = cat
+			path := models.CategoryAncestors(categories, cat.ID)
+			data["CategoryPath"] = path
+			for _, a := range path {
+				scope = append(scope, a.ID)
+			}
+			scope = append(scope, descendants...)
 		}
 	}
+	query = applyFilters(c, data, query, scope)
+	applySEO(c, data, "products", seoMeta{})
+	if cat, ok := data["CurrentCategory"].(models.Category); ok {
+		applySEO(c, data, models.SEOCategoryPage(cat.ID), seoMeta{
+			Description: metaDescription(cat.Description, 160),
+			Image:       siteURL(cat.Image),
+			URL:         siteURL(localePath(c, "/products?category="+url.QueryEscape(cat.Slug))),
+		})
+	}
 
 	search := c.QueryParam("q")
 	if search != "" {
-		query = query.Where("name LIKE ? OR description LIKE ?", "%"+search+"%", "%"+search+"%")
+		like := "%" + search + "%"
+		translated := database.DB.Model(&models.ProductTranslation{}).Select("product_id").
+			Where("locale = ? AND (name LIKE ? OR description LIKE ?)", i18n.Locale(c), like, like)
+		query = query.Where("name LIKE ? OR description LIKE ? OR id IN (?)", like, like, translated)
 		data["SearchQuery"] = search
 	}
 
 	var total int64
 	query.Count(&total)
 
+	// Newest first, unless ?sort=trending.
+	var order any = "created_at DESC"
+	data["Sort"] = ""
+	if c.QueryParam("sort") == "trending" {
+		order = trendingOrder()
+		data["Sort"] = "trending"
+		data["SortQuery"] = template.URL("&sort=trending")
+	}
+
 	var products []models.Product
-	query.Preload("Images").Preload("Category").Order("created_at DESC").Offset(offset).Limit(perPage).Find(&products)
+	withTranslations(c, query, "Translations", "Category.Translations").Preload("Images").Preload("Category").Order(order).Offset(offset).Limit(perPage).Find(&products)
+	localizeProducts(c, products)
 
 	totalPages := int(math.Ceil(float64(total) / float64(perPage)))
 
@@ -59,16 +107,55 @@ func ProductDetail(c echo.Context) error {
 	data := webData(c)
 
 	var product models.Product
-	if err := database.DB.Preload("Images").Preload("Category").Where("slug = ? AND is_active = ?", c.Param("slug"), true).First(&product).Error; err != nil {
+	id, ok := productBySlug(c, c.Param("slug"))
+	moved := false
+	if !ok {
+		if id, moved = movedSlug(c, models.SlugProduct, c.Param("slug")); !moved {
+			return c.Redirect(http.StatusFound, "/products")
+		}
+	}
+	if err := withTranslations(c, database.DB, "Translations", "Category.Translations").Preload("Images").Preload("Category").
+		Preload("Attributes.Attribute").Preload("Attributes.Value").Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("name ASC") }).
+		Where("id = ? AND is_active = ?", id, true).First(&product).Error; err != nil {
 		return c.Redirect(http.StatusFound, "/products")
 	}
+	product.Localize(i18n.Locale(c))
+	if moved {
+		target := localePath(c, "/products/"+product.Slug)
+		if q := c.Request().URL.RawQuery; q != "" {
+			target += "?" + q
+		}
+		return c.Redirect(http.StatusMovedPermanently, target)
+	}
 
 	data["Title"] = product.Name
 	data["Product"] = product
+	data["Specs"] = models.ProductSpecs(product.Attributes)
+	categoryPath := models.CategoryAncestors(activeCategories(c), product.CategoryID)
+	data["CategoryPath"] = categoryPath
+
+	fallback := seoMeta{
+		Description: metaDescription(product.Description, 160),
+		URL:         siteURL(localePath(c, "/products/"+product.Slug)),
+		Type:        "product",
+	}
+	if len(product.Images) > 0 {
+		fallback.Image = siteURL(product.Images[0].URL)
+	}
+	applySEO(c, data, models.SEOProductPage(product.ID), fallback)
+
+	bundle := productBundle(c, data, product)
+	relatedProducts(c, data, []string{product.ID}, bundle, 4)
+	recent := recentViewIDs(c)
+	data["RecentlyViewed"] = recentlyViewed(c, recent, product.ID)
+	trackView(c, recent, product.ID)
+
+	productReviews(c, data, product.ID)
+	stockSubscription(c, data, product.ID)
 
-	var related []models.Product
-	database.DB.Preload("Images").Where("category_id = ? AND id != ? AND is_active = ?", product.CategoryID, product.ID, true).Limit(4).Find(&related)
-	data["RelatedProducts"] = related
+	reviews, _ := data["Reviews"].([]models.Review)
+	addStructuredData(data, productLD(c, product, reviews))
+	addStructuredData(data, breadcrumbLD(c, product, categoryPath))
 
 	return c.Render(http.StatusOK, "web/products/detail", data)
 }
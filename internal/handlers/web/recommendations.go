@@ -0,0 +1,76 @@
+package web
+
+import (
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/internal/recommendations"
+	"shoop-golang/pkg/money"
+
+	"github.com/labstack/echo/v4"
+)
+
+// productsByIDs loads the active products with ids, in the order of ids,
+// ready for product cards.
+func productsByIDs(c echo.Context, ids []string) []models.Product {
+	if len(ids) == 0 {
+		return nil
+	}
+	var found []models.Product
+	withTranslations(c, database.DB, "Translations").Preload("Images").
+		Where("id IN ? AND is_active = ?", ids, true).Find(&found)
+	localizeProducts(c, found)
+	byID := make(map[string]models.Product, len(found))
+	for _, p := range found {
+		byID[p.ID] = p
+	}
+	products := make([]models.Product, 0, len(found))
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			products = append(products, p)
+		}
+	}
+	return products
+}
+
+// inStock keeps at most limit of products that can be ordered.
+func inStock(products []models.Product, limit int) []models.Product {
+	var kept []models.Product
+	for _, p := range products {
+		if p.Stock > 0 && len(kept) < limit {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// productBundle sets the "frequently bought together" bundle of product:
+// the product itself with up to two in-stock products customers often
+// ordered with it.
+func productBundle(c echo.Context, data map[string]any, product models.Product) []string {
+	if product.Stock <= 0 {
+		return nil
+	}
+	together := inStock(productsByIDs(c, recommendations.BoughtTogether(database.DB, []string{product.ID}, 6)), 2)
+	if len(together) == 0 {
+		return nil
+	}
+	bundle := append([]models.Product{product}, together...)
+	var total money.Money
+	ids := make([]string, len(bundle))
+	for i, p := range bundle {
+		total += p.Price()
+		ids[i] = p.ID
+	}
+	data["Bundle"] = bundle
+	data["BundleTotal"] = total
+	data["BundleIDs"] = ids
+	return ids
+}
+
+// relatedProducts sets up to limit products to suggest with ids, from
+// the order history when there is any and similar products otherwise.
+func relatedProducts(c echo.Context, data map[string]any, ids, exclude []string, limit int) {
+	related, fromOrders := recommendations.Related(database.DB, ids, exclude, limit)
+	data["RelatedProducts"] = productsByIDs(c, related)
+	data["RelatedFromOrders"] = fromOrders
+}
@@ -0,0 +1,195 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/session"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const (
+	maxReviewPhotos    = 4
+	maxReviewPhotoSize = 5 << 20
+)
+
+// reviewPhotoTypes maps the photo extensions accepted to the content type
+// the file itself must sniff as.
+var reviewPhotoTypes = map[string]string{".jpg": "image/jpeg", ".jpeg": "image/jpeg", ".png": "image/png", ".webp": "image/webp"}
+
+// deliveredOrderWith returns the ID of the customer's most recent delivered
+// order that still contains the product after cancellations.
+func deliveredOrderWith(userID, productID string) (string, bool) {
+	var ids []string
+	database.DB.Model(&models.Order{}).
+		Joins("JOIN order_items ON order_items.order_id = orders.id AND order_items.deleted_at IS NULL").
+		Where("orders.user_id = ? AND orders.status = ? AND order_items.product_id = ? AND order_items.quantity > 0", userID, "delivered", productID).
+		Order("orders.created_at DESC").Limit(1).Pluck("orders.id", &ids)
+	if len(ids) == 0 {
+		return "", false
+	}
+	return ids[0], true
+}
+
+// productReviews adds the approved reviews of a product to data and, for a
+// logged-in customer, whether they may write one or what they wrote.
+func productReviews(c echo.Context, data map[string]any, productID string) {
+	var reviews []models.Review
+	database.DB.Preload("User").Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order ASC") }).
+		Where("product_id = ? AND status = ?", productID, models.ReviewApproved).
+		Order("created_at DESC").Limit(20).Find(&reviews)
+	data["Reviews"] = reviews
+
+	userID, _ := c.Get("user_id").(string)
+	if userID == "" {
+		return
+	}
+	var mine models.Review
+	if database.DB.Where("product_id = ? AND user_id = ?", productID, userID).First(&mine).Error == nil {
+		data["MyReview"] = mine
+		return
+	}
+	_, data["CanReview"] = deliveredOrderWith(userID, productID)
+}
+
+func ReviewStore(c echo.Context) error {
+	userID, _ := c.Get("user_id").(string)
+	sess := session.GetWebSession(c)
+
+	var product models.Product
+	id, ok := productBySlug(c, c.Param("slug"))
+	if !ok || database.DB.First(&product, "id = ? AND is_active = ?", id, true).Error != nil {
+		return c.Redirect(http.StatusFound, "/products")
+	}
+	back := "/products/" + c.Param("slug") + "#reviews"
+	fail := func(key string) error {
+		session.SetFlash(c, sess, session.FlashError, i18n.T(c, key))
+		return c.Redirect(http.StatusFound, back)
+	}
+
+	orderID, ok := deliveredOrderWith(userID, product.ID)
+	if !ok {
+		return fail("review.not_eligible")
+	}
+	var count int64
+	database.DB.Model(&models.Review{}).Where("product_id = ? AND user_id = ?", product.ID, userID).Count(&count)
+	if count > 0 {
+		return fail("review.already_reviewed")
+	}
+
+	rating, _ := strconv.Atoi(c.FormValue("rating"))
+	if rating < 1 || rating > 5 {
+		return fail("review.invalid_rating")
+	}
+	var files []*multipart.FileHeader
+	if form, err := c.MultipartForm(); err == nil {
+		files = form.File["photos"]
+	}
+	if len(files) > maxReviewPhotos {
+		return fail("review.too_many_photos")
+	}
+	for _, file := range files {
+		if file.Size > maxReviewPhotoSize || !isReviewPhoto(file) {
+			return fail("review.invalid_photo")
+		}
+	}
+
+	review := models.Review{
+		ProductID: product.ID,
+		UserID:    userID,
+		OrderID:   orderID,
+		Rating:    rating,
+		Title:     strings.TrimSpace(c.FormValue("title")),
+		Body:      strings.TrimSpace(c.FormValue("body")),
+		Status:    models.ReviewPending,
+	}
+	// Photos are written once the review row exists, and removed again if
+	// anything after fails, so no file is left without a row.
+	var saved []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&review).Error; err != nil {
+			return err
+		}
+		for i, file := range files {
+			path, err := saveReviewPhoto(file)
+			if err != nil {
+				return err
+			}
+			saved = append(saved, path)
+			if err := tx.Create(&models.ReviewPhoto{ReviewID: review.ID, URL: "/" + filepath.ToSlash(path), SortOrder: i}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for _, path := range saved {
+			os.Remove(path)
+		}
+		return fail("review.save_failed")
+	}
+
+	session.SetFlash(c, sess, session.FlashSuccess, i18n.T(c, "review.submitted"))
+	return c.Redirect(http.StatusFound, back)
+}
+
+// isReviewPhoto reports whether file has an accepted extension and its
+// content sniffs as that type of image.
+func isReviewPhoto(file *multipart.FileHeader) bool {
+	want, ok := reviewPhotoTypes[strings.ToLower(filepath.Ext(file.Filename))]
+	if !ok {
+		return false
+	}
+	src, err := file.Open()
+	if err != nil {
+		return false
+	}
+	defer src.Close()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(src, head)
+	return http.DetectContentType(head[:n]) == want
+}
+
+// saveReviewPhoto writes file under uploads/reviews and returns its path.
+// A partly written file is removed.
+func saveReviewPhoto(file *multipart.FileHeader) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	filename := fmt.Sprintf("%s%s", uuid.New().String(), ext)
+	uploadDir := "uploads/reviews"
+	if err := os.MkdirAll(uploadDir, 0o755); err != nil {
+		return "", err
+	}
+	dstPath := filepath.Join(uploadDir, filename)
+
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(dst, src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dstPath)
+		return "", err
+	}
+	return dstPath, nil
+}
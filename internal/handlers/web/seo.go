@@ -0,0 +1,114 @@
+package web
+
+import (
+	"strings"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+
+	"github.com/labstack/echo/v4"
+)
+
+// seoMeta is what web/layouts/base.html puts in <head>: the title and
+// description, the canonical URL and the Open Graph / Twitter card tags.
+// URLs are absolute.
+type seoMeta struct {
+	Title       string // the whole <title>; empty uses "<page title> - OCC.IO.VN"
+	Description string
+	Keywords    string
+	Image       string
+	URL         string
+	Type        string // og:type
+	SiteName    string
+}
+
+// merge overlays the non-empty fields of o.
+func (m *seoMeta) merge(o seoMeta) {
+	overlay(&m.Title, o.Title)
+	overlay(&m.Description, o.Description)
+	overlay(&m.Keywords, o.Keywords)
+	overlay(&m.Image, o.Image)
+	overlay(&m.URL, o.URL)
+	overlay(&m.Type, o.Type)
+	overlay(&m.SiteName, o.SiteName)
+}
+
+// overlay sets *dst to src unless src is empty.
+func overlay(dst *string, src string) {
+	if src != "" {
+		*dst = src
+	}
+}
+
+// defaultSEO describes any page from the company info.
+func defaultSEO(c echo.Context, company models.CompanyInfo) seoMeta {
+	return seoMeta{
+		Description: company.Tagline,
+		Image:       siteURL(company.LogoURL),
+		URL:         siteURL(localePath(c, c.Request().URL.Path)),
+		Type:        "website",
+		SiteName:    company.Name,
+	}
+}
+
+// applySEO layers fallback, usually taken from the product or category
+// shown, and then the admin's SEOBanner entry for page over the tags
+// webData set.
+func applySEO(c echo.Context, data map[string]any, page string, fallback seoMeta) {
+	meta, _ := data["SEO"].(seoMeta)
+	meta.merge(fallback)
+
+	var banner models.SEOBanner
+	if withTranslations(c, database.DB, "Translations").First(&banner, "page = ?", page).Error == nil {
+		banner.Localize(i18n.Locale(c))
+		meta.merge(seoMeta{
+			Title:       banner.Title,
+			Description: banner.Description,
+			Keywords:    banner.Keywords,
+			Image:       siteURL(banner.OGImage),
+		})
+	}
+	data["SEO"] = meta
+}
+
+// baseURL is the public address of the shop that absolute links in
+// sitemaps, feeds and meta tags start with. It is configured rather than
+// read from the request, whose Host header the client controls.
+var baseURL = "http://localhost:8600"
+
+// SetBaseURL sets the public address of the shop, config.BaseURL.
+func SetBaseURL(u string) {
+	baseURL = strings.TrimRight(u, "/")
+}
+
+// siteURL makes a path on this site absolute; empty stays empty.
+func siteURL(path string) string {
+	if path == "" {
+		return ""
+	}
+	return absoluteURL(baseURL, path)
+}
+
+// localePath adds the URL prefix of the current locale to path.
+func localePath(c echo.Context, path string) string {
+	if locale := i18n.Locale(c); locale != i18n.Default {
+		return "/" + locale + path
+	}
+	return path
+}
+
+// metaDescription shortens s to about n characters at a word boundary for
+// a description tag.
+func metaDescription(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	cut := string(r[:n])
+	if i := strings.LastIndex(cut, " "); i > n/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:-") + "…"
+}
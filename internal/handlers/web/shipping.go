@@ -0,0 +1,100 @@
+package web
+
+import (
+	"net/http"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/money"
+
+	"github.com/labstack/echo/v4"
+)
+
+// shippingOption is one delivery method as offered for the current cart.
+type shippingOption struct {
+	Code        string      `json:"code"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Fee         money.Money `json:"fee"`
+	Available   bool        `json:"available"`
+}
+
+// cartQuote breaks the cart down into the amounts stored on the order.
+type cartQuote struct {
+	Province     string           `json:"province"`
+	ProvinceCode string           `json:"province_code"`
+	Weight       int              `json:"weight"`
+	Subtotal     money.Money      `json:"subtotal"`
+	ShippingFee  money.Money      `json:"shipping_fee"`
+	Discount     money.Money      `json:"discount"`
+	Total        money.Money      `json:"total"`
+	Selected     string           `json:"selected"`
+	Options      []shippingOption `json:"options"`
+}
+
+// quoteCart prices items for delivery to province. The requested method is
+// kept when it is available, otherwise the first available one is chosen.
+func quoteCart(items []models.CartItem, province, method string) cartQuote {
+	q := cartQuote{Province: province, Options: []shippingOption{}}
+
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		q.Subtotal += item.Price.Mul(item.Quantity)
+		ids = append(ids, item.ProductID)
+	}
+	if len(ids) > 0 {
+		var products []models.Product
+		database.DB.Select("id", "weight").Where("id IN ?", ids).Find(&products)
+		weights := make(map[string]int, len(products))
+		for _, p := range products {
+			weights[p.ID] = p.Weight
+		}
+		for _, item := range items {
+			q.Weight += weights[item.ProductID] * item.Quantity
+		}
+	}
+
+	var methods []models.ShippingMethod
+	database.DB.Preload("Rules").Where("is_active = ?", true).Order("sort_order ASC").Find(&methods)
+	for _, m := range methods {
+		fee, ok := m.Quote(province, q.Subtotal, q.Weight)
+		q.Options = append(q.Options, shippingOption{
+			Code:        m.Code,
+			Name:        m.Name,
+			Description: m.Description,
+			Fee:         fee,
+			Available:   ok,
+		})
+	}
+
+	for _, o := range q.Options {
+		if o.Available && (o.Code == method || q.Selected == "") {
+			q.Selected = o.Code
+			q.ShippingFee = o.Fee
+			if o.Code == method {
+				break
+			}
+		}
+	}
+
+	q.Total = q.Subtotal + q.ShippingFee - q.Discount
+	return q
+}
+
+// quoteProvince picks the destination for a quote: ?province_code= from the
+// division dataset, else a plain ?province= name.
+func quoteProvince(c echo.Context) (code, name string) {
+	if p, ok := lookupProvince(c.QueryParam("province_code")); ok {
+		return p.Code, p.Name
+	}
+	return "", c.QueryParam("province")
+}
+
+// ShippingQuote returns the cart totals and shipping options for the
+// destination and ?method= so the cart page can update without reloading.
+func ShippingQuote(c echo.Context) error {
+	code, name := quoteProvince(c)
+	quote := quoteCart(getCartItems(c), name, c.QueryParam("method"))
+	quote.ProvinceCode = code
+	return c.JSON(http.StatusOK, quote)
+}
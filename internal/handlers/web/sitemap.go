@@ -0,0 +1,275 @@
+package web
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// Sitemaps per https://www.sitemaps.org/protocol.html, with Google's image
+// extension and hreflang alternates for every locale.
+type sitemapIndex struct {
+	XMLName  xml.Name       `xml:"sitemapindex"`
+	XMLNS    string         `xml:"xmlns,attr"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type urlSet struct {
+	XMLName    xml.Name     `xml:"urlset"`
+	XMLNS      string       `xml:"xmlns,attr"`
+	XMLNSImage string       `xml:"xmlns:image,attr"`
+	XMLNSXHTML string       `xml:"xmlns:xhtml,attr"`
+	URLs       []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc        string             `xml:"loc"`
+	LastMod    string             `xml:"lastmod,omitempty"`
+	Alternates []sitemapAlternate `xml:"xhtml:link"`
+	Images     []sitemapImage     `xml:"image:image"`
+}
+
+type sitemapAlternate struct {
+	Rel      string `xml:"rel,attr"`
+	HrefLang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+type sitemapImage struct {
+	Loc string `xml:"image:loc"`
+}
+
+// sitemapCache keeps rendered sitemaps and robots.txt until what they are
+// built from changes. Products and categories are edited by the admin
+// process, so entries are keyed by a stamp read from the database instead
+// of being cleared from there.
+var sitemapCache = struct {
+	sync.Mutex
+	docs map[string]cachedDoc
+}{docs: map[string]cachedDoc{}}
+
+type cachedDoc struct {
+	stamp string
+	body  []byte
+}
+
+func cachedBody(key, stamp string, build func() ([]byte, error)) ([]byte, error) {
+	sitemapCache.Lock()
+	doc, ok := sitemapCache.docs[key]
+	sitemapCache.Unlock()
+	if ok && doc.stamp == stamp {
+		return doc.body, nil
+	}
+	body, err := build()
+	if err != nil {
+		return nil, err
+	}
+	sitemapCache.Lock()
+	sitemapCache.docs[key] = cachedDoc{stamp: stamp, body: body}
+	sitemapCache.Unlock()
+	return body, nil
+}
+
+// tableStamp changes whenever a row of model is added, updated or deleted.
+func tableStamp(model any) string {
+	var s struct {
+		Count     int64
+		UpdatedAt string
+		DeletedAt string
+	}
+	database.DB.Unscoped().Model(model).
+		Select("COUNT(*) AS count, MAX(updated_at) AS updated_at, MAX(deleted_at) AS deleted_at").
+		Scan(&s)
+	return fmt.Sprintf("%d/%s/%s", s.Count, s.UpdatedAt, s.DeletedAt)
+}
+
+func catalogStamp() string {
+	return tableStamp(&models.Product{}) + "|" + tableStamp(&models.Category{})
+}
+
+const sitemapMaxAge = 3600
+
+// serveCached answers with the cached document for key, the request path,
+// built when stamp has changed since it was last rendered.
+func serveCached(c echo.Context, key, stamp, contentType string, build func(base string) ([]byte, error)) error {
+	base := baseURL
+	body, err := cachedBody(key, stamp, func() ([]byte, error) { return build(base) })
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", sitemapMaxAge))
+	return c.Blob(http.StatusOK, contentType, body)
+}
+
+func marshalSitemap(v any) ([]byte, error) {
+	out, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+func newURLSet() urlSet {
+	return urlSet{
+		XMLNS:      "http://www.sitemaps.org/schemas/sitemap/0.9",
+		XMLNSImage: "http://www.google.com/schemas/sitemap-image/1.1",
+		XMLNSXHTML: "http://www.w3.org/1999/xhtml",
+	}
+}
+
+// localizedURLs lists a page once per locale, each entry pointing at all
+// the others. path returns the page's path in a locale, without prefix.
+func localizedURLs(base string, path func(locale string) string, lastMod time.Time, images []string) []sitemapURL {
+	var alternates []sitemapAlternate
+	for _, locale := range i18n.Locales {
+		alternates = append(alternates, sitemapAlternate{Rel: "alternate", HrefLang: locale, Href: base + sitemapPath(locale, path(locale))})
+	}
+	var imgs []sitemapImage
+	for _, img := range images {
+		imgs = append(imgs, sitemapImage{Loc: absoluteURL(base, img)})
+	}
+	urls := make([]sitemapURL, 0, len(alternates))
+	for _, alt := range alternates {
+		u := sitemapURL{Loc: alt.Href, Alternates: alternates, Images: imgs}
+		if !lastMod.IsZero() {
+			u.LastMod = lastMod.UTC().Format(time.RFC3339)
+		}
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+func sitemapPath(locale, path string) string {
+	if locale == i18n.Default {
+		return path
+	}
+	return "/" + locale + path
+}
+
+// Sitemap serves the sitemap index at /sitemap.xml.
+func Sitemap(c echo.Context) error {
+	return serveCached(c, "/sitemap.xml", catalogStamp(), echo.MIMEApplicationXMLCharsetUTF8, func(base string) ([]byte, error) {
+		lastMod := func(model any) string {
+			var updated string
+			database.DB.Model(model).Select("MAX(updated_at)").Scan(&updated)
+			if t := parseDBTime(updated); !t.IsZero() {
+				return t.UTC().Format(time.RFC3339)
+			}
+			return ""
+		}
+		index := sitemapIndex{
+			XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9",
+			Sitemaps: []sitemapEntry{
+				{Loc: base + "/sitemaps/pages.xml"},
+				{Loc: base + "/sitemaps/categories.xml", LastMod: lastMod(&models.Category{})},
+				{Loc: base + "/sitemaps/products.xml", LastMod: lastMod(&models.Product{})},
+			},
+		}
+		return marshalSitemap(index)
+	})
+}
+
+// SitemapPages lists the home, product list, About and Contact pages.
+func SitemapPages(c echo.Context) error {
+	var about models.AboutPage
+	database.DB.First(&about)
+	stamp := about.UpdatedAt.String()
+	return serveCached(c, "/sitemaps/pages.xml", stamp, echo.MIMEApplicationXMLCharsetUTF8, func(base string) ([]byte, error) {
+		set := newURLSet()
+		for _, path := range []string{"/", "/products", "/about", "/contact"} {
+			var lastMod time.Time
+			if path == "/about" {
+				lastMod = about.UpdatedAt
+			}
+			set.URLs = append(set.URLs, localizedURLs(base, func(string) string { return path }, lastMod, nil)...)
+		}
+		return marshalSitemap(set)
+	})
+}
+
+func SitemapCategories(c echo.Context) error {
+	return serveCached(c, "/sitemaps/categories.xml", catalogStamp(), echo.MIMEApplicationXMLCharsetUTF8, func(base string) ([]byte, error) {
+		var categories []models.Category
+		if err := database.DB.Preload("Translations").Where("is_active = ?", true).Order("sort_order ASC").Find(&categories).Error; err != nil {
+			return nil, err
+		}
+		set := newURLSet()
+		for _, cat := range categories {
+			var images []string
+			if cat.Image != "" {
+				images = []string{cat.Image}
+			}
+			path := func(locale string) string {
+				slug := cat.Slug
+				if t := cat.Translation(locale); t.Slug != "" {
+					slug = t.Slug
+				}
+				return "/products?category=" + url.QueryEscape(slug)
+			}
+			set.URLs = append(set.URLs, localizedURLs(base, path, cat.UpdatedAt, images)...)
+		}
+		return marshalSitemap(set)
+	})
+}
+
+func SitemapProducts(c echo.Context) error {
+	return serveCached(c, "/sitemaps/products.xml", catalogStamp(), echo.MIMEApplicationXMLCharsetUTF8, func(base string) ([]byte, error) {
+		var products []models.Product
+		err := database.DB.Preload("Translations").Preload("Images", func(db *gorm.DB) *gorm.DB {
+			return db.Order("is_primary DESC, sort_order ASC")
+		}).Where("is_active = ?", true).Order("created_at DESC").Find(&products).Error
+		if err != nil {
+			return nil, err
+		}
+		set := newURLSet()
+		for _, p := range products {
+			images := make([]string, 0, len(p.Images))
+			for _, img := range p.Images {
+				images = append(images, img.URL)
+			}
+			path := func(locale string) string {
+				slug := p.Slug
+				if t := p.Translation(locale); t.Slug != "" {
+					slug = t.Slug
+				}
+				return "/products/" + slug
+			}
+			set.URLs = append(set.URLs, localizedURLs(base, path, p.UpdatedAt, images)...)
+		}
+		return marshalSitemap(set)
+	})
+}
+
+// RobotsTxt serves the admin's crawler rules, or models.DefaultRobotsRules,
+// followed by the sitemap location.
+func RobotsTxt(c echo.Context) error {
+	var cfg models.RobotsConfig
+	database.DB.First(&cfg)
+	return serveCached(c, "/robots.txt", cfg.UpdatedAt.String(), echo.MIMETextPlainCharsetUTF8, func(base string) ([]byte, error) {
+		rules := strings.TrimSpace(cfg.Rules)
+		if rules == "" {
+			rules = strings.TrimSpace(models.DefaultRobotsRules)
+		}
+		var b bytes.Buffer
+		b.WriteString(strings.ReplaceAll(rules, "\r\n", "\n"))
+		fmt.Fprintf(&b, "\n\nSitemap: %s/sitemap.xml\n", base)
+		return b.Bytes(), nil
+	})
+}
@@ -0,0 +1,168 @@
+package web
+
+import (
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Structured data for search engines, as schema.org JSON-LD. webData puts
+// the Organization in data["StructuredData"]; pages append their own
+// entries and web/layouts/base.html writes each in a script tag.
+
+type ldObject map[string]any
+
+// addStructuredData appends v to what the page describes.
+func addStructuredData(data map[string]any, v ldObject) {
+	list, _ := data["StructuredData"].([]ldObject)
+	data["StructuredData"] = append(list, v)
+}
+
+// organizationLD describes the shop from the company info.
+func organizationLD(c echo.Context, company models.CompanyInfo) ldObject {
+	org := ldObject{
+		"@context": "https://schema.org",
+		"@type":    "Organization",
+		"name":     company.Name,
+		"url":      siteURL(localePath(c, "/")),
+	}
+	if company.Name == "" {
+		org["name"] = "OCC.IO.VN"
+	}
+	if company.LogoURL != "" {
+		org["logo"] = siteURL(company.LogoURL)
+	}
+	if company.Email != "" {
+		org["email"] = company.Email
+	}
+	if company.Phone != "" {
+		org["telephone"] = company.Phone
+	}
+	if company.Address != "" {
+		org["address"] = ldObject{"@type": "PostalAddress", "streetAddress": company.Address, "addressCountry": "VN"}
+	}
+	var profiles []string
+	for _, u := range []string{company.FacebookURL, company.ZaloURL} {
+		if u != "" {
+			profiles = append(profiles, u)
+		}
+	}
+	if len(profiles) > 0 {
+		org["sameAs"] = profiles
+	}
+	return org
+}
+
+// productLD describes a product with its offer and, once it has approved
+// reviews, their rating. Prices are always in VND, as orders are.
+func productLD(c echo.Context, p models.Product, reviews []models.Review) ldObject {
+	url := siteURL(localePath(c, "/products/"+p.Slug))
+	product := ldObject{
+		"@context":    "https://schema.org",
+		"@type":       "Product",
+		"name":        p.Name,
+		"description": metaDescription(p.Description, 5000),
+		"url":         url,
+	}
+	if p.SKU != "" {
+		product["sku"] = p.SKU
+	}
+	if len(p.Images) > 0 {
+		images := make([]string, 0, len(p.Images))
+		for _, img := range p.Images {
+			images = append(images, siteURL(img.URL))
+		}
+		product["image"] = images
+	}
+	if p.Category.Name != "" {
+		product["category"] = p.Category.Name
+	}
+
+	// A price of zero is shown as "contact us", which is no offer.
+	if p.Price() > 0 {
+		availability := "https://schema.org/InStock"
+		if p.Stock <= 0 {
+			availability = "https://schema.org/OutOfStock"
+		}
+		offer := ldObject{
+			"@type":         "Offer",
+			"url":           url,
+			"price":         int64(p.Price()),
+			"priceCurrency": "VND",
+			"availability":  availability,
+			"itemCondition": "https://schema.org/NewCondition",
+		}
+		if p.SalePercent() > 0 {
+			offer["priceSpecification"] = ldObject{
+				"@type":         "UnitPriceSpecification",
+				"priceType":     "https://schema.org/StrikethroughPrice",
+				"price":         int64(p.OriginalPrice),
+				"priceCurrency": "VND",
+			}
+		}
+		product["offers"] = offer
+	}
+
+	if p.RatingCount > 0 {
+		product["aggregateRating"] = ldObject{
+			"@type":       "AggregateRating",
+			"ratingValue": p.RatingAverage,
+			"reviewCount": p.RatingCount,
+			"bestRating":  5,
+			"worstRating": 1,
+		}
+		list := make([]ldObject, 0, len(reviews))
+		for _, r := range reviews {
+			review := ldObject{
+				"@type":         "Review",
+				"author":        ldObject{"@type": "Person", "name": r.User.Name},
+				"datePublished": r.CreatedAt.Format("2006-01-02"),
+				"reviewRating":  ldObject{"@type": "Rating", "ratingValue": r.Rating, "bestRating": 5},
+			}
+			if r.Title != "" {
+				review["name"] = r.Title
+			}
+			if r.Body != "" {
+				review["reviewBody"] = r.Body
+			}
+			list = append(list, review)
+		}
+		if len(list) > 0 {
+			product["review"] = list
+		}
+	}
+	return product
+}
+
+// breadcrumbLD lists the trail home → products → categories → product,
+// with path holding the parents of the product's category.
+func breadcrumbLD(c echo.Context, p models.Product, path []models.Category) ldObject {
+	type crumb struct{ name, path string }
+	crumbs := []crumb{
+		{i18n.T(c, "page.home"), "/"},
+		{i18n.T(c, "page.products"), "/products"},
+	}
+	for _, cat := range path {
+		crumbs = append(crumbs, crumb{cat.Name, "/products?category=" + cat.Slug})
+	}
+	if p.Category.Slug != "" {
+		crumbs = append(crumbs, crumb{p.Category.Name, "/products?category=" + p.Category.Slug})
+	}
+	crumbs = append(crumbs, crumb{p.Name, "/products/" + p.Slug})
+
+	items := make([]ldObject, len(crumbs))
+	for i, cr := range crumbs {
+		items[i] = ldObject{
+			"@type":    "ListItem",
+			"position": i + 1,
+			"name":     cr.name,
+			"item":     siteURL(localePath(c, cr.path)),
+		}
+	}
+	return ldObject{
+		"@context":        "https://schema.org",
+		"@type":           "BreadcrumbList",
+		"itemListElement": items,
+	}
+}
@@ -0,0 +1,127 @@
+package web
+
+import (
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// withTranslations preloads the translations of assocs ("Translations",
+// "Category.Translations") into the request's locale. Pages in the default
+// locale read the models' own columns and skip the extra queries.
+func withTranslations(c echo.Context, query *gorm.DB, assocs ...string) *gorm.DB {
+	locale := i18n.Locale(c)
+	if locale == i18n.Default {
+		return query
+	}
+	for _, assoc := range assocs {
+		query = query.Preload(assoc, "locale = ?", locale)
+	}
+	return query
+}
+
+func localizeProducts(c echo.Context, products []models.Product) {
+	locale := i18n.Locale(c)
+	for i := range products {
+		products[i].Localize(locale)
+	}
+}
+
+func localizeCategories(c echo.Context, categories []models.Category) {
+	locale := i18n.Locale(c)
+	for i := range categories {
+		categories[i].Localize(locale)
+	}
+}
+
+func localizeBanners(c echo.Context, banners []models.Banner) {
+	locale := i18n.Locale(c)
+	for i := range banners {
+		banners[i].Localize(locale)
+	}
+}
+
+// slugOwner returns the ID of the row a product or category slug names.
+// A slug of the request's locale wins, then the default slug, then a slug
+// of another locale, so links shared across languages keep working.
+func slugOwner(c echo.Context, model, translation any, fk, slug string) (string, bool) {
+	var ids []string
+	locale := i18n.Locale(c)
+	if locale != i18n.Default {
+		database.DB.Model(translation).Where("locale = ? AND slug = ?", locale, slug).Limit(1).Pluck(fk, &ids)
+		if len(ids) > 0 {
+			return ids[0], true
+		}
+	}
+	database.DB.Model(model).Where("slug = ?", slug).Limit(1).Pluck("id", &ids)
+	if len(ids) > 0 {
+		return ids[0], true
+	}
+	database.DB.Model(translation).Where("slug = ?", slug).Order("locale ASC").Limit(1).Pluck(fk, &ids)
+	if len(ids) > 0 {
+		return ids[0], true
+	}
+	return "", false
+}
+
+// activeCategories loads the visible categories in the request's locale,
+// in menu order. models.CategoryTree nests them.
+func activeCategories(c echo.Context) []models.Category {
+	var categories []models.Category
+	withTranslations(c, database.DB, "Translations").Where("is_active = ?", true).Order("sort_order ASC").Find(&categories)
+	localizeCategories(c, categories)
+	return categories
+}
+
+func productBySlug(c echo.Context, slug string) (string, bool) {
+	return slugOwner(c, &models.Product{}, &models.ProductTranslation{}, "product_id", slug)
+}
+
+func categoryBySlug(c echo.Context, slug string) (string, bool) {
+	return slugOwner(c, &models.Category{}, &models.CategoryTranslation{}, "category_id", slug)
+}
+
+// movedSlug returns the ID of the product or category that used to have
+// slug, preferring the request's locale. Callers answer with a 301 to its
+// current URL.
+func movedSlug(c echo.Context, kind, slug string) (string, bool) {
+	var ids []string
+	database.DB.Model(&models.SlugRedirect{}).Where("kind = ? AND slug = ?", kind, slug).
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: "locale = ? DESC", Vars: []any{i18n.Locale(c)}}}).
+		Limit(1).Pluck("target_id", &ids)
+	if len(ids) == 0 {
+		return "", false
+	}
+	return ids[0], true
+}
+
+// localizeCartItems shows the names of cart lines in the request's locale.
+// The session keeps the default names, which orders are placed with.
+func localizeCartItems(c echo.Context, items []models.CartItem) []models.CartItem {
+	locale := i18n.Locale(c)
+	if locale == i18n.Default || len(items) == 0 {
+		return items
+	}
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ProductID
+	}
+	var translations []models.ProductTranslation
+	database.DB.Where("locale = ? AND product_id IN ?", locale, ids).Find(&translations)
+	names := make(map[string]string, len(translations))
+	for _, t := range translations {
+		names[t.ProductID] = t.Name
+	}
+	out := make([]models.CartItem, len(items))
+	for i, item := range items {
+		if name := names[item.ProductID]; name != "" {
+			item.Name = name
+		}
+		out[i] = item
+	}
+	return out
+}
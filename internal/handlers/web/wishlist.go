@@ -0,0 +1,108 @@
+package web
+
+import (
+	"net/http"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+
+	"github.com/labstack/echo/v4"
+)
+
+func WishlistPage(c echo.Context) error {
+	userID, _ := c.Get("user_id").(string)
+	data := webData(c)
+	data["Title"] = i18n.T(c, "page.wishlist")
+
+	var items []models.Wishlist
+	withTranslations(c, database.DB, "Product.Translations").Preload("Product.Images").
+		Joins("JOIN products ON products.id = wishlists.product_id AND products.deleted_at IS NULL").
+		Where("wishlists.user_id = ?", userID).Order("wishlists.created_at DESC").Find(&items)
+	for i := range items {
+		items[i].Product.Localize(i18n.Locale(c))
+	}
+	data["Items"] = items
+
+	return c.Render(http.StatusOK, "web/account/wishlist", data)
+}
+
+func AddToWishlist(c echo.Context) error {
+	userID, ok := wishlistUser(c)
+	if !ok {
+		return loginRequired(c, "wishlist.login_required")
+	}
+	var product models.Product
+	if err := database.DB.First(&product, "id = ? AND is_active = ?", c.FormValue("product_id"), true).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": i18n.T(c, "product.not_found")})
+	}
+
+	item := models.Wishlist{UserID: userID, ProductID: product.ID}
+	if err := database.DB.Where(item).Attrs(models.Wishlist{Locale: i18n.Locale(c)}).FirstOrCreate(&item).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": i18n.T(c, "common.error")})
+	}
+	return wishlistJSON(c, userID, true)
+}
+
+func RemoveFromWishlist(c echo.Context) error {
+	userID, ok := wishlistUser(c)
+	if !ok {
+		return loginRequired(c, "wishlist.login_required")
+	}
+	database.DB.Unscoped().Where("user_id = ? AND product_id = ?", userID, c.FormValue("product_id")).Delete(&models.Wishlist{})
+	return wishlistJSON(c, userID, false)
+}
+
+// MoveWishlistToCart puts one of a saved product in the cart and takes it
+// off the wishlist.
+func MoveWishlistToCart(c echo.Context) error {
+	userID, ok := wishlistUser(c)
+	if !ok {
+		return loginRequired(c, "wishlist.login_required")
+	}
+	var product models.Product
+	if err := database.DB.Preload("Images").First(&product, "id = ? AND is_active = ?", c.FormValue("product_id"), true).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": i18n.T(c, "product.not_found")})
+	}
+	if product.Stock <= 0 {
+		return c.JSON(http.StatusConflict, map[string]string{"error": i18n.T(c, "wishlist.out_of_stock")})
+	}
+
+	items := addCartItem(c, product, 1)
+	database.DB.Unscoped().Where("user_id = ? AND product_id = ?", userID, product.ID).Delete(&models.Wishlist{})
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"status":        "ok",
+		"cartCount":     cartCount(items),
+		"wishlistCount": wishlistCount(userID),
+	})
+}
+
+func wishlistUser(c echo.Context) (string, bool) {
+	userID, _ := c.Get("user_id").(string)
+	return userID, userID != ""
+}
+
+func wishlistJSON(c echo.Context, userID string, wishlisted bool) error {
+	return c.JSON(http.StatusOK, map[string]any{
+		"status":        "ok",
+		"wishlisted":    wishlisted,
+		"wishlistCount": wishlistCount(userID),
+	})
+}
+
+func wishlistCount(userID string) int64 {
+	var n int64
+	database.DB.Model(&models.Wishlist{}).Where("user_id = ?", userID).Count(&n)
+	return n
+}
+
+// wishlistIDs returns the IDs of the products a customer saved, for marking
+// the hearts on product cards.
+func wishlistIDs(userID string) []string {
+	ids := []string{}
+	if userID != "" {
+		database.DB.Model(&models.Wishlist{}).Where("user_id = ?", userID).Pluck("product_id", &ids)
+	}
+	return ids
+}
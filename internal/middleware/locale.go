@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"strings"
+
+	"shoop-golang/pkg/i18n"
+
+	"github.com/labstack/echo/v4"
+)
+
+// WebLocale picks the storefront locale from a URL prefix (/en/products),
+// the lang cookie or Accept-Language, in that order. It runs before routing:
+// the prefix is stripped so the same routes serve every locale, and
+// remembered in the cookie so links without it keep the locale.
+func WebLocale(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		if locale, rest, ok := localePrefix(req.URL.Path); ok {
+			req.URL.Path = rest
+			req.URL.RawPath = ""
+			i18n.SetCookie(c, locale)
+			c.Set(i18n.ContextKey, locale)
+			return next(c)
+		}
+		c.Set(i18n.ContextKey, requestLocale(c))
+		return next(c)
+	}
+}
+
+// AdminLocale picks the admin locale from the lang cookie or
+// Accept-Language.
+func AdminLocale(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Set(i18n.ContextKey, requestLocale(c))
+		return next(c)
+	}
+}
+
+func requestLocale(c echo.Context) string {
+	if cookie, err := c.Cookie(i18n.CookieName); err == nil && i18n.Supported(cookie.Value) {
+		return cookie.Value
+	}
+	return i18n.Negotiate(c.Request().Header.Get("Accept-Language"))
+}
+
+// localePrefix splits "/en/products" into "en" and "/products".
+func localePrefix(path string) (locale, rest string, ok bool) {
+	trimmed := strings.TrimPrefix(path, "/")
+	locale, rest, _ = strings.Cut(trimmed, "/")
+	if !i18n.Supported(locale) {
+		return "", path, false
+	}
+	return locale, "/" + rest, true
+}
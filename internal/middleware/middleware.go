@@ -3,6 +3,7 @@ package middleware
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
 
 	"shoop-golang/internal/models"
 	"shoop-golang/pkg/session"
@@ -28,10 +29,13 @@ func WebAuth(next echo.HandlerFunc) echo.HandlerFunc {
 		sess := session.GetWebSession(c)
 		userID, ok := sess.Values["user_id"].(string)
 		if !ok || userID == "" {
-			return c.Redirect(http.StatusFound, "/login")
+			// The storefront logs in through a modal, so send the visitor
+			// to the home page with it open and come back afterwards.
+			return c.Redirect(http.StatusFound, "/?login=1&redirect="+url.QueryEscape(c.Request().URL.RequestURI()))
 		}
 		c.Set("user_id", userID)
 		c.Set("user_name", sess.Values["user_name"])
+		c.Set("is_logged_in", true)
 		return next(c)
 	}
 }
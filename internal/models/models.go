@@ -1,10 +1,18 @@
 package models
 
 import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
 	"time"
 
+	"shoop-golang/pkg/money"
+	"shoop-golang/pkg/payments"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BaseModel struct {
@@ -40,6 +48,11 @@ type User struct {
 	Phone    string  `json:"phone"`
 	Address  string  `json:"address"`
 	Orders   []Order `gorm:"foreignKey:UserID" json:"orders,omitempty"`
+
+	// BirthYear (lunar) and Gender, when the user saved them, pick the
+	// products suiting their element on the home page. See pkg/fengshui.
+	BirthYear int    `gorm:"default:0" json:"birth_year,omitempty"`
+	Gender    string `gorm:"size:8" json:"gender,omitempty"`
 }
 
 type Category struct {
@@ -48,33 +61,164 @@ type Category struct {
 	Slug        string    `gorm:"uniqueIndex;not null" json:"slug"`
 	Description string    `json:"description"`
 	Image       string    `json:"image"`
+	ParentID    *string   `gorm:"index" json:"parent_id"` // nil for a top-level category
 	SortOrder   int       `gorm:"default:0" json:"sort_order"`
 	IsActive    bool      `gorm:"default:true" json:"is_active"`
 	Products    []Product `gorm:"foreignKey:CategoryID" json:"products,omitempty"`
+
+	// Children is filled in by CategoryTree.
+	Children []Category `gorm:"-" json:"children,omitempty"`
+
+	Translations []CategoryTranslation `gorm:"foreignKey:CategoryID" json:"translations,omitempty"`
+}
+
+// MaxCategoryDepth is how deep categories nest, counting the top level:
+// Vòng tay → Thạch anh → Thạch anh tím.
+const MaxCategoryDepth = 3
+
+// CategoryTree nests categories under their parents, keeping their order.
+// A category whose parent is not in the list is a root.
+func CategoryTree(categories []Category) []Category {
+	known := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		known[c.ID] = true
+	}
+	var roots []Category
+	children := map[string][]Category{}
+	for _, c := range categories {
+		if c.ParentID != nil && known[*c.ParentID] {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+	var attach func(level []Category, depth int) []Category
+	attach = func(level []Category, depth int) []Category {
+		for i := range level {
+			if depth < MaxCategoryDepth {
+				level[i].Children = attach(children[level[i].ID], depth+1)
+			}
+		}
+		return level
+	}
+	return attach(roots, 1)
+}
+
+// CategoryAncestors returns the parents of category id found in categories,
+// the top level first.
+func CategoryAncestors(categories []Category, id string) []Category {
+	byID := make(map[string]Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+	var path []Category
+	seen := map[string]bool{id: true}
+	c, ok := byID[id]
+	for ok && c.ParentID != nil && !seen[*c.ParentID] {
+		seen[*c.ParentID] = true
+		if c, ok = byID[*c.ParentID]; ok {
+			path = append([]Category{c}, path...)
+		}
+	}
+	return path
+}
+
+// CategoryDescendants returns id and the IDs of every category below it.
+func CategoryDescendants(categories []Category, id string) []string {
+	children := map[string][]string{}
+	for _, c := range categories {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+	ids := []string{id}
+	seen := map[string]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if !seen[child] {
+				seen[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+	return ids
+}
+
+// CategoryHeight counts the levels from category id down to its deepest
+// descendant: 1 for a category without children.
+func CategoryHeight(categories []Category, id string) int {
+	children := map[string][]string{}
+	for _, c := range categories {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+	var height func(id string, depth int) int
+	height = func(id string, depth int) int {
+		h := 1
+		if depth > len(categories) {
+			return h // a cycle; stop
+		}
+		for _, child := range children[id] {
+			h = max(h, 1+height(child, depth+1))
+		}
+		return h
+	}
+	return height(id, 0)
 }
 
 type Product struct {
 	BaseModel
-	Name          string   `gorm:"not null" json:"name"`
-	Slug          string   `gorm:"uniqueIndex;not null" json:"slug"`
-	Description   string   `gorm:"type:text" json:"description"`
-	Content       string   `gorm:"type:text" json:"content"`
-	OriginalPrice float64  `gorm:"not null" json:"original_price"`
-	SalePrice     float64  `json:"sale_price"`
-	SKU           string   `gorm:"uniqueIndex" json:"sku"`
-	Stock         int      `gorm:"default:0" json:"stock"`
-	CategoryID    string   `gorm:"index" json:"category_id"`
-	Category      Category `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
-	Images        []Image  `gorm:"foreignKey:ProductID" json:"images,omitempty"`
-	IsActive      bool     `gorm:"default:true" json:"is_active"`
-	IsFeatured    bool     `gorm:"default:false" json:"is_featured"`
+	Name          string      `gorm:"not null" json:"name"`
+	Slug          string      `gorm:"uniqueIndex;not null" json:"slug"`
+	Description   string      `gorm:"type:text" json:"description"`
+	Content       string      `gorm:"type:text" json:"content"`
+	OriginalPrice money.Money `gorm:"not null" json:"original_price"`
+	SalePrice     money.Money `json:"sale_price"`
+	SKU           string      `gorm:"uniqueIndex" json:"sku"`
+	Stock         int         `gorm:"default:0" json:"stock"`
+	Weight        int         `gorm:"default:0" json:"weight"` // grams, used for shipping fees
+	CategoryID    string      `gorm:"index" json:"category_id"`
+	Category      Category    `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	Images        []Image     `gorm:"foreignKey:ProductID" json:"images,omitempty"`
+	IsActive      bool        `gorm:"default:true" json:"is_active"`
+	IsFeatured    bool        `gorm:"default:false" json:"is_featured"`
+	// RatingAverage and RatingCount cache the approved reviews, see
+	// RefreshRating.
+	RatingAverage float64 `gorm:"not null;default:0" json:"rating_average"`
+	RatingCount   int     `gorm:"not null;default:0" json:"rating_count"`
+
+	Attributes []ProductAttribute `gorm:"foreignKey:ProductID" json:"attributes,omitempty"`
+	Tags       []Tag              `gorm:"many2many:product_tags" json:"tags,omitempty"`
+
+	Translations []ProductTranslation `gorm:"foreignKey:ProductID" json:"translations,omitempty"`
 }
 
 func (p Product) SalePercent() int {
 	if p.OriginalPrice <= 0 || p.SalePrice <= 0 || p.SalePrice >= p.OriginalPrice {
 		return 0
 	}
-	return int(((p.OriginalPrice - p.SalePrice) / p.OriginalPrice) * 100)
+	return int((p.OriginalPrice - p.SalePrice) * 100 / p.OriginalPrice)
+}
+
+// Price is what the product sells for: the sale price when set.
+func (p Product) Price() money.Money {
+	if p.SalePrice > 0 {
+		return p.SalePrice
+	}
+	return p.OriginalPrice
+}
+
+// StockChange returns the UpdateColumns values that move a product's stock
+// by delta. updated_at only moves when the product sells out or comes back
+// in stock, so orders and restocks don't mark the catalog as edited for the
+// sitemaps and the merchant feed.
+func StockChange(delta int) map[string]any {
+	return map[string]any{
+		"stock": gorm.Expr("stock + ?", delta),
+		"updated_at": gorm.Expr("CASE WHEN (stock > 0) <> (stock + ? > 0) THEN ? ELSE updated_at END",
+			delta, time.Now()),
+	}
 }
 
 // ImageURL returns the URL of the primary (or first) product image.
@@ -99,26 +243,486 @@ type Image struct {
 	IsPrimary bool   `gorm:"default:false" json:"is_primary"`
 }
 
+// OrderNumberPrefix starts every customer-facing order number.
+const OrderNumberPrefix = "OCC"
+
 type Order struct {
 	BaseModel
-	UserID      string      `gorm:"index;not null" json:"user_id"`
-	User        User        `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Status      string      `gorm:"default:pending" json:"status"` // pending, confirmed, shipping, delivered, cancelled
-	TotalAmount float64     `gorm:"not null" json:"total_amount"`
-	Name        string      `json:"name"`
-	Phone       string      `json:"phone"`
-	Address     string      `gorm:"type:text" json:"address"`
-	Note        string      `gorm:"type:text" json:"note"`
-	Items       []OrderItem `gorm:"foreignKey:OrderID" json:"items,omitempty"`
+	Number         string      `gorm:"uniqueIndex;default:null" json:"number"` // OCC-2026-000123, see BeforeCreate
+	UserID         string      `gorm:"index;not null" json:"user_id"`
+	User           User        `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Status         string      `gorm:"default:pending" json:"status"` // pending, confirmed, shipping, delivered, cancelled
+	Subtotal       money.Money `gorm:"not null;default:0" json:"subtotal"`
+	ShippingFee    money.Money `gorm:"not null;default:0" json:"shipping_fee"`
+	Discount       money.Money `gorm:"not null;default:0" json:"discount"`
+	TotalAmount    money.Money `gorm:"not null" json:"total_amount"`                // grand total: subtotal + shipping - discount
+	Currency       string      `gorm:"size:3;not null;default:VND" json:"currency"` // money.Currency
+	DisplayCode    string      `gorm:"size:3" json:"display_code"`                  // currency the shopper browsed in
+	DisplayRate    float64     `json:"display_rate"`                                // đồng per unit of DisplayCode at checkout
+	Name           string      `json:"name"`
+	Phone          string      `json:"phone"`
+	ProvinceCode   string      `gorm:"index" json:"province_code"`
+	Province       string      `json:"province"`
+	DistrictCode   string      `json:"district_code"`
+	District       string      `json:"district"`
+	WardCode       string      `json:"ward_code"`
+	Ward           string      `json:"ward"`
+	Address        string      `gorm:"type:text" json:"address"`          // street line: house number, street
+	Shipping       string      `json:"shipping"`                          // ShippingMethod.Code
+	PaymentMethod  string      `gorm:"default:cod" json:"payment_method"` // payments.Provider code
+	PaymentStatus  string      `gorm:"default:unpaid;index" json:"payment_status"`
+	PaidAt         *time.Time  `json:"paid_at"`
+	RefundedAmount money.Money `gorm:"not null;default:0" json:"refunded_amount"` // total taken off by refunds
+	Note           string      `gorm:"type:text" json:"note"`
+	Locale         string      `gorm:"size:8" json:"locale"` // language the customer ordered in, for documents sent to them
+	Items          []OrderItem `gorm:"foreignKey:OrderID" json:"items,omitempty"`
+	Invoice        *Invoice    `gorm:"foreignKey:OrderID" json:"invoice,omitempty"`
+	Payments       []Payment   `gorm:"foreignKey:OrderID" json:"payments,omitempty"`
+	Refunds        []Refund    `gorm:"foreignKey:OrderID" json:"refunds,omitempty"`
+}
+
+// Order payment statuses.
+const (
+	PaymentUnpaid   = "unpaid"
+	PaymentPaid     = "paid"
+	PaymentFailed   = "failed"
+	PaymentRefunded = "refunded"
+)
+
+// FullAddress joins the street line with the ward, district and province.
+func (o Order) FullAddress() string {
+	return joinAddress(o.Address, o.Ward, o.District, o.Province)
+}
+
+// BeforeCreate assigns the next order number for the current year in the
+// same transaction as the insert, so a failed checkout does not burn a number.
+func (o *Order) BeforeCreate(tx *gorm.DB) error {
+	if err := o.BaseModel.BeforeCreate(tx); err != nil {
+		return err
+	}
+	if o.Number != "" {
+		return nil
+	}
+	year := time.Now().Year()
+	n, err := NextSequence(tx.Session(&gorm.Session{NewDB: true}), fmt.Sprintf("order-%d", year))
+	if err != nil {
+		return err
+	}
+	o.Number = FormatOrderNumber(year, n)
+	return nil
+}
+
+// FormatOrderNumber renders the n-th order of year, e.g. OCC-2026-000123.
+func FormatOrderNumber(year int, n int64) string {
+	return fmt.Sprintf("%s-%d-%06d", OrderNumberPrefix, year, n)
 }
 
 type OrderItem struct {
 	BaseModel
-	OrderID   string  `gorm:"index;not null" json:"order_id"`
-	ProductID string  `gorm:"index;not null" json:"product_id"`
+	OrderID   string      `gorm:"index;not null" json:"order_id"`
+	ProductID string      `gorm:"index;not null" json:"product_id"`
+	Product   Product     `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Quantity  int         `gorm:"not null" json:"quantity"` // still to be delivered and charged
+	Price     money.Money `gorm:"not null" json:"price"`
+	// CancelledQuantity counts the units already taken off the line by
+	// refunds; the customer originally ordered Quantity + CancelledQuantity.
+	CancelledQuantity int `gorm:"not null;default:0" json:"cancelled_quantity"`
+}
+
+// Subtotal returns the line total (price × quantity).
+func (i OrderItem) Subtotal() money.Money {
+	return i.Price.Mul(i.Quantity)
+}
+
+// OrderedQuantity returns the quantity placed at checkout.
+func (i OrderItem) OrderedQuantity() int {
+	return i.Quantity + i.CancelledQuantity
+}
+
+// Refund records order lines cancelled or returned after checkout. Amount is
+// how much the order total went down; when the order had already been paid
+// the money given back is recorded as a refund Payment.
+type Refund struct {
+	BaseModel
+	OrderID   string       `gorm:"index;not null" json:"order_id"`
+	Amount    money.Money  `gorm:"not null" json:"amount"`
+	Reason    string       `gorm:"type:text;not null" json:"reason"`
+	Restocked bool         `gorm:"default:false" json:"restocked"`
+	PaymentID *string      `gorm:"index" json:"payment_id"`
+	Payment   *Payment     `gorm:"foreignKey:PaymentID" json:"payment,omitempty"`
+	Items     []RefundItem `gorm:"foreignKey:RefundID" json:"items,omitempty"`
+}
+
+type RefundItem struct {
+	BaseModel
+	RefundID    string      `gorm:"index;not null" json:"refund_id"`
+	OrderItemID string      `gorm:"index;not null" json:"order_item_id"`
+	OrderItem   OrderItem   `gorm:"foreignKey:OrderItemID" json:"order_item,omitempty"`
+	Quantity    int         `gorm:"not null" json:"quantity"`
+	Amount      money.Money `gorm:"not null" json:"amount"`
+}
+
+// Review is a customer's rating of a product from one of their delivered
+// orders, at most one per customer and product. It is shown on the
+// storefront once an admin approves it.
+type Review struct {
+	BaseModel
+	ProductID string        `gorm:"uniqueIndex:idx_review_product_user;not null" json:"product_id"`
+	Product   Product       `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	UserID    string        `gorm:"uniqueIndex:idx_review_product_user;not null" json:"user_id"`
+	User      User          `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	OrderID   string        `gorm:"index;not null" json:"order_id"` // the delivered order that qualified it
+	Rating    int           `gorm:"not null" json:"rating"`         // 1 to 5
+	Title     string        `json:"title"`
+	Body      string        `gorm:"type:text" json:"body"`
+	Status    string        `gorm:"not null;default:pending;index" json:"status"` // ReviewPending, ReviewApproved, ReviewRejected
+	Reply     string        `gorm:"type:text" json:"reply"`                       // the shop's public answer
+	RepliedAt *time.Time    `json:"replied_at"`
+	Photos    []ReviewPhoto `gorm:"foreignKey:ReviewID" json:"photos,omitempty"`
+}
+
+// Review moderation statuses.
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+type ReviewPhoto struct {
+	BaseModel
+	ReviewID  string `gorm:"index;not null" json:"review_id"`
+	URL       string `gorm:"not null" json:"url"`
+	SortOrder int    `gorm:"default:0" json:"sort_order"`
+}
+
+// RefreshRating recomputes the cached rating of a product from its approved
+// reviews. Call it in the transaction that changes a review's status.
+func RefreshRating(tx *gorm.DB, productID string) error {
+	var agg struct {
+		Average float64
+		Count   int
+	}
+	err := tx.Model(&Review{}).Where("product_id = ? AND status = ?", productID, ReviewApproved).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").Scan(&agg).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&Product{}).Where("id = ?", productID).Updates(map[string]any{
+		"rating_average": math.Round(agg.Average*10) / 10,
+		"rating_count":   agg.Count,
+	}).Error
+}
+
+// Wishlist is a product a customer saved for later. Locale is the language
+// they browsed in, used for the emails sent about the product.
+type Wishlist struct {
+	BaseModel
+	UserID    string  `gorm:"uniqueIndex:idx_wishlist_user_product;not null" json:"user_id"`
+	ProductID string  `gorm:"uniqueIndex:idx_wishlist_user_product;not null" json:"product_id"`
 	Product   Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
-	Quantity  int     `gorm:"not null" json:"quantity"`
-	Price     float64 `gorm:"not null" json:"price"`
+	Locale    string  `gorm:"size:8" json:"locale"`
+}
+
+// RecentView is a product a customer looked at, for their recently viewed
+// products; guests keep the list in their session instead.
+type RecentView struct {
+	BaseModel
+	UserID    string    `gorm:"uniqueIndex:idx_recent_view_user_product;not null" json:"user_id"`
+	ProductID string    `gorm:"uniqueIndex:idx_recent_view_user_product;not null" json:"product_id"`
+	ViewedAt  time.Time `gorm:"index" json:"viewed_at"`
+}
+
+// ProductView counts the visits to a product's page on one day (UTC), for
+// sorting the product list by what is trending.
+type ProductView struct {
+	BaseModel
+	ProductID string    `gorm:"uniqueIndex:idx_product_view_day;not null" json:"product_id"`
+	Day       time.Time `gorm:"uniqueIndex:idx_product_view_day;index;not null" json:"day"`
+	Views     int       `gorm:"not null" json:"views"`
+}
+
+// ProductAffinity records that customers bought RelatedID in the same
+// order as ProductID. The recommendations job rebuilds the table from the
+// order history, with a row each way for every pair.
+type ProductAffinity struct {
+	BaseModel
+	ProductID string  `gorm:"uniqueIndex:idx_product_affinity;not null" json:"product_id"`
+	RelatedID string  `gorm:"uniqueIndex:idx_product_affinity;index;not null" json:"related_id"`
+	Orders    int     `gorm:"not null" json:"orders"` // orders holding both
+	Score     float64 `gorm:"not null" json:"score"`  // Orders over the orders holding ProductID
+}
+
+// Notification is an email about a product waiting in the outbox, written
+// in the same transaction as the change that caused it and delivered by
+// the notifications job.
+type Notification struct {
+	BaseModel
+	UserID    *string    `gorm:"index" json:"user_id"`
+	Email     string     `gorm:"not null" json:"email"`
+	Locale    string     `gorm:"size:8" json:"locale"`
+	Kind      string     `gorm:"not null" json:"kind"` // NotifyPriceDrop, NotifyRestock, NotifyConfirm
+	ProductID string     `gorm:"index;not null" json:"product_id"`
+	Product   Product    `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Status    string     `gorm:"not null;default:queued;index" json:"status"` // NotificationQueued, NotificationSent, NotificationFailed
+	Attempts  int        `gorm:"not null;default:0" json:"attempts"`
+	Error     string     `json:"error"`
+	SentAt    *time.Time `json:"sent_at"`
+	// UnsubscribeToken links the email to the StockSubscription it came
+	// from; empty for wishlist emails.
+	UnsubscribeToken string `gorm:"size:64" json:"-"`
+}
+
+// StockSubscription asks for one email when an out-of-stock product is
+// back. Guests leave an address; customers are linked by UserID. Token
+// identifies the subscription in confirm and unsubscribe links. A guest's
+// address gets nothing but the confirmation email until ConfirmedAt is set.
+type StockSubscription struct {
+	BaseModel
+	ProductID   string     `gorm:"uniqueIndex:idx_stock_sub_product_email;not null" json:"product_id"`
+	Product     Product    `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	UserID      *string    `gorm:"index" json:"user_id"`
+	Email       string     `gorm:"uniqueIndex:idx_stock_sub_product_email;not null" json:"email"`
+	Locale      string     `gorm:"size:8" json:"locale"`
+	Token       string     `gorm:"uniqueIndex;not null" json:"-"`
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+	NotifiedAt  *time.Time `json:"notified_at"`
+}
+
+// Notification kinds.
+const (
+	NotifyPriceDrop = "price_drop"
+	NotifyRestock   = "restock"
+	// NotifyConfirm asks a guest to confirm a stock subscription.
+	NotifyConfirm = "confirm"
+)
+
+// Notification statuses.
+const (
+	NotificationQueued = "queued"
+	NotificationSent   = "sent"
+	NotificationFailed = "failed"
+)
+
+// Invoice is issued at most once per order; Number is sequential per year
+// (e.g. INV-2026-000042).
+type Invoice struct {
+	BaseModel
+	OrderID  string    `gorm:"uniqueIndex;not null" json:"order_id"`
+	Number   string    `gorm:"uniqueIndex;not null" json:"number"`
+	IssuedAt time.Time `gorm:"not null" json:"issued_at"`
+}
+
+// Payment is one transaction with a payment provider: the charge started at
+// checkout, a settlement reported by webhook, or a refund. Provider and
+// TransactionID identify a webhook delivery, so a repeated notification
+// cannot be recorded twice.
+type Payment struct {
+	BaseModel
+	OrderID       string      `gorm:"index;not null" json:"order_id"`
+	Provider      string      `gorm:"not null;uniqueIndex:idx_payments_transaction" json:"provider"`
+	TransactionID string      `gorm:"uniqueIndex:idx_payments_transaction;default:null" json:"transaction_id"`
+	Kind          string      `gorm:"not null" json:"kind"`   // PaymentCharge, PaymentRefund
+	Status        string      `gorm:"not null" json:"status"` // payments.Status*
+	Amount        money.Money `gorm:"not null" json:"amount"`
+	Reference     string      `json:"reference"`
+	Payload       string      `gorm:"type:text" json:"payload"` // instruction or raw notification
+}
+
+// Payment kinds.
+const (
+	PaymentCharge = "charge"
+	PaymentRefund = "refund"
+)
+
+// RecordPayment stores p and settles its order once the succeeded charges
+// cover the order total. A failed charge marks an unpaid order as failed so
+// staff can follow up; a later success still settles it.
+func RecordPayment(tx *gorm.DB, p *Payment) error {
+	if err := tx.Create(p).Error; err != nil {
+		return err
+	}
+	return settlePayment(tx, p)
+}
+
+// CompletePayment marks a pending charge as succeeded, e.g. when the
+// courier has collected cash on delivery.
+func CompletePayment(tx *gorm.DB, p *Payment) error {
+	p.Status = payments.StatusSucceeded
+	if err := tx.Model(p).Update("status", p.Status).Error; err != nil {
+		return err
+	}
+	return settlePayment(tx, p)
+}
+
+func settlePayment(tx *gorm.DB, p *Payment) error {
+	if p.Kind != PaymentCharge {
+		return nil
+	}
+
+	var order Order
+	if err := tx.First(&order, "id = ?", p.OrderID).Error; err != nil {
+		return err
+	}
+	switch p.Status {
+	case payments.StatusSucceeded:
+		var paid money.Money
+		tx.Model(&Payment{}).Where("order_id = ? AND kind = ? AND status = ?", order.ID, PaymentCharge, payments.StatusSucceeded).
+			Select("COALESCE(SUM(amount), 0)").Scan(&paid)
+		if paid >= order.TotalAmount && order.PaymentStatus != PaymentPaid {
+			now := time.Now()
+			return tx.Model(&order).Updates(map[string]any{"payment_status": PaymentPaid, "paid_at": &now}).Error
+		}
+	case payments.StatusFailed:
+		if order.PaymentStatus == PaymentUnpaid {
+			return tx.Model(&order).Update("payment_status", PaymentFailed).Error
+		}
+	}
+	return nil
+}
+
+// Sequence is a named counter used to hand out gap-free document numbers.
+type Sequence struct {
+	Name  string `gorm:"primaryKey" json:"name"`
+	Value int64  `gorm:"not null;default:0" json:"value"`
+}
+
+// NextSequence increments the named counter and returns its new value.
+// Call it inside a transaction: the first statement is a write, so SQLite
+// serialises concurrent callers and no two of them get the same number.
+func NextSequence(tx *gorm.DB, name string) (int64, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&Sequence{Name: name}).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&Sequence{}).Where("name = ?", name).
+		Update("value", gorm.Expr("value + 1")).Error; err != nil {
+		return 0, err
+	}
+	var seq Sequence
+	if err := tx.First(&seq, "name = ?", name).Error; err != nil {
+		return 0, err
+	}
+	return seq.Value, nil
+}
+
+// Province, District and Ward hold the administrative divisions of Vietnam
+// keyed by their official codes. They are loaded by the seeder.
+type Province struct {
+	Code      string     `gorm:"primaryKey" json:"code"`
+	Name      string     `gorm:"not null" json:"name"`
+	Districts []District `gorm:"foreignKey:ProvinceCode" json:"districts,omitempty"`
+}
+
+type District struct {
+	Code         string `gorm:"primaryKey" json:"code"`
+	ProvinceCode string `gorm:"index;not null" json:"province_code"`
+	Name         string `gorm:"not null" json:"name"`
+	Wards        []Ward `gorm:"foreignKey:DistrictCode" json:"wards,omitempty"`
+}
+
+type Ward struct {
+	Code         string `gorm:"primaryKey" json:"code"`
+	DistrictCode string `gorm:"index;not null" json:"district_code"`
+	Name         string `gorm:"not null" json:"name"`
+}
+
+// UserAddress is an entry in a customer's address book. At most one per
+// user has IsDefault set.
+type UserAddress struct {
+	BaseModel
+	UserID       string `gorm:"index;not null" json:"user_id"`
+	Name         string `gorm:"not null" json:"name"`
+	Phone        string `gorm:"not null" json:"phone"`
+	ProvinceCode string `json:"province_code"`
+	Province     string `json:"province"`
+	DistrictCode string `json:"district_code"`
+	District     string `json:"district"`
+	WardCode     string `json:"ward_code"`
+	Ward         string `json:"ward"`
+	Address      string `gorm:"type:text" json:"address"`
+	IsDefault    bool   `gorm:"default:false" json:"is_default"`
+}
+
+func (a UserAddress) FullAddress() string {
+	return joinAddress(a.Address, a.Ward, a.District, a.Province)
+}
+
+func joinAddress(parts ...string) string {
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, ", ")
+}
+
+// ShippingMethod is a delivery option offered at checkout. Its fee depends
+// on the destination province, the order subtotal and the parcel weight,
+// as described by its rules.
+type ShippingMethod struct {
+	BaseModel
+	Code        string         `gorm:"uniqueIndex;not null" json:"code"` // standard, express, pickup
+	Name        string         `gorm:"not null" json:"name"`
+	Description string         `json:"description"`
+	SortOrder   int            `gorm:"default:0" json:"sort_order"`
+	IsActive    bool           `gorm:"default:true" json:"is_active"`
+	Rules       []ShippingRule `gorm:"foreignKey:MethodID" json:"rules,omitempty"`
+}
+
+// ShippingRule prices a method for one province. A rule with an empty
+// Province applies to every province without a rule of its own; a method
+// with neither is not available there.
+type ShippingRule struct {
+	BaseModel
+	MethodID       string      `gorm:"index;not null" json:"method_id"`
+	Province       string      `json:"province"`
+	BaseFee        money.Money `gorm:"default:0" json:"base_fee"`
+	IncludedWeight int         `gorm:"default:0" json:"included_weight"` // grams covered by BaseFee
+	PerKgFee       money.Money `gorm:"default:0" json:"per_kg_fee"`      // each started kg above IncludedWeight
+	MaxWeight      int         `gorm:"default:0" json:"max_weight"`      // grams, 0 = no limit
+	FreeOver       money.Money `gorm:"default:0" json:"free_over"`       // subtotal for free shipping, 0 = never
+}
+
+// RuleFor returns the rule that applies to province, preferring an exact
+// match over the catch-all rule.
+func (m ShippingMethod) RuleFor(province string) (ShippingRule, bool) {
+	var fallback *ShippingRule
+	for i, r := range m.Rules {
+		if r.Province == "" {
+			if fallback == nil {
+				fallback = &m.Rules[i]
+			}
+			continue
+		}
+		if strings.EqualFold(r.Province, province) {
+			return r, true
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return ShippingRule{}, false
+}
+
+// Quote returns the shipping fee for a parcel of weight grams and the given
+// order subtotal. ok is false when the method does not serve province or
+// the parcel is too heavy.
+func (m ShippingMethod) Quote(province string, subtotal money.Money, weight int) (fee money.Money, ok bool) {
+	r, ok := m.RuleFor(province)
+	if !ok {
+		return 0, false
+	}
+	if r.MaxWeight > 0 && weight > r.MaxWeight {
+		return 0, false
+	}
+	if r.FreeOver > 0 && subtotal >= r.FreeOver {
+		return 0, true
+	}
+	fee = r.BaseFee
+	if extra := weight - r.IncludedWeight; extra > 0 && r.PerKgFee > 0 {
+		fee += r.PerKgFee.Mul((extra + 999) / 1000)
+	}
+	return fee, true
 }
 
 type Banner struct {
@@ -129,6 +733,8 @@ type Banner struct {
 	Link      string `json:"link"`
 	SortOrder int    `gorm:"default:0" json:"sort_order"`
 	IsActive  bool   `gorm:"default:true" json:"is_active"`
+
+	Translations []BannerTranslation `gorm:"foreignKey:BannerID" json:"translations,omitempty"`
 }
 
 // ImageURL returns the image URL of the banner.
@@ -149,27 +755,356 @@ type CompanyInfo struct {
 	Copyright   string `json:"copyright"`
 }
 
+// ExchangeRate converts VND prices for shoppers browsing in another
+// currency. Orders are always charged in VND.
+type ExchangeRate struct {
+	BaseModel
+	Currency string  `gorm:"size:3;uniqueIndex;not null" json:"currency"` // USD, EUR, see money.DisplayCodes
+	Rate     float64 `gorm:"not null;default:0" json:"rate"`              // đồng per unit of Currency
+	IsActive bool    `gorm:"default:false" json:"is_active"`
+}
+
+// Display returns the rate as a storefront display currency.
+func (r ExchangeRate) Display() money.Display {
+	return money.Display{Code: r.Currency, Rate: r.Rate}
+}
+
 type AboutPage struct {
 	BaseModel
 	Title   string `gorm:"not null" json:"title"`
 	Content string `gorm:"type:text" json:"content"`
 	Image   string `json:"image"`
+
+	Translations []AboutPageTranslation `gorm:"foreignKey:AboutPageID" json:"translations,omitempty"`
 }
 
+// SEOBanner overrides the meta tags of a storefront page. Page is one of
+// SEOPages, or SEOCategoryPage / SEOProductPage for a single category or
+// product.
 type SEOBanner struct {
 	BaseModel
-	Page        string `gorm:"not null;index" json:"page"` // home, products, about, contact
+	Page        string `gorm:"not null;index" json:"page"` // home, products, about, contact, category:<id>, product:<id>
 	Title       string `json:"title"`
 	Description string `gorm:"type:text" json:"description"`
 	Keywords    string `json:"keywords"`
 	OGImage     string `json:"og_image"`
+
+	Translations []SEOBannerTranslation `gorm:"foreignKey:SEOBannerID" json:"translations,omitempty"`
+}
+
+// RobotsConfig holds the crawler rules served at /robots.txt; the sitemap
+// line is added by the storefront. There is at most one row.
+type RobotsConfig struct {
+	BaseModel
+	Rules string `gorm:"type:text" json:"rules"`
 }
 
+// DefaultRobotsRules keep crawlers out of carts, accounts, payments and
+// search results, in every locale.
+const DefaultRobotsRules = `User-agent: *
+Disallow: /cart
+Disallow: /en/cart
+Disallow: /account/
+Disallow: /en/account/
+Disallow: /payments/
+Disallow: /en/payments/
+Disallow: /notify/
+Disallow: /en/notify/
+Disallow: /*?q=
+`
+
+// SEOPages are the storefront pages with their own SEOBanner entry.
+var SEOPages = []string{"home", "products", "about", "contact"}
+
+func SEOCategoryPage(categoryID string) string { return "category:" + categoryID }
+
+func SEOProductPage(productID string) string { return "product:" + productID }
+
 // Cart item stored in session for anonymous users, or DB for logged-in
 type CartItem struct {
-	ProductID string  `json:"product_id"`
-	Name      string  `json:"name"`
-	Image     string  `json:"image"`
-	Price     float64 `json:"price"`
-	Quantity  int     `json:"quantity"`
+	ProductID string      `json:"product_id"`
+	Name      string      `json:"name"`
+	Image     string      `json:"image"`
+	Price     money.Money `json:"price"`
+	Quantity  int         `json:"quantity"`
+}
+
+// Catalog content is written in the default locale in the models' own
+// columns; a translation row holds another locale's text. Localize overlays
+// the fields a translation fills in, so anything left empty falls back to
+// the default locale. Slugs are per locale so /en/products/:slug resolves
+// the English slug.
+
+type ProductTranslation struct {
+	BaseModel
+	ProductID   string `gorm:"uniqueIndex:idx_product_translation;not null" json:"product_id"`
+	Locale      string `gorm:"size:8;uniqueIndex:idx_product_translation;uniqueIndex:idx_product_translation_slug;not null" json:"locale"`
+	Name        string `json:"name"`
+	Slug        string `gorm:"uniqueIndex:idx_product_translation_slug;not null" json:"slug"`
+	Description string `gorm:"type:text" json:"description"`
+	Content     string `gorm:"type:text" json:"content"`
+}
+
+type CategoryTranslation struct {
+	BaseModel
+	CategoryID  string `gorm:"uniqueIndex:idx_category_translation;not null" json:"category_id"`
+	Locale      string `gorm:"size:8;uniqueIndex:idx_category_translation;uniqueIndex:idx_category_translation_slug;not null" json:"locale"`
+	Name        string `json:"name"`
+	Slug        string `gorm:"uniqueIndex:idx_category_translation_slug;not null" json:"slug"`
+	Description string `json:"description"`
+}
+
+// Attribute types. Select and multi attributes pick from the attribute's
+// Values and can filter the product list; text and number attributes are
+// typed in per product and only shown in its specs.
+const (
+	AttributeSelect = "select"
+	AttributeMulti  = "multi"
+	AttributeText   = "text"
+	AttributeNumber = "number"
+)
+
+var AttributeTypes = []string{AttributeSelect, AttributeMulti, AttributeText, AttributeNumber}
+
+// ElementAttribute is the code of the attribute holding a product's
+// feng-shui elements, with values slugged as the fengshui package's
+// element codes: kim, moc, thuy, hoa, tho.
+const ElementAttribute = "element"
+
+// Attribute classifies products beyond their category: element, zodiac,
+// stone, colour. It applies to the products of its Categories and their
+// subcategories, or to every product when it has none.
+type Attribute struct {
+	BaseModel
+	Name       string           `gorm:"not null" json:"name"`
+	Code       string           `gorm:"uniqueIndex;not null" json:"code"` // the filter's query parameter: ?element=kim
+	Type       string           `gorm:"size:16;not null;default:select" json:"type"`
+	Unit       string           `json:"unit"` // after number values: "mm"
+	Filterable bool             `gorm:"default:true" json:"filterable"`
+	SortOrder  int              `gorm:"default:0" json:"sort_order"`
+	Values     []AttributeValue `gorm:"foreignKey:AttributeID" json:"values,omitempty"`
+	Categories []Category       `gorm:"many2many:category_attributes" json:"categories,omitempty"`
+}
+
+// HasValues reports whether products pick from a's Values.
+func (a Attribute) HasValues() bool {
+	return a.Type == AttributeSelect || a.Type == AttributeMulti
+}
+
+// AppliesTo reports whether a is used in the category with the given
+// path, the category itself last.
+func (a Attribute) AppliesTo(path []string) bool {
+	if len(a.Categories) == 0 {
+		return true
+	}
+	for _, c := range a.Categories {
+		if slices.Contains(path, c.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+type AttributeValue struct {
+	BaseModel
+	AttributeID string `gorm:"uniqueIndex:idx_attribute_value_slug;not null" json:"attribute_id"`
+	Name        string `gorm:"not null" json:"name"`
+	Slug        string `gorm:"uniqueIndex:idx_attribute_value_slug;not null" json:"slug"` // in filter URLs
+	SortOrder   int    `gorm:"default:0" json:"sort_order"`
+}
+
+// ProductAttribute gives a product one value of an attribute: a row per
+// chosen value for select and multi attributes, or the typed Text.
+type ProductAttribute struct {
+	BaseModel
+	ProductID   string          `gorm:"index;not null" json:"product_id"`
+	AttributeID string          `gorm:"index;not null" json:"attribute_id"`
+	Attribute   Attribute       `gorm:"foreignKey:AttributeID" json:"attribute,omitempty"`
+	ValueID     *string         `gorm:"index" json:"value_id"`
+	Value       *AttributeValue `gorm:"foreignKey:ValueID" json:"value,omitempty"`
+	Text        string          `json:"text"`
+}
+
+// Tag is a free-form label on products, listed at /products?tag=<slug>.
+type Tag struct {
+	BaseModel
+	Name string `gorm:"not null" json:"name"`
+	Slug string `gorm:"uniqueIndex;not null" json:"slug"`
+}
+
+// Spec is a line of a product's specs table.
+type Spec struct {
+	Attribute Attribute
+	Values    []string
+}
+
+// ProductSpecs groups a product's attribute rows, with Attribute and Value
+// loaded, into spec lines in attribute order.
+func ProductSpecs(rows []ProductAttribute) []Spec {
+	sorted := slices.Clone(rows)
+	slices.SortStableFunc(sorted, func(a, b ProductAttribute) int {
+		if a.Attribute.SortOrder != b.Attribute.SortOrder {
+			return a.Attribute.SortOrder - b.Attribute.SortOrder
+		}
+		if a.Attribute.Name != b.Attribute.Name {
+			return strings.Compare(a.Attribute.Name, b.Attribute.Name)
+		}
+		if a.AttributeID != b.AttributeID {
+			return strings.Compare(a.AttributeID, b.AttributeID)
+		}
+		if a.Value != nil && b.Value != nil {
+			return a.Value.SortOrder - b.Value.SortOrder
+		}
+		return 0
+	})
+	var specs []Spec
+	for _, r := range sorted {
+		value := r.Text
+		if r.Value != nil {
+			value = r.Value.Name
+		}
+		if value == "" {
+			continue
+		}
+		if r.Attribute.Unit != "" && r.Attribute.Type == AttributeNumber {
+			value += " " + r.Attribute.Unit
+		}
+		if n := len(specs); n > 0 && specs[n-1].Attribute.ID == r.AttributeID {
+			specs[n-1].Values = append(specs[n-1].Values, value)
+			continue
+		}
+		specs = append(specs, Spec{Attribute: r.Attribute, Values: []string{value}})
+	}
+	return specs
+}
+
+// SlugRedirect remembers a slug a product or category no longer uses, so
+// old links answer with a 301 to its current URL. It points at the row
+// rather than the new slug, so renaming twice never chains redirects.
+type SlugRedirect struct {
+	BaseModel
+	Kind     string `gorm:"size:16;uniqueIndex:idx_slug_redirect;not null" json:"kind"` // SlugProduct, SlugCategory
+	Locale   string `gorm:"size:8;uniqueIndex:idx_slug_redirect;not null" json:"locale"`
+	Slug     string `gorm:"uniqueIndex:idx_slug_redirect;not null" json:"slug"` // the old slug
+	TargetID string `gorm:"index;not null" json:"target_id"`
+}
+
+const (
+	SlugProduct  = "product"
+	SlugCategory = "category"
+)
+
+type BannerTranslation struct {
+	BaseModel
+	BannerID string `gorm:"uniqueIndex:idx_banner_translation;not null" json:"banner_id"`
+	Locale   string `gorm:"size:8;uniqueIndex:idx_banner_translation;not null" json:"locale"`
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle"`
+}
+
+type AboutPageTranslation struct {
+	BaseModel
+	AboutPageID string `gorm:"uniqueIndex:idx_about_page_translation;not null" json:"about_page_id"`
+	Locale      string `gorm:"size:8;uniqueIndex:idx_about_page_translation;not null" json:"locale"`
+	Title       string `json:"title"`
+	Content     string `gorm:"type:text" json:"content"`
+}
+
+type SEOBannerTranslation struct {
+	BaseModel
+	SEOBannerID string `gorm:"uniqueIndex:idx_seo_banner_translation;not null" json:"seo_banner_id"`
+	Locale      string `gorm:"size:8;uniqueIndex:idx_seo_banner_translation;not null" json:"locale"`
+	Title       string `json:"title"`
+	Description string `gorm:"type:text" json:"description"`
+	Keywords    string `json:"keywords"`
+}
+
+// overlay sets *dst to src unless src is empty.
+func overlay(dst *string, src string) {
+	if src != "" {
+		*dst = src
+	}
+}
+
+// Translation returns the product's translation into locale, or an empty
+// one.
+func (p Product) Translation(locale string) ProductTranslation {
+	for _, t := range p.Translations {
+		if t.Locale == locale {
+			return t
+		}
+	}
+	return ProductTranslation{}
+}
+
+// Localize shows the product, and its category if loaded, in locale.
+func (p *Product) Localize(locale string) {
+	t := p.Translation(locale)
+	overlay(&p.Name, t.Name)
+	overlay(&p.Slug, t.Slug)
+	overlay(&p.Description, t.Description)
+	overlay(&p.Content, t.Content)
+	p.Category.Localize(locale)
+}
+
+func (c Category) Translation(locale string) CategoryTranslation {
+	for _, t := range c.Translations {
+		if t.Locale == locale {
+			return t
+		}
+	}
+	return CategoryTranslation{}
+}
+
+func (c *Category) Localize(locale string) {
+	t := c.Translation(locale)
+	overlay(&c.Name, t.Name)
+	overlay(&c.Slug, t.Slug)
+	overlay(&c.Description, t.Description)
+}
+
+func (b Banner) Translation(locale string) BannerTranslation {
+	for _, t := range b.Translations {
+		if t.Locale == locale {
+			return t
+		}
+	}
+	return BannerTranslation{}
+}
+
+func (b *Banner) Localize(locale string) {
+	t := b.Translation(locale)
+	overlay(&b.Title, t.Title)
+	overlay(&b.Subtitle, t.Subtitle)
+}
+
+func (a AboutPage) Translation(locale string) AboutPageTranslation {
+	for _, t := range a.Translations {
+		if t.Locale == locale {
+			return t
+		}
+	}
+	return AboutPageTranslation{}
+}
+
+func (a *AboutPage) Localize(locale string) {
+	t := a.Translation(locale)
+	overlay(&a.Title, t.Title)
+	overlay(&a.Content, t.Content)
+}
+
+func (s SEOBanner) Translation(locale string) SEOBannerTranslation {
+	for _, t := range s.Translations {
+		if t.Locale == locale {
+			return t
+		}
+	}
+	return SEOBannerTranslation{}
+}
+
+func (s *SEOBanner) Localize(locale string) {
+	t := s.Translation(locale)
+	overlay(&s.Title, t.Title)
+	overlay(&s.Description, t.Description)
+	overlay(&s.Keywords, t.Keywords)
 }
@@ -0,0 +1,256 @@
+// Package notifications emails customers about products they follow, from
+// their wishlist or a "notify me" stock subscription, when the price drops
+// or the product comes back in stock. Notifications are written to an
+// outbox (models.Notification) in the same transaction as the product
+// change and delivered in the background by Run, so a slow or failing mail
+// server never holds up the admin.
+package notifications
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/mail"
+	"shoop-golang/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// maxAttempts is how often delivery of a notification is tried before
+	// it is marked failed.
+	maxAttempts = 3
+	// maxPerHour caps the emails one address receives in an hour; the rest
+	// stay queued for a later run.
+	maxPerHour = 3
+)
+
+// Track runs change, which updates the products with the given IDs in tx,
+// and queues notifications for those whose price dropped or that came back
+// in stock.
+func Track(tx *gorm.DB, ids []string, change func() error) error {
+	before, err := loadProducts(tx, ids)
+	if err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	after, err := loadProducts(tx, ids)
+	if err != nil {
+		return err
+	}
+	for id, p := range after {
+		old, ok := before[id]
+		if !ok {
+			continue
+		}
+		for _, kind := range Changes(old, p) {
+			if err := queue(tx, p, kind); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Changes lists the notification kinds a change from before to after
+// causes. Hidden products notify nobody.
+func Changes(before, after models.Product) []string {
+	if !after.IsActive {
+		return nil
+	}
+	var kinds []string
+	if before.Stock <= 0 && after.Stock > 0 {
+		kinds = append(kinds, models.NotifyRestock)
+	}
+	if price := after.Price(); price > 0 && price < before.Price() {
+		kinds = append(kinds, models.NotifyPriceDrop)
+	}
+	return kinds
+}
+
+func loadProducts(tx *gorm.DB, ids []string) (map[string]models.Product, error) {
+	var products []models.Product
+	if err := tx.Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string]models.Product, len(products))
+	for _, p := range products {
+		out[p.ID] = p
+	}
+	return out, nil
+}
+
+// queue adds a notification of kind for every customer who wishlisted the
+// product and, for restocks, every confirmed open stock subscription,
+// unless the address already has one waiting to be sent.
+func queue(tx *gorm.DB, product models.Product, kind string) error {
+	var followers []models.Notification
+	err := tx.Model(&models.Wishlist{}).
+		Select("wishlists.user_id, users.email, wishlists.locale").
+		Joins("JOIN users ON users.id = wishlists.user_id AND users.deleted_at IS NULL").
+		Where("wishlists.product_id = ?", product.ID).
+		Scan(&followers).Error
+	if err != nil {
+		return err
+	}
+	if kind == models.NotifyRestock {
+		var subs []models.StockSubscription
+		open := "product_id = ? AND confirmed_at IS NOT NULL AND notified_at IS NULL"
+		if err := tx.Where(open, product.ID).Find(&subs).Error; err != nil {
+			return err
+		}
+		for _, sub := range subs {
+			followers = append(followers, models.Notification{UserID: sub.UserID, Email: sub.Email, Locale: sub.Locale, UnsubscribeToken: sub.Token})
+		}
+		// Subscriptions are one-off; the customer can sign up again if the
+		// product sells out before they buy.
+		if len(subs) > 0 {
+			if err := tx.Model(&models.StockSubscription{}).Where(open, product.ID).Update("notified_at", time.Now()).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, n := range followers {
+		var pending int64
+		tx.Model(&models.Notification{}).
+			Where("email = ? AND product_id = ? AND kind = ? AND status = ?", n.Email, product.ID, kind, models.NotificationQueued).
+			Count(&pending)
+		if pending > 0 {
+			continue
+		}
+		n.Kind = kind
+		n.ProductID = product.ID
+		n.Status = models.NotificationQueued
+		if err := tx.Create(&n).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Deliver sends up to limit queued notifications, oldest first, and
+// returns how many were sent. Addresses that already got maxPerHour emails
+// in the last hour are skipped until a later run.
+func Deliver(db *gorm.DB, baseURL string, limit int) (int, error) {
+	since := time.Now().Add(-time.Hour)
+	var recent []struct {
+		Email string
+		Count int
+	}
+	err := db.Model(&models.Notification{}).Select("email, COUNT(*) AS count").
+		Where("status = ? AND sent_at > ?", models.NotificationSent, since).
+		Group("email").Scan(&recent).Error
+	if err != nil {
+		return 0, err
+	}
+	sentTo := make(map[string]int, len(recent))
+	busy := []string{}
+	for _, r := range recent {
+		sentTo[r.Email] = r.Count
+		if r.Count >= maxPerHour {
+			busy = append(busy, r.Email)
+		}
+	}
+
+	var queued []models.Notification
+	query := db.Preload("Product").Preload("Product.Translations").
+		Where("status = ?", models.NotificationQueued)
+	if len(busy) > 0 {
+		query = query.Where("email NOT IN ?", busy)
+	}
+	if err := query.Order("created_at ASC").Limit(limit).Find(&queued).Error; err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, n := range queued {
+		if sentTo[n.Email] >= maxPerHour {
+			continue
+		}
+		updates := map[string]any{"attempts": n.Attempts + 1}
+		if n.Product.ID == "" || !n.Product.IsActive {
+			updates["status"] = models.NotificationFailed
+			updates["error"] = "product unavailable"
+		} else if err := mail.Send(Compose(n, baseURL)); err != nil {
+			updates["error"] = err.Error()
+			if n.Attempts+1 >= maxAttempts {
+				updates["status"] = models.NotificationFailed
+			}
+		} else {
+			updates["status"] = models.NotificationSent
+			updates["sent_at"] = time.Now()
+			updates["error"] = ""
+			sentTo[n.Email]++
+			sent++
+		}
+		if err := db.Model(&n).Updates(updates).Error; err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// Compose writes the email for n in the customer's language. n.Product
+// must be loaded with its translations.
+func Compose(n models.Notification, baseURL string) mail.Message {
+	locale := n.Locale
+	if !i18n.Supported(locale) {
+		locale = i18n.Default
+	}
+	product := n.Product
+	product.Localize(locale)
+
+	prefix := ""
+	if locale != i18n.Default {
+		prefix = "/" + locale
+	}
+	link := baseURL + prefix + "/products/" + product.Slug
+
+	if n.Kind == models.NotifyConfirm {
+		return mail.Message{
+			To:      n.Email,
+			Subject: i18n.Translate(locale, "notify.confirm.subject", product.Name),
+			Body:    i18n.Translate(locale, "notify.confirm.body", product.Name, baseURL+prefix+"/notify/confirm/"+n.UnsubscribeToken),
+		}
+	}
+
+	var subject, body string
+	switch n.Kind {
+	case models.NotifyPriceDrop:
+		subject = i18n.Translate(locale, "notify.price_drop.subject", product.Name)
+		body = i18n.Translate(locale, "notify.price_drop.body", product.Name, utils.ListPrice(locale, product.Price()), link)
+	default:
+		subject = i18n.Translate(locale, "notify.restock.subject", product.Name)
+		body = i18n.Translate(locale, "notify.restock.body", product.Name, link)
+	}
+	if n.UnsubscribeToken != "" {
+		body += "\n\n" + i18n.Translate(locale, "notify.footer.subscription", baseURL+prefix+"/notify/unsubscribe/"+n.UnsubscribeToken)
+	} else {
+		body += "\n\n" + i18n.Translate(locale, "notify.footer.wishlist", baseURL+prefix+"/account/wishlist")
+	}
+	return mail.Message{To: n.Email, Subject: subject, Body: body}
+}
+
+// Run delivers queued notifications every interval until ctx is done.
+func Run(ctx context.Context, db *gorm.DB, baseURL string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := Deliver(db, baseURL, 50); err != nil {
+			log.Printf("notifications: %v", err)
+		} else if n > 0 {
+			log.Printf("notifications: sent %d", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Package recommendations suggests products to show next to others, from
+// what customers bought together. Rebuild turns the order history into
+// models.ProductAffinity rows; Run repeats it in the background, so pages
+// only read a small precomputed table. Products without enough history
+// fall back to others sharing their category or tags.
+package recommendations
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"shoop-golang/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MinBundleOrders is how many orders must hold two products before
+	// they count as frequently bought together.
+	MinBundleOrders = 2
+	// keepPerProduct caps the affinities stored for one product.
+	keepPerProduct = 20
+)
+
+// Rebuild recomputes every product affinity from the orders that were not
+// cancelled, and returns how many rows it wrote.
+func Rebuild(db *gorm.DB) (int, error) {
+	var lines []struct {
+		OrderID   string
+		ProductID string
+	}
+	err := db.Table("order_items").
+		Select("DISTINCT order_items.order_id, order_items.product_id").
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Where("orders.status <> ? AND order_items.quantity > 0 AND order_items.deleted_at IS NULL", "cancelled").
+		Order("order_items.order_id").
+		Scan(&lines).Error
+	if err != nil {
+		return 0, err
+	}
+
+	type pair struct{ a, b string }
+	bought := map[string]int{}
+	together := map[pair]int{}
+	count := func(products []string) {
+		for _, a := range products {
+			bought[a]++
+			for _, b := range products {
+				if a != b {
+					together[pair{a, b}]++
+				}
+			}
+		}
+	}
+	var order string
+	var products []string
+	for _, l := range lines {
+		if l.OrderID != order {
+			count(products)
+			order, products = l.OrderID, products[:0]
+		}
+		products = append(products, l.ProductID)
+	}
+	count(products)
+
+	byProduct := map[string][]models.ProductAffinity{}
+	for p, n := range together {
+		byProduct[p.a] = append(byProduct[p.a], models.ProductAffinity{
+			ProductID: p.a, RelatedID: p.b, Orders: n, Score: float64(n) / float64(bought[p.a]),
+		})
+	}
+	var rows []models.ProductAffinity
+	for _, related := range byProduct {
+		sort.Slice(related, func(i, j int) bool {
+			if related[i].Orders != related[j].Orders {
+				return related[i].Orders > related[j].Orders
+			}
+			return related[i].RelatedID < related[j].RelatedID
+		})
+		if len(related) > keepPerProduct {
+			related = related[:keepPerProduct]
+		}
+		rows = append(rows, related...)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(&models.ProductAffinity{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&rows, 500).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+// Run rebuilds the affinities every interval until ctx is done.
+func Run(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := Rebuild(db); err != nil {
+			log.Printf("recommendations: %v", err)
+		} else {
+			log.Printf("recommendations: %d affinities", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// BoughtTogether returns the IDs of active products bought with any of
+// ids in at least MinBundleOrders orders, most often first.
+func BoughtTogether(db *gorm.DB, ids []string, limit int) []string {
+	return affinities(db, ids, nil, "SUM(product_affinities.orders)", MinBundleOrders, limit)
+}
+
+// AlsoBought returns the IDs of active products bought by customers who
+// bought any of ids, best first, leaving out exclude.
+func AlsoBought(db *gorm.DB, ids, exclude []string, limit int) []string {
+	return affinities(db, ids, exclude, "SUM(product_affinities.score)", 1, limit)
+}
+
+func affinities(db *gorm.DB, ids, exclude []string, rank string, minOrders, limit int) []string {
+	if len(ids) == 0 {
+		return nil
+	}
+	var related []string
+	db.Model(&models.ProductAffinity{}).
+		Select("product_affinities.related_id").
+		Joins("JOIN products ON products.id = product_affinities.related_id AND products.is_active = ? AND products.deleted_at IS NULL", true).
+		Where("product_affinities.product_id IN ? AND product_affinities.related_id NOT IN ?", ids, concat(exclude, ids)).
+		Group("product_affinities.related_id").
+		Having("SUM(product_affinities.orders) >= ?", minOrders).
+		Order(rank+" DESC, product_affinities.related_id").
+		Limit(limit).
+		Pluck("product_affinities.related_id", &related)
+	return related
+}
+
+// Similar returns the IDs of active products sharing tags or a category
+// with any of ids, those sharing the most first, leaving out exclude.
+func Similar(db *gorm.DB, ids, exclude []string, limit int) []string {
+	if len(ids) == 0 {
+		return nil
+	}
+	var categories, tags []string
+	db.Model(&models.Product{}).Where("id IN ?", ids).Distinct().Pluck("category_id", &categories)
+	db.Table("product_tags").Where("product_id IN ?", ids).Distinct().Pluck("tag_id", &tags)
+
+	shared := db.Table("product_tags").Select("COUNT(*)").
+		Where("product_tags.product_id = products.id AND product_tags.tag_id IN ?", tags)
+	tagged := db.Table("product_tags").Select("product_id").Where("tag_id IN ?", tags)
+
+	var similar []string
+	db.Model(&models.Product{}).
+		Where("is_active = ? AND id NOT IN ?", true, concat(exclude, ids)).
+		Where(db.Where("category_id IN ?", categories).Or("id IN (?)", tagged)).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "(?) DESC, CASE WHEN category_id IN (?) THEN 1 ELSE 0 END DESC, is_featured DESC, created_at DESC",
+			Vars:               []any{shared, categories},
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Pluck("id", &similar)
+	return similar
+}
+
+// Related returns up to limit products to suggest with ids: those
+// customers also bought, then similar ones. fromOrders reports whether
+// any came from the order history.
+func Related(db *gorm.DB, ids, exclude []string, limit int) (related []string, fromOrders bool) {
+	related = AlsoBought(db, ids, exclude, limit)
+	fromOrders = len(related) > 0
+	if len(related) < limit {
+		related = append(related, Similar(db, ids, concat(exclude, related), limit-len(related))...)
+	}
+	return related, fromOrders
+}
+
+// concat joins a and b into a new slice, leaving the caller's alone.
+func concat(a, b []string) []string {
+	return append(append([]string{}, a...), b...)
+}
@@ -0,0 +1,166 @@
+// Package fengshui works out a person's element (mệnh) from their birth
+// year by the Can-Chi sixty-year cycle, and which elements suit it.
+//
+// Years are lunar years: someone born before Tết belongs to the year
+// before. The element is the year's nạp âm; with a gender the package
+// also gives the Bát trạch trigram (cung mệnh) and its element.
+package fengshui
+
+// The five elements, coded as the slugs of the storefront's element
+// attribute values.
+const (
+	Kim  = "kim"  // metal
+	Moc  = "moc"  // wood
+	Thuy = "thuy" // water
+	Hoa  = "hoa"  // fire
+	Tho  = "tho"  // earth
+)
+
+var Elements = []string{Kim, Moc, Thuy, Hoa, Tho}
+
+const (
+	Male   = "male"
+	Female = "female"
+)
+
+// Years outside this range are refused as typos.
+const (
+	MinYear = 1900
+	MaxYear = 2100
+)
+
+var stems = []string{"Giáp", "Ất", "Bính", "Đinh", "Mậu", "Kỷ", "Canh", "Tân", "Nhâm", "Quý"}
+
+var branches = []string{"Tý", "Sửu", "Dần", "Mão", "Thìn", "Tỵ", "Ngọ", "Mùi", "Thân", "Dậu", "Tuất", "Hợi"}
+
+// napAm lists the thirty nạp âm of the cycle from Giáp Tý; each covers
+// two consecutive years.
+var napAm = []struct {
+	Name    string
+	Element string
+}{
+	{"Hải Trung Kim", Kim}, {"Lư Trung Hỏa", Hoa}, {"Đại Lâm Mộc", Moc},
+	{"Lộ Bàng Thổ", Tho}, {"Kiếm Phong Kim", Kim}, {"Sơn Đầu Hỏa", Hoa},
+	{"Giản Hạ Thủy", Thuy}, {"Thành Đầu Thổ", Tho}, {"Bạch Lạp Kim", Kim},
+	{"Dương Liễu Mộc", Moc}, {"Tuyền Trung Thủy", Thuy}, {"Ốc Thượng Thổ", Tho},
+	{"Tích Lịch Hỏa", Hoa}, {"Tùng Bách Mộc", Moc}, {"Trường Lưu Thủy", Thuy},
+	{"Sa Trung Kim", Kim}, {"Sơn Hạ Hỏa", Hoa}, {"Bình Địa Mộc", Moc},
+	{"Bích Thượng Thổ", Tho}, {"Kim Bạch Kim", Kim}, {"Phúc Đăng Hỏa", Hoa},
+	{"Thiên Hà Thủy", Thuy}, {"Đại Trạch Thổ", Tho}, {"Thoa Xuyến Kim", Kim},
+	{"Tang Đố Mộc", Moc}, {"Đại Khê Thủy", Thuy}, {"Sa Trung Thổ", Tho},
+	{"Thiên Thượng Hỏa", Hoa}, {"Thạch Lựu Mộc", Moc}, {"Đại Hải Thủy", Thuy},
+}
+
+// trigrams are the Bát trạch trigrams by Lo Shu number; 5 has none.
+var trigrams = map[int]struct {
+	Name    string
+	Element string
+}{
+	1: {"Khảm", Thuy}, 2: {"Khôn", Tho}, 3: {"Chấn", Moc}, 4: {"Tốn", Moc},
+	6: {"Càn", Kim}, 7: {"Đoài", Kim}, 8: {"Cấn", Tho}, 9: {"Ly", Hoa},
+}
+
+// generates maps each element to the one it feeds (tương sinh).
+var generates = map[string]string{Kim: Thuy, Thuy: Moc, Moc: Hoa, Hoa: Tho, Tho: Kim}
+
+// overcomes maps each element to the one it restrains (tương khắc).
+var overcomes = map[string]string{Kim: Moc, Moc: Tho, Tho: Thuy, Thuy: Hoa, Hoa: Kim}
+
+// Profile is what a birth year and gender say.
+type Profile struct {
+	Year    int
+	Gender  string // Male, Female or empty
+	CanChi  string // "Canh Ngọ"
+	NapAm   string // "Lộ Bàng Thổ"
+	Element string
+	// Trigram and TrigramElement are the cung mệnh, set with a gender.
+	Trigram        string
+	TrigramElement string
+}
+
+// Lookup returns the profile of a lunar birth year. Any gender other than
+// Male or Female is dropped; ok is false for years out of range.
+func Lookup(year int, gender string) (p Profile, ok bool) {
+	if year < MinYear || year > MaxYear {
+		return Profile{}, false
+	}
+	// 1984 was Giáp Tý, the start of a cycle.
+	n := mod(year-1984, 60)
+	p = Profile{
+		Year:    year,
+		CanChi:  stems[n%10] + " " + branches[n%12],
+		NapAm:   napAm[n/2].Name,
+		Element: napAm[n/2].Element,
+	}
+	if gender == Male || gender == Female {
+		p.Gender = gender
+		t := trigrams[trigramNumber(year, gender)]
+		p.Trigram, p.TrigramElement = t.Name, t.Element
+	}
+	return p, true
+}
+
+// trigramNumber is the Lo Shu number of the cung mệnh, with 5 taken as
+// Khôn for men and Cấn for women.
+func trigramNumber(year int, gender string) int {
+	s := digitRoot(year)
+	var k int
+	if gender == Male {
+		k = digitRoot(11 - s)
+		if k == 5 {
+			k = 2
+		}
+	} else {
+		k = digitRoot(4 + s)
+		if k == 5 {
+			k = 8
+		}
+	}
+	return k
+}
+
+// Compatible lists the elements that suit e, best first: the one that
+// feeds it, then e itself.
+func Compatible(e string) []string {
+	for from, to := range generates {
+		if to == e {
+			return []string{from, e}
+		}
+	}
+	return nil
+}
+
+// Conflicting returns the element that restrains e.
+func Conflicting(e string) string {
+	for from, to := range overcomes {
+		if to == e {
+			return from
+		}
+	}
+	return ""
+}
+
+// IsElement reports whether e is one of Elements.
+func IsElement(e string) bool {
+	_, ok := generates[e]
+	return ok
+}
+
+func digitRoot(n int) int {
+	for n > 9 || n < 1 {
+		if n < 1 {
+			n += 9
+			continue
+		}
+		s := 0
+		for ; n > 0; n /= 10 {
+			s += n % 10
+		}
+		n = s
+	}
+	return n
+}
+
+func mod(a, b int) int {
+	return (a%b + b) % b
+}
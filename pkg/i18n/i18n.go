@@ -0,0 +1,158 @@
+// Package i18n translates storefront and admin messages. Catalogs are flat
+// JSON files, one per locale (locales/vi.json, locales/en.json), mapping a
+// message key such as "cart.title" to its text. Texts may contain fmt verbs
+// filled from the arguments passed to T.
+//
+// The catalogs in this package are built in; Load replaces them with files
+// from a directory so translations can be updated without a rebuild.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/language"
+)
+
+// Default is the locale used when nothing else matches, and the catalog
+// other locales fall back to for missing keys.
+const Default = "vi"
+
+// Locales lists the supported locales, Default first.
+var Locales = []string{Default, "en"}
+
+// Translated returns the locales other than Default, the ones catalog
+// content is translated into.
+func Translated() []string {
+	return Locales[1:]
+}
+
+// ContextKey is where middleware stores the request's locale.
+const ContextKey = "locale"
+
+// CookieName remembers a locale chosen by the visitor.
+const CookieName = "lang"
+
+//go:embed locales/*.json
+var builtin embed.FS
+
+func init() {
+	sub, _ := fs.Sub(builtin, "locales")
+	if err := LoadFS(sub); err != nil {
+		panic(err)
+	}
+}
+
+var (
+	mu       sync.RWMutex
+	catalogs = map[string]map[string]string{}
+	matcher  = language.NewMatcher([]language.Tag{language.Vietnamese, language.English})
+)
+
+// Load reads <locale>.json for every supported locale from dir, replacing
+// the catalogs loaded before.
+func Load(dir string) error {
+	return LoadFS(os.DirFS(dir))
+}
+
+// LoadFS is Load reading from fsys.
+func LoadFS(fsys fs.FS) error {
+	loaded := make(map[string]map[string]string, len(Locales))
+	for _, locale := range Locales {
+		b, err := fs.ReadFile(fsys, locale+".json")
+		if err != nil {
+			return fmt.Errorf("i18n: %w", err)
+		}
+		messages := map[string]string{}
+		if err := json.Unmarshal(b, &messages); err != nil {
+			return fmt.Errorf("i18n: %s.json: %w", locale, err)
+		}
+		loaded[locale] = messages
+	}
+	mu.Lock()
+	catalogs = loaded
+	mu.Unlock()
+	return nil
+}
+
+// Supported reports whether locale is one of Locales.
+func Supported(locale string) bool {
+	for _, l := range Locales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the text of key in locale, falling back to Default. ok is
+// false when neither catalog has the key.
+func Lookup(locale, key string) (msg string, ok bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if msg, ok = catalogs[locale][key]; !ok {
+		msg, ok = catalogs[Default][key]
+	}
+	return msg, ok
+}
+
+// Translate returns the text of key in locale, falling back to Default and
+// then to the key itself, so messages that are not keys pass through.
+func Translate(locale, key string, args ...any) string {
+	msg, ok := Lookup(locale, key)
+	if !ok {
+		msg = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// T translates key into the locale of the request.
+func T(c echo.Context, key string, args ...any) string {
+	return Translate(Locale(c), key, args...)
+}
+
+// Locale returns the locale middleware picked for the request.
+func Locale(c echo.Context) string {
+	if c != nil {
+		if l, ok := c.Get(ContextKey).(string); ok && l != "" {
+			return l
+		}
+	}
+	return Default
+}
+
+// Negotiate picks a supported locale from an Accept-Language header.
+func Negotiate(acceptLanguage string) string {
+	if strings.TrimSpace(acceptLanguage) == "" {
+		return Default
+	}
+	tag, _ := language.MatchStrings(matcher, acceptLanguage)
+	base, _ := tag.Base()
+	if Supported(base.String()) {
+		return base.String()
+	}
+	return Default
+}
+
+// SetCookie remembers locale for the visitor.
+func SetCookie(c echo.Context, locale string) {
+	c.SetCookie(&http.Cookie{
+		Name:     CookieName,
+		Value:    locale,
+		Path:     "/",
+		Expires:  time.Now().AddDate(1, 0, 0),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
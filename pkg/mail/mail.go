@@ -0,0 +1,110 @@
+// Package mail sends the store's plain-text emails to customers. Messages
+// go through the Sender set with SetSender: SMTP in production, Log when no
+// server is configured, and Recorder in tests.
+package mail
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Sender interface {
+	Send(m Message) error
+}
+
+var (
+	mu     sync.RWMutex
+	sender Sender = Log{}
+)
+
+// SetSender replaces the sender used by Send.
+func SetSender(s Sender) {
+	mu.Lock()
+	defer mu.Unlock()
+	sender = s
+}
+
+// Send delivers m through the current sender.
+func Send(m Message) error {
+	mu.RLock()
+	s := sender
+	mu.RUnlock()
+	return s.Send(m)
+}
+
+// SMTP sends through a mail server, authenticating when Username is set.
+type SMTP struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s SMTP) Send(m Message) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	return smtp.SendMail(s.Host+":"+s.Port, auth, s.From, []string{m.To}, s.format(m))
+}
+
+func (s SMTP) format(m Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.From)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	// Vietnamese subjects need RFC 2047 encoding; ASCII passes through.
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// Log writes messages to the standard logger instead of sending them, for
+// development without a mail server.
+type Log struct{}
+
+func (Log) Send(m Message) error {
+	log.Printf("mail to %s: %s\n%s", m.To, m.Subject, m.Body)
+	return nil
+}
+
+// Recorder keeps sent messages in memory for tests. Err, when set, is
+// returned instead of recording.
+type Recorder struct {
+	Err error
+
+	mu   sync.Mutex
+	sent []Message
+}
+
+func (r *Recorder) Send(m Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	r.sent = append(r.sent, m)
+	return nil
+}
+
+// Sent returns the messages recorded so far.
+func (r *Recorder) Sent() []Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Message(nil), r.sent...)
+}
@@ -0,0 +1,48 @@
+package money
+
+// Display is a currency the storefront shows prices in. Rate is the number of
+// đồng one unit of Code is worth; orders are still charged and stored in VND.
+type Display struct {
+	Code string
+	Rate float64
+}
+
+// VND shows amounts as they are stored.
+var VND = Display{Code: Currency, Rate: 1}
+
+// DisplayCodes lists the currencies shoppers can switch to, VND first.
+var DisplayCodes = []string{Currency, "USD", "EUR"}
+
+// IsDisplayCode reports whether code is one of DisplayCodes.
+func IsDisplayCode(code string) bool {
+	for _, c := range DisplayCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBase reports whether d shows amounts unconverted.
+func (d Display) IsBase() bool {
+	return d.Code == "" || d.Code == Currency || d.Rate <= 0
+}
+
+// Symbol returns the sign printed with converted amounts.
+func (d Display) Symbol() string {
+	switch d.Code {
+	case "USD":
+		return "$"
+	case "EUR":
+		return "€"
+	}
+	return "₫"
+}
+
+// Convert returns m in units of d.
+func (d Display) Convert(m Money) float64 {
+	if d.IsBase() {
+		return float64(m)
+	}
+	return float64(m) / d.Rate
+}
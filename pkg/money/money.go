@@ -0,0 +1,108 @@
+// Package money represents prices and order amounts as whole đồng. The
+// Vietnamese đồng has no minor unit in use, so an amount is stored and summed
+// as an integer, which keeps totals exact where float64 would drift.
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Currency is the ISO 4217 code of every Money amount. Orders record it so
+// amounts stay unambiguous if other currencies are added later.
+const Currency = "VND"
+
+// Money is an amount in whole đồng.
+type Money int64
+
+// FromFloat rounds f to the nearest đồng.
+func FromFloat(f float64) Money {
+	return Money(math.Round(f))
+}
+
+// Parse reads an amount typed by staff, e.g. "1990000", "1.990.000",
+// "1,990,000" or "1.990.000₫". Dots and commas are read as thousands
+// separators when they group digits by three, otherwise as a decimal point
+// and the amount is rounded. An empty string is zero.
+func Parse(s string) (Money, error) {
+	s = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(s), "₫đ"))
+	if s == "" {
+		return 0, nil
+	}
+	if thousands.MatchString(s) {
+		s = strings.NewReplacer(".", "", ",", "").Replace(s)
+	}
+	var m Money
+	if err := m.parseNumber(s); err != nil {
+		return 0, err
+	}
+	return m, nil
+}
+
+var thousands = regexp.MustCompile(`^-?\d{1,3}([.,]\d{3})+$`)
+
+// Mul returns the amount of n units at price m.
+func (m Money) Mul(n int) Money {
+	return m * Money(n)
+}
+
+// Float returns m as a float64 for ratios and external APIs.
+func (m Money) Float() float64 {
+	return float64(m)
+}
+
+// Value stores m as an integer column.
+func (m Money) Value() (driver.Value, error) {
+	return int64(m), nil
+}
+
+// Scan reads an integer column, rounding values still stored as REAL by
+// databases created before amounts were integers.
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case int64:
+		*m = Money(v)
+	case float64:
+		*m = FromFloat(v)
+	case []byte:
+		return m.parseNumber(string(v))
+	case string:
+		return m.parseNumber(v)
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+	return nil
+}
+
+// UnmarshalJSON accepts integer and fractional numbers, so carts saved in
+// sessions and provider notifications sending 250000.0 still decode.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var f json.Number
+	if err := json.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("money: %w", err)
+	}
+	return m.parseNumber(f.String())
+}
+
+func (m *Money) parseNumber(s string) error {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		*m = Money(n)
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("money: invalid amount %q", s)
+	}
+	*m = FromFloat(f)
+	return nil
+}
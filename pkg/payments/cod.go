@@ -0,0 +1,33 @@
+package payments
+
+import (
+	"net/http"
+
+	"shoop-golang/pkg/money"
+)
+
+// COD is cash on delivery: nothing happens online, the courier collects the
+// amount and the order is settled when it is delivered.
+type COD struct{}
+
+func (COD) Code() string        { return "cod" }
+func (COD) Name() string        { return "Thanh toán khi nhận hàng (COD)" }
+func (COD) Description() string { return "Trả tiền mặt cho nhân viên giao hàng" }
+
+func (c COD) Initiate(req Request) (Instruction, error) {
+	return Instruction{
+		Provider:  c.Code(),
+		Status:    StatusPending,
+		Reference: req.OrderNumber,
+		Amount:    req.Amount,
+	}, nil
+}
+
+func (COD) VerifySignature([]byte, http.Header) error { return ErrNotSupported }
+
+func (COD) HandleCallback([]byte) (Event, error) { return Event{}, ErrNotSupported }
+
+// Refund of cash is paid back by staff.
+func (COD) Refund(string, money.Money) (RefundResult, error) {
+	return RefundResult{Status: StatusSucceeded, Manual: true}, nil
+}
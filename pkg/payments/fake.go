@@ -0,0 +1,86 @@
+package payments
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"shoop-golang/pkg/money"
+)
+
+// Fake is an in-memory provider for tests and local development. Payments
+// start pending and settle when a webhook arrives; webhooks are JSON
+//
+//	{"transaction_id": "...", "reference": "OCC-...", "amount": 1, "status": "succeeded"}
+//
+// signed with Secret.
+type Fake struct {
+	Secret string
+	// RefundErr, when set, fails every refund.
+	RefundErr error
+
+	mu      sync.Mutex
+	refunds []RefundResult
+}
+
+func NewFake(secret string) *Fake {
+	return &Fake{Secret: secret}
+}
+
+func (f *Fake) Code() string        { return "fake" }
+func (f *Fake) Name() string        { return "Cổng thanh toán thử nghiệm" }
+func (f *Fake) Description() string { return "Chỉ dùng khi phát triển" }
+
+func (f *Fake) Initiate(req Request) (Instruction, error) {
+	return Instruction{
+		Provider:  f.Code(),
+		Status:    StatusPending,
+		Reference: req.OrderNumber,
+		Amount:    req.Amount,
+	}, nil
+}
+
+func (f *Fake) VerifySignature(body []byte, header http.Header) error {
+	return verifyHMAC(f.Secret, body, header)
+}
+
+func (f *Fake) HandleCallback(body []byte) (Event, error) {
+	var e struct {
+		TransactionID string      `json:"transaction_id"`
+		Reference     string      `json:"reference"`
+		Amount        money.Money `json:"amount"`
+		Status        string      `json:"status"`
+	}
+	if err := json.Unmarshal(body, &e); err != nil {
+		return Event{}, fmt.Errorf("payments: invalid fake notification: %w", err)
+	}
+	if e.TransactionID == "" {
+		return Event{}, fmt.Errorf("payments: fake notification without transaction_id")
+	}
+	if e.Status == "" {
+		e.Status = StatusSucceeded
+	}
+	return Event{TransactionID: e.TransactionID, Reference: e.Reference, Amount: e.Amount, Status: e.Status}, nil
+}
+
+func (f *Fake) Refund(transactionID string, amount money.Money) (RefundResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.RefundErr != nil {
+		return RefundResult{}, f.RefundErr
+	}
+	r := RefundResult{
+		TransactionID: fmt.Sprintf("fake-refund-%d", len(f.refunds)+1),
+		Status:        StatusSucceeded,
+	}
+	f.refunds = append(f.refunds, r)
+	return r, nil
+}
+
+// Refunds lists the refunds made so far.
+func (f *Fake) Refunds() []RefundResult {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]RefundResult(nil), f.refunds...)
+}
@@ -0,0 +1,162 @@
+// Package payments defines the interface between the store and payment
+// providers, along with the providers the store ships with: cash on
+// delivery, VietQR bank transfer and a fake provider for tests.
+//
+// Providers are stateless as far as the store is concerned: they describe
+// how a customer pays and translate provider notifications into events. The
+// caller records transactions and updates orders.
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+
+	"shoop-golang/pkg/money"
+)
+
+// Transaction statuses reported by providers.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of a webhook body.
+const SignatureHeader = "X-Signature"
+
+var (
+	ErrInvalidSignature = errors.New("payments: invalid signature")
+	ErrNotSupported     = errors.New("payments: not supported by provider")
+)
+
+// Request describes the charge for one order.
+type Request struct {
+	OrderID     string
+	OrderNumber string
+	Amount      money.Money
+}
+
+// Instruction tells the customer how to complete a payment. Only the fields
+// relevant to the provider are set.
+type Instruction struct {
+	Provider      string      `json:"provider"`
+	Status        string      `json:"status"`
+	Reference     string      `json:"reference"`
+	Amount        money.Money `json:"amount"`
+	RedirectURL   string      `json:"redirect_url,omitempty"`
+	QRPayload     string      `json:"qr_payload,omitempty"`
+	BankName      string      `json:"bank_name,omitempty"`
+	AccountNumber string      `json:"account_number,omitempty"`
+	AccountName   string      `json:"account_name,omitempty"`
+	Memo          string      `json:"memo,omitempty"`
+}
+
+// Event is a provider notification about a transaction. Reference is the
+// order number the transaction belongs to; TransactionID is the provider's
+// own id and is what makes repeated deliveries recognisable.
+type Event struct {
+	TransactionID string
+	Reference     string
+	Amount        money.Money
+	Status        string
+}
+
+// RefundResult reports a refund. Manual is set when the provider cannot move
+// money itself and staff are expected to pay the customer back.
+type RefundResult struct {
+	TransactionID string
+	Status        string
+	Manual        bool
+}
+
+type Provider interface {
+	Code() string
+	Name() string
+	Description() string
+	// Initiate starts paying for an order.
+	Initiate(req Request) (Instruction, error)
+	// VerifySignature checks that a webhook body was sent by the provider.
+	VerifySignature(body []byte, header http.Header) error
+	// HandleCallback parses a verified webhook body.
+	HandleCallback(body []byte) (Event, error)
+	// Refund returns amount of a settled transaction to the customer.
+	Refund(transactionID string, amount money.Money) (RefundResult, error)
+}
+
+var (
+	mu        sync.RWMutex
+	providers []Provider
+)
+
+// Register makes p available at checkout, replacing any provider with the
+// same code. Providers are offered in registration order.
+func Register(p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	for i, existing := range providers {
+		if existing.Code() == p.Code() {
+			providers[i] = p
+			return
+		}
+	}
+	providers = append(providers, p)
+}
+
+// Reset removes all registered providers.
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	providers = nil
+}
+
+func Get(code string) (Provider, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, p := range providers {
+		if p.Code() == code {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+func All() []Provider {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]Provider(nil), providers...)
+}
+
+// Sign returns the signature a provider sends in SignatureHeader for body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func verifyHMAC(secret string, body []byte, header http.Header) error {
+	if secret == "" {
+		return ErrInvalidSignature
+	}
+	got, err := hex.DecodeString(header.Get(SignatureHeader))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	want, _ := hex.DecodeString(Sign(secret, body))
+	if !hmac.Equal(got, want) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// RegisterDefaults registers cash on delivery and, when its account is set,
+// the VietQR bank transfer.
+func RegisterDefaults(vietqr VietQR) {
+	Register(COD{})
+	if vietqr.BankBIN != "" && vietqr.AccountNumber != "" {
+		Register(vietqr)
+	}
+}
@@ -0,0 +1,154 @@
+package payments
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"shoop-golang/pkg/money"
+)
+
+// VietQR is a bank transfer to the shop's account. The customer scans a
+// NAPAS VietQR code that fills in the account, the amount and a memo with
+// the order number; the bank notification forwarded by a reconciliation
+// service settles the order.
+//
+// Webhook bodies are JSON of the form
+//
+//	{"id": "FT2601...", "amount": 250000, "description": "OCC-2026-000123"}
+//
+// signed with WebhookSecret in SignatureHeader.
+type VietQR struct {
+	BankBIN       string // 6-digit NAPAS bank id, e.g. 970436
+	BankName      string
+	AccountNumber string
+	AccountName   string
+	WebhookSecret string
+	// OrderPrefix is the order number prefix, used to find the order in
+	// memos where the bank has dropped the dashes.
+	OrderPrefix string
+}
+
+func (v VietQR) Code() string        { return "vietqr" }
+func (v VietQR) Name() string        { return "Chuyển khoản ngân hàng (VietQR)" }
+func (v VietQR) Description() string { return "Quét mã QR bằng ứng dụng ngân hàng" }
+
+func (v VietQR) Initiate(req Request) (Instruction, error) {
+	if v.BankBIN == "" || v.AccountNumber == "" {
+		return Instruction{}, errors.New("payments: vietqr account is not configured")
+	}
+	return Instruction{
+		Provider:      v.Code(),
+		Status:        StatusPending,
+		Reference:     req.OrderNumber,
+		Amount:        req.Amount,
+		QRPayload:     VietQRPayload(v.BankBIN, v.AccountNumber, req.Amount, req.OrderNumber),
+		BankName:      v.BankName,
+		AccountNumber: v.AccountNumber,
+		AccountName:   v.AccountName,
+		Memo:          req.OrderNumber,
+	}, nil
+}
+
+func (v VietQR) VerifySignature(body []byte, header http.Header) error {
+	return verifyHMAC(v.WebhookSecret, body, header)
+}
+
+func (v VietQR) HandleCallback(body []byte) (Event, error) {
+	var n struct {
+		ID          json.RawMessage `json:"id"`
+		Amount      money.Money     `json:"amount"`
+		Description string          `json:"description"`
+	}
+	if err := json.Unmarshal(body, &n); err != nil {
+		return Event{}, fmt.Errorf("payments: invalid vietqr notification: %w", err)
+	}
+	id := strings.Trim(string(n.ID), `"`)
+	if id == "" || id == "null" {
+		return Event{}, errors.New("payments: vietqr notification without id")
+	}
+	return Event{
+		TransactionID: id,
+		Reference:     v.findOrderNumber(n.Description),
+		Amount:        n.Amount,
+		Status:        StatusSucceeded,
+	}, nil
+}
+
+// Refund of a bank transfer is paid back by staff from the shop account.
+func (v VietQR) Refund(string, money.Money) (RefundResult, error) {
+	return RefundResult{Status: StatusSucceeded, Manual: true}, nil
+}
+
+func (v VietQR) findOrderNumber(memo string) string {
+	if v.OrderPrefix == "" {
+		return strings.TrimSpace(memo)
+	}
+	re := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(v.OrderPrefix) + `[-\s]?(\d{4})[-\s]?(\d+)`)
+	m := re.FindStringSubmatch(memo)
+	if m == nil {
+		return ""
+	}
+	return v.OrderPrefix + "-" + m[1] + "-" + m[2]
+}
+
+// VietQRPayload builds the EMVCo merchant-presented QR string defined by
+// NAPAS for a transfer of amount đồng to account at the bank with id bin.
+func VietQRPayload(bin, account string, amount money.Money, memo string) string {
+	beneficiary := emv("00", bin) + emv("01", account)
+	merchant := emv("00", "A000000727") + emv("01", beneficiary) + emv("02", "QRIBFTTA")
+
+	var b strings.Builder
+	b.WriteString(emv("00", "01"))
+	b.WriteString(emv("01", "12")) // dynamic: single use, fixed amount
+	b.WriteString(emv("38", merchant))
+	b.WriteString(emv("53", "704"))
+	if amount > 0 {
+		b.WriteString(emv("54", strconv.FormatInt(int64(amount), 10)))
+	}
+	b.WriteString(emv("58", "VN"))
+	if memo = sanitizeMemo(memo); memo != "" {
+		b.WriteString(emv("62", emv("08", memo)))
+	}
+	b.WriteString("6304")
+	return b.String() + fmt.Sprintf("%04X", crc16(b.String()))
+}
+
+func emv(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+// sanitizeMemo keeps the characters banks accept in a transfer memo.
+func sanitizeMemo(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < 128 && (r == ' ' || r == '-' || r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z') {
+			b.WriteRune(r)
+		}
+	}
+	out := b.String()
+	if len(out) > 25 {
+		out = out[:25]
+	}
+	return out
+}
+
+// crc16 is CRC-16/CCITT-FALSE, the checksum of EMVCo QR payloads.
+func crc16(s string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
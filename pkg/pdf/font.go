@@ -0,0 +1,337 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/go-fonts/liberation/liberationsansbold"
+	"github.com/go-fonts/liberation/liberationsansregular"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Liberation Sans has the metrics of Helvetica and covers Vietnamese, so
+// layouts measured for Helvetica keep working.
+var (
+	regularFont = mustParseFont(liberationsansregular.TTF)
+	boldFont    = mustParseFont(liberationsansbold.TTF)
+)
+
+// ttfFont is a TrueType font embedded in documents as a Type 0 font with
+// two-byte glyph IDs, so any character the font has can be shown.
+type ttfFont struct {
+	data       []byte
+	name       string
+	unitsPerEm int
+	bbox       [4]int
+	ascent     int
+	descent    int
+	capHeight  int
+
+	mu     sync.Mutex
+	sfnt   *sfnt.Font
+	buf    sfnt.Buffer
+	glyphs map[rune]glyph
+}
+
+type glyph struct {
+	id      uint16
+	advance int // in font units
+}
+
+func mustParseFont(data []byte) *ttfFont {
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		panic("pdf: " + err.Error())
+	}
+	t := &ttfFont{data: data, sfnt: f, unitsPerEm: int(f.UnitsPerEm()), glyphs: map[rune]glyph{}}
+	t.name, _ = f.Name(&t.buf, sfnt.NameIDPostScript)
+	t.name = strings.ReplaceAll(t.name, " ", "")
+
+	tables, err := ttfTables(data)
+	if err != nil {
+		panic("pdf: " + err.Error())
+	}
+	head, hhea, os2 := tables["head"], tables["hhea"], tables["OS/2"]
+	for i := range t.bbox {
+		t.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	t.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	t.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	t.capHeight = t.ascent
+	if len(os2) >= 90 {
+		t.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:])))
+	}
+	return t
+}
+
+// glyph looks up r, falling back to its base letter when the font lacks
+// it. Unknown characters map to glyph 0, the font's missing glyph box.
+func (t *ttfFont) glyph(r rune) glyph {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if g, ok := t.glyphs[r]; ok {
+		return g
+	}
+	id, _ := t.sfnt.GlyphIndex(&t.buf, r)
+	if id == 0 {
+		for _, d := range norm.NFD.String(string(r)) {
+			if !unicode.Is(unicode.Mn, d) {
+				if d != r {
+					id, _ = t.sfnt.GlyphIndex(&t.buf, d)
+				}
+				break
+			}
+		}
+	}
+	adv, _ := t.sfnt.GlyphAdvance(&t.buf, id, fixed.I(t.unitsPerEm), font.HintingNone)
+	g := glyph{id: uint16(id), advance: adv.Round()}
+	t.glyphs[r] = g
+	return g
+}
+
+// toPDF converts font units to the 1/1000 em used by PDF font metrics.
+func (t *ttfFont) toPDF(v int) int {
+	return v * 1000 / t.unitsPerEm
+}
+
+// ttfTables returns the tables of a TrueType font by tag.
+func ttfTables(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, errors.New("font too short")
+	}
+	n := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*n {
+		return nil, errors.New("font table directory truncated")
+	}
+	tables := make(map[string][]byte, n)
+	for i := 0; i < n; i++ {
+		rec := data[12+16*i:]
+		off, length := binary.BigEndian.Uint32(rec[8:]), binary.BigEndian.Uint32(rec[12:])
+		if uint64(off)+uint64(length) > uint64(len(data)) {
+			return nil, fmt.Errorf("font table %q out of range", rec[:4])
+		}
+		tables[string(rec[:4])] = data[off : off+length]
+	}
+	for _, tag := range []string{"head", "hhea", "hmtx", "maxp", "loca", "glyf"} {
+		if tables[tag] == nil {
+			return nil, fmt.Errorf("font has no %q table", tag)
+		}
+	}
+	return tables, nil
+}
+
+// subsetTables are the tables of a font subset, sorted by tag as the table
+// directory must be. Layout tables such as kerning are left out.
+var subsetTables = []string{"OS/2", "cmap", "cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "name", "post", "prep"}
+
+// subset returns a copy of the font keeping only the outlines of used
+// (plus the glyphs they are composed of and the missing glyph). Glyph IDs
+// stay the same so text can keep referring to them.
+func (t *ttfFont) subset(used map[uint16]rune) ([]byte, error) {
+	tables, err := ttfTables(t.data)
+	if err != nil {
+		return nil, err
+	}
+	numGlyphs := int(binary.BigEndian.Uint16(tables["maxp"][4:]))
+	shortLoca := binary.BigEndian.Uint16(tables["head"][50:]) == 0
+	loca, glyf := tables["loca"], tables["glyf"]
+	outline := func(id int) []byte {
+		var start, end int
+		if shortLoca {
+			start, end = 2*int(binary.BigEndian.Uint16(loca[2*id:])), 2*int(binary.BigEndian.Uint16(loca[2*id+2:]))
+		} else {
+			start, end = int(binary.BigEndian.Uint32(loca[4*id:])), int(binary.BigEndian.Uint32(loca[4*id+4:]))
+		}
+		if start > end || end > len(glyf) {
+			return nil
+		}
+		return glyf[start:end]
+	}
+
+	keep := map[int]bool{0: true}
+	queue := []int{0}
+	for id := range used {
+		if int(id) < numGlyphs && !keep[int(id)] {
+			keep[int(id)] = true
+			queue = append(queue, int(id))
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, part := range components(outline(id)) {
+			if part < numGlyphs && !keep[part] {
+				keep[part] = true
+				queue = append(queue, part)
+			}
+		}
+	}
+
+	// Rebuild glyf with empty outlines for the glyphs left out, and a long
+	// loca pointing into it.
+	var newGlyf bytes.Buffer
+	newLoca := make([]byte, 4*(numGlyphs+1))
+	for id := 0; id < numGlyphs; id++ {
+		binary.BigEndian.PutUint32(newLoca[4*id:], uint32(newGlyf.Len()))
+		if keep[id] {
+			newGlyf.Write(outline(id))
+			for newGlyf.Len()%4 != 0 {
+				newGlyf.WriteByte(0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(newLoca[4*numGlyphs:], uint32(newGlyf.Len()))
+
+	head := append([]byte(nil), tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0)  // checkSumAdjustment, set below
+	binary.BigEndian.PutUint16(head[50:], 1) // indexToLocFormat: long
+	tables["head"], tables["loca"], tables["glyf"] = head, newLoca, newGlyf.Bytes()
+	if post := tables["post"]; len(post) >= 32 {
+		// Version 3 keeps the metrics but drops the glyph names.
+		post = append([]byte(nil), post[:32]...)
+		binary.BigEndian.PutUint32(post, 0x00030000)
+		tables["post"] = post
+	}
+
+	var tags []string
+	for _, tag := range subsetTables {
+		if tables[tag] != nil {
+			tags = append(tags, tag)
+		}
+	}
+	n := len(tags)
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= n {
+		entrySelector++
+	}
+	var out bytes.Buffer
+	binary.Write(&out, binary.BigEndian, []uint32{0x00010000})
+	binary.Write(&out, binary.BigEndian, []uint16{uint16(n), uint16(16 << entrySelector), uint16(entrySelector), uint16(16*n - 16<<entrySelector)})
+	offset := 12 + 16*n
+	headAt := 0
+	for _, tag := range tags {
+		data := tables[tag]
+		if tag == "head" {
+			headAt = offset
+		}
+		out.WriteString(tag)
+		binary.Write(&out, binary.BigEndian, []uint32{checksum(data), uint32(offset), uint32(len(data))})
+		offset += (len(data) + 3) &^ 3
+	}
+	for _, tag := range tags {
+		out.Write(tables[tag])
+		for out.Len()%4 != 0 {
+			out.WriteByte(0)
+		}
+	}
+	ttf := out.Bytes()
+	binary.BigEndian.PutUint32(ttf[headAt+8:], 0xB1B0AFBA-checksum(ttf))
+	return ttf, nil
+}
+
+// components returns the glyphs a composite glyph outline is built from.
+func components(outline []byte) []int {
+	if len(outline) < 10 || int16(binary.BigEndian.Uint16(outline)) >= 0 {
+		return nil
+	}
+	const (
+		argsAreWords   = 0x0001
+		haveScale      = 0x0008
+		moreComponents = 0x0020
+		haveXYScale    = 0x0040
+		have2x2        = 0x0080
+	)
+	var parts []int
+	for p := 10; p+4 <= len(outline); {
+		flags := binary.BigEndian.Uint16(outline[p:])
+		parts = append(parts, int(binary.BigEndian.Uint16(outline[p+2:])))
+		p += 4
+		if flags&argsAreWords != 0 {
+			p += 4
+		} else {
+			p += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			p += 2
+		case flags&haveXYScale != 0:
+			p += 4
+		case flags&have2x2 != 0:
+			p += 8
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return parts
+}
+
+func checksum(b []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(b); i += 4 {
+		var word [4]byte
+		copy(word[:], b[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
+
+// subsetTag names a font subset after the glyphs it holds, as the PDF
+// specification asks: six upper-case letters and a plus sign.
+func subsetTag(ids []uint16) string {
+	h := crc32.NewIEEE()
+	for _, id := range ids {
+		binary.Write(h, binary.BigEndian, id)
+	}
+	sum := h.Sum32()
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + byte(sum%26)
+		sum /= 26
+	}
+	return string(tag) + "+"
+}
+
+// toUnicode returns the CMap that lets viewers copy and search the text
+// shown with the used glyphs.
+func toUnicode(ids []uint16, used map[uint16]rune) string {
+	var b strings.Builder
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(ids); start += 100 {
+		end := min(start+100, len(ids))
+		fmt.Fprintf(&b, "%d beginbfchar\n", end-start)
+		for _, id := range ids[start:end] {
+			fmt.Fprintf(&b, "<%04X> <", id)
+			for _, u := range utf16.Encode([]rune{used[id]}) {
+				fmt.Fprintf(&b, "%04X", u)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.String()
+}
+
+func sortedIDs(used map[uint16]rune) []uint16 {
+	ids := make([]uint16, 0, len(used))
+	for id := range used {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
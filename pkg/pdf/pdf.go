@@ -0,0 +1,189 @@
+// Package pdf is a minimal PDF writer for simple business documents such as
+// invoices: A4 pages with text, lines and filled boxes.
+//
+// Text is set in Liberation Sans, embedded as a TrueType subset holding
+// only the glyphs a document uses, so Vietnamese prints with its
+// diacritics while the files stay small.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// A4 page size in points.
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+type Document struct {
+	pages []*Page
+	// used maps the glyphs drawn with the regular and bold font to the
+	// characters they show.
+	used [2]map[uint16]rune
+}
+
+// Page collects drawing operations. Coordinates are in points with the
+// origin at the top-left corner and y growing downwards.
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+}
+
+func New() *Document {
+	return &Document{used: [2]map[uint16]rune{{}, {}}}
+}
+
+func (d *Document) AddPage() *Page {
+	p := &Page{doc: d}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Text draws s with its baseline at (x, y).
+func (p *Page) Text(x, y, size float64, bold bool, s string) {
+	f, n := fontFor(bold)
+	var hex strings.Builder
+	for _, r := range norm.NFC.String(s) {
+		if r == '\r' || r == '\n' {
+			r = ' '
+		}
+		g := f.glyph(r)
+		fmt.Fprintf(&hex, "%04X", g.id)
+		if _, ok := p.doc.used[n][g.id]; !ok {
+			p.doc.used[n][g.id] = r
+		}
+	}
+	fmt.Fprintf(&p.content, "BT /F%d %.2f Tf %.2f %.2f Td <%s> Tj ET\n",
+		n+1, size, x, PageHeight-y, hex.String())
+}
+
+// TextRight draws s so that it ends at x.
+func (p *Page) TextRight(x, y, size float64, bold bool, s string) {
+	p.Text(x-TextWidth(s, size, bold), y, size, bold, s)
+}
+
+// Line draws a straight line of the given stroke width.
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f m %.2f %.2f l S\n",
+		width, x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+// FillRect fills a rectangle with a grey level between 0 (black) and 1
+// (white). (x, y) is the top-left corner.
+func (p *Page) FillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(&p.content, "q %.2f g %.2f %.2f %.2f %.2f re f Q\n",
+		gray, x, PageHeight-y-h, w, h)
+}
+
+// TextWidth returns the width of s in points.
+func TextWidth(s string, size float64, bold bool) float64 {
+	f, _ := fontFor(bold)
+	var w int
+	for _, r := range norm.NFC.String(s) {
+		w += f.glyph(r).advance
+	}
+	return float64(w) * size / float64(f.unitsPerEm)
+}
+
+// fontFor returns the font for regular or bold text and its index in
+// Document.used.
+func fontFor(bold bool) (*ttfFont, int) {
+	if bold {
+		return boldFont, 1
+	}
+	return regularFont, 0
+}
+
+// WriteTo serialises the document.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	var offsets []int
+
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	// Objects 1-2: catalog and page tree, then fontObjects objects for
+	// each font. Pages follow in pairs of (page, content stream).
+	firstPage := 3 + len(d.used)*fontObjects
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+i*2)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	for i, used := range d.used {
+		f, _ := fontFor(i == 1)
+		if err := writeFont(obj, 3+i*fontObjects, f, used); err != nil {
+			return 0, err
+		}
+	}
+
+	for i, p := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 %d 0 R >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, 3+fontObjects, firstPage+1+i*2))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// fontObjects is how many objects writeFont adds.
+const fontObjects = 5
+
+// writeFont adds f as objects first to first+4: the Type 0 font pages
+// refer to, its CID font, font descriptor, font program subset and
+// ToUnicode map.
+func writeFont(obj func(string), first int, f *ttfFont, used map[uint16]rune) error {
+	ttf, err := f.subset(used)
+	if err != nil {
+		return err
+	}
+	var program bytes.Buffer
+	zw := zlib.NewWriter(&program)
+	zw.Write(ttf)
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	ids := sortedIDs(used)
+	name := subsetTag(ids) + f.name
+	var widths strings.Builder
+	for _, id := range ids {
+		fmt.Fprintf(&widths, "%d [%d] ", id, f.toPDF(f.glyph(used[id]).advance))
+	}
+	cmap := toUnicode(ids, used)
+
+	obj(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H "+
+		"/DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>", name, first+1, first+4))
+	obj(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s "+
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+		"/FontDescriptor %d 0 R /CIDToGIDMap /Identity /W [%s] >>", name, first+2, strings.TrimSpace(widths.String())))
+	obj(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] "+
+		"/ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		name, f.toPDF(f.bbox[0]), f.toPDF(f.bbox[1]), f.toPDF(f.bbox[2]), f.toPDF(f.bbox[3]),
+		f.toPDF(f.ascent), f.toPDF(f.descent), f.toPDF(f.capHeight), first+3))
+	obj(fmt.Sprintf("<< /Length %d /Length1 %d /Filter /FlateDecode >>\nstream\n%s\nendstream",
+		program.Len(), len(ttf), program.String()))
+	obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(cmap), cmap))
+	return nil
+}
@@ -1,43 +1,61 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"math"
 	"regexp"
 	"strings"
 	"time"
+	"unicode"
+
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/money"
+
+	"golang.org/x/text/unicode/norm"
 )
 
+// TemplateFuncs returns the template functions in the default locale.
 func TemplateFuncs() template.FuncMap {
+	return LocaleFuncs(i18n.Default)
+}
+
+// LocaleFuncs returns the template functions with t and the labels they
+// print translated into locale.
+func LocaleFuncs(locale string) template.FuncMap {
+	t := func(key string, args ...any) string {
+		return i18n.Translate(locale, key, args...)
+	}
+	formatVND := func(price money.Money) string {
+		return ListPrice(locale, price)
+	}
+	tOr := func(key, fallback string) string {
+		if msg, ok := i18n.Lookup(locale, key); ok {
+			return msg
+		}
+		return fallback
+	}
+	label := func(prefix, status string) string {
+		return tOr(prefix+status, status)
+	}
 	return template.FuncMap{
-		"formatPrice": func(price float64) string {
+		"t":           t,
+		"tOr":         tOr,
+		"locale":      func() string { return locale },
+		"formatPrice": formatVND,
+		"formatVND":   formatVND,
+		"formatPriceIn": func(price money.Money, code string, rate float64) string {
 			if price == 0 {
-				return "Liên hệ"
-			}
-			p := int64(price)
-			s := fmt.Sprintf("%d", p)
-			n := len(s)
-			if n <= 3 {
-				return s + "₫"
+				return t("price.contact")
 			}
-			var parts []string
-			for n > 0 {
-				end := n
-				start := n - 3
-				if start < 0 {
-					start = 0
-				}
-				parts = append([]string{s[start:end]}, parts...)
-				n = start
-			}
-			return strings.Join(parts, ".") + "₫"
+			return FormatPriceIn(price, money.Display{Code: code, Rate: rate})
 		},
-		"salePercent": func(original, sale float64) int {
+		"salePercent": func(original, sale money.Money) int {
 			if original <= 0 || sale <= 0 || sale >= original {
 				return 0
 			}
-			return int(math.Round(((original - sale) / original) * 100))
+			return int(math.Round((original - sale).Float() / original.Float() * 100))
 		},
 		"truncate": func(s string, length int) string {
 			if len(s) <= length {
@@ -48,6 +66,7 @@ func TemplateFuncs() template.FuncMap {
 		"safeHTML": func(s string) template.HTML {
 			return template.HTML(s)
 		},
+		"jsonLD": JSONLD,
 		"formatDate": func(t time.Time) string {
 			return t.Format("02/01/2006")
 		},
@@ -64,7 +83,7 @@ func TemplateFuncs() template.FuncMap {
 		"add": func(a, b int) int { return a + b },
 		"sub": func(a, b int) int { return a - b },
 		"mul":     func(a, b float64) float64 { return a * b },
-		"mulInt":  func(a float64, b int) float64 { return a * float64(b) },
+		"mulInt":  func(a money.Money, b int) money.Money { return a.Mul(b) },
 		"statusBadge": func(status string) template.HTML {
 			colors := map[string]string{
 				"pending":   "bg-yellow-100 text-yellow-800",
@@ -73,23 +92,41 @@ func TemplateFuncs() template.FuncMap {
 				"delivered": "bg-green-100 text-green-800",
 				"cancelled": "bg-red-100 text-red-800",
 			}
-			labels := map[string]string{
-				"pending":   "Chờ xử lý",
-				"confirmed": "Đã xác nhận",
-				"shipping":  "Đang giao",
-				"delivered": "Đã giao",
-				"cancelled": "Đã hủy",
+			cls := colors[status]
+			if cls == "" {
+				cls = "bg-gray-100 text-gray-800"
+			}
+			lbl := label("order.status.", status)
+			return template.HTML(fmt.Sprintf(`<span class="px-2 py-1 text-xs font-medium rounded-full %s">%s</span>`, cls, lbl))
+		},
+		"paymentBadge": func(status string) template.HTML {
+			colors := map[string]string{
+				"unpaid":   "bg-yellow-100 text-yellow-800",
+				"paid":     "bg-green-100 text-green-800",
+				"failed":   "bg-red-100 text-red-800",
+				"refunded": "bg-gray-100 text-gray-800",
 			}
 			cls := colors[status]
-			lbl := labels[status]
 			if cls == "" {
 				cls = "bg-gray-100 text-gray-800"
 			}
-			if lbl == "" {
-				lbl = status
+			lbl := label("payment.status.", status)
+			return template.HTML(fmt.Sprintf(`<span class="px-2 py-1 text-xs font-medium rounded-full %s">%s</span>`, cls, lbl))
+		},
+		"reviewBadge": func(status string) template.HTML {
+			colors := map[string]string{
+				"pending":  "bg-yellow-100 text-yellow-800",
+				"approved": "bg-green-100 text-green-800",
+				"rejected": "bg-red-100 text-red-800",
+			}
+			cls := colors[status]
+			if cls == "" {
+				cls = "bg-gray-100 text-gray-800"
 			}
+			lbl := label("review.status.", status)
 			return template.HTML(fmt.Sprintf(`<span class="px-2 py-1 text-xs font-medium rounded-full %s">%s</span>`, cls, lbl))
 		},
+		"stars": StarIcons,
 		"dict": func(values ...any) map[string]any {
 			m := make(map[string]any)
 			for i := 0; i < len(values)-1; i += 2 {
@@ -103,10 +140,99 @@ func TemplateFuncs() template.FuncMap {
 	}
 }
 
+// StarIcons returns the Font Awesome classes of five stars showing rating,
+// rounded to the nearest half star.
+func StarIcons(rating float64) []string {
+	halves := int(math.Round(rating * 2))
+	icons := make([]string, 5)
+	for i := range icons {
+		switch {
+		case halves >= 2*(i+1):
+			icons[i] = "fas fa-star"
+		case halves == 2*i+1:
+			icons[i] = "fas fa-star-half-alt"
+		default:
+			icons[i] = "far fa-star"
+		}
+	}
+	return icons
+}
+
+// JSONLD serializes v for a <script type="application/ld+json"> block.
+// json.Marshal escapes <, > and &, so the data cannot close the script.
+func JSONLD(v any) (template.JS, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return template.JS(b), nil
+}
+
+// FormatPrice formats a VND amount with "." thousands separators, e.g.
+// 1990000 → "1.990.000₫".
+func FormatPrice(price money.Money) string {
+	sign := ""
+	if price < 0 {
+		sign = "-"
+		price = -price
+	}
+	return sign + groupThousands(fmt.Sprintf("%d", int64(price)), ".") + "₫"
+}
+
+// ListPrice formats the price of a product for shoppers reading locale. A
+// product without a price shows the price.contact label instead of 0₫.
+func ListPrice(locale string, price money.Money) string {
+	if price == 0 {
+		return i18n.Translate(locale, "price.contact")
+	}
+	return FormatPrice(price)
+}
+
+// FormatPriceIn formats a VND amount converted to the display currency d,
+// e.g. "$75.42" or "€69.10". Amounts in VND fall back to FormatPrice.
+func FormatPriceIn(price money.Money, d money.Display) string {
+	if d.IsBase() {
+		return FormatPrice(price)
+	}
+	v := d.Convert(price)
+	sign := ""
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	s := fmt.Sprintf("%.2f", v)
+	whole, cents := s[:len(s)-3], s[len(s)-2:]
+	return sign + d.Symbol() + groupThousands(whole, ",") + "." + cents
+}
+
+// groupThousands inserts sep between groups of three digits.
+func groupThousands(s, sep string) string {
+	n := len(s)
+	if n <= 3 {
+		return s
+	}
+	var parts []string
+	for n > 0 {
+		start := max(n-3, 0)
+		parts = append([]string{s[start:n]}, parts...)
+		n = start
+	}
+	return strings.Join(parts, sep)
+}
+
 var slugRegex = regexp.MustCompile(`[^a-z0-9]+`)
 
+// Slugify makes a URL slug of s, spelling Vietnamese letters without their
+// marks: "Vòng tay đá thạch anh" → "vong-tay-da-thach-anh".
 func Slugify(s string) string {
 	s = strings.ToLower(strings.TrimSpace(s))
-	s = slugRegex.ReplaceAllString(s, "-")
+	s = strings.ReplaceAll(s, "đ", "d")
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	s = slugRegex.ReplaceAllString(b.String(), "-")
 	return strings.Trim(s, "-")
 }
@@ -7,64 +7,118 @@ import (
 	"path/filepath"
 	"strings"
 
+	"shoop-golang/pkg/i18n"
+	"shoop-golang/pkg/money"
+
 	"github.com/labstack/echo/v4"
 )
 
+// TemplateRenderer keeps the pages parsed once per locale, with t and the
+// other template functions bound to the locale at parse time. Prices show
+// in VND; a page whose data["Currency"] is another display currency is
+// rendered from a clone with formatPrice bound to that request's rate.
 type TemplateRenderer struct {
-	templates map[string]*template.Template
+	locales map[string]*localeTemplates
+}
+
+type localeTemplates struct {
+	locale string
+	pages  map[string]*template.Template // rendered as they are, in VND
+	// sources are never executed, so they can still be cloned:
+	// html/template refuses to clone a template once it has run.
+	sources map[string]*template.Template
+}
+
+func newRenderer(parse func(funcs template.FuncMap) map[string]*template.Template) *TemplateRenderer {
+	t := &TemplateRenderer{locales: make(map[string]*localeTemplates)}
+	for _, locale := range i18n.Locales {
+		set := &localeTemplates{locale: locale, pages: make(map[string]*template.Template), sources: parse(LocaleFuncs(locale))}
+		for name, src := range set.sources {
+			set.pages[name] = template.Must(src.Clone())
+		}
+		t.locales[locale] = set
+	}
+	return t
 }
 
 func NewAdminRenderer(templatesDir string) *TemplateRenderer {
-	t := &TemplateRenderer{templates: make(map[string]*template.Template)}
-	funcs := TemplateFuncs()
+	return newRenderer(func(funcs template.FuncMap) map[string]*template.Template {
+		return parseAdmin(templatesDir, funcs)
+	})
+}
+
+func parseAdmin(templatesDir string, funcs template.FuncMap) map[string]*template.Template {
+	templates := make(map[string]*template.Template)
 
 	base := filepath.Join(templatesDir, "admin", "layouts", "base.html")
 	sidebar := filepath.Join(templatesDir, "admin", "partials", "sidebar.html")
 	header := filepath.Join(templatesDir, "admin", "partials", "header.html")
+	pager := filepath.Join(templatesDir, "admin", "partials", "pager.html")
+	localeTabs := filepath.Join(templatesDir, "admin", "partials", "locale_tabs.html")
 
 	pages, _ := filepath.Glob(filepath.Join(templatesDir, "admin", "pages", "*", "*.html"))
 	for _, page := range pages {
 		name := adminTemplateName(templatesDir, page)
-		t.templates[name] = template.Must(
-			template.New("").Funcs(funcs).ParseFiles(base, sidebar, header, page),
+		templates[name] = template.Must(
+			template.New("").Funcs(funcs).ParseFiles(base, sidebar, header, pager, localeTabs, page),
 		)
 	}
 
 	login := filepath.Join(templatesDir, "admin", "pages", "login.html")
-	t.templates["admin/login"] = template.Must(
+	templates["admin/login"] = template.Must(
 		template.New("").Funcs(funcs).ParseFiles(login),
 	)
 
-	return t
+	return templates
 }
 
 func NewWebRenderer(templatesDir string) *TemplateRenderer {
-	t := &TemplateRenderer{templates: make(map[string]*template.Template)}
-	funcs := TemplateFuncs()
+	return newRenderer(func(funcs template.FuncMap) map[string]*template.Template {
+		return parseWeb(templatesDir, funcs)
+	})
+}
+
+func parseWeb(templatesDir string, funcs template.FuncMap) map[string]*template.Template {
+	templates := make(map[string]*template.Template)
 
 	base := filepath.Join(templatesDir, "web", "layouts", "base.html")
 	navbar := filepath.Join(templatesDir, "web", "partials", "navbar.html")
 	footer := filepath.Join(templatesDir, "web", "partials", "footer.html")
 	authModal := filepath.Join(templatesDir, "web", "partials", "auth_modal.html")
 	productCard := filepath.Join(templatesDir, "web", "partials", "product_card.html")
+	addressFields := filepath.Join(templatesDir, "web", "partials", "address_fields.html")
 
 	pages, _ := filepath.Glob(filepath.Join(templatesDir, "web", "pages", "*", "*.html"))
 	for _, page := range pages {
 		name := webTemplateName(templatesDir, page)
-		t.templates[name] = template.Must(
-			template.New("").Funcs(funcs).ParseFiles(base, navbar, footer, authModal, productCard, page),
+		templates[name] = template.Must(
+			template.New("").Funcs(funcs).ParseFiles(base, navbar, footer, authModal, productCard, addressFields, page),
 		)
 	}
 
-	return t
+	return templates
 }
 
 func (r *TemplateRenderer) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
-	tmpl, ok := r.templates[name]
+	set, ok := r.locales[i18n.Locale(c)]
+	if !ok {
+		set = r.locales[i18n.Default]
+	}
+	tmpl, ok := set.pages[name]
 	if !ok {
 		return fmt.Errorf("template %s not found", name)
 	}
 
+	if m, ok := data.(map[string]any); ok {
+		if d, ok := m["Currency"].(money.Display); ok && !d.IsBase() {
+			clone, err := set.sources[name].Clone()
+			if err != nil {
+				return err
+			}
+			tmpl = clone.Funcs(template.FuncMap{"formatPrice": displayPrice(set.locale, d)})
+		}
+	}
+
 	templateName := "base"
 	if name == "admin/login" {
 		templateName = "login"
@@ -73,6 +127,16 @@ func (r *TemplateRenderer) Render(w io.Writer, name string, data interface{}, c
 	return tmpl.ExecuteTemplate(w, templateName, data)
 }
 
+// displayPrice is formatPrice for prices shown in the display currency d.
+func displayPrice(locale string, d money.Display) func(money.Money) string {
+	return func(price money.Money) string {
+		if price == 0 {
+			return i18n.Translate(locale, "price.contact")
+		}
+		return FormatPriceIn(price, d)
+	}
+}
+
 func adminTemplateName(base, path string) string {
 	rel, _ := filepath.Rel(filepath.Join(base, "admin", "pages"), path)
 	rel = strings.TrimSuffix(rel, ".html")
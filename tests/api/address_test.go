@@ -0,0 +1,257 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/tests/testutil"
+)
+
+// createTestDivisions adds Hà Nội with one district and ward, and Cần Thơ
+// without any districts.
+func createTestDivisions(t *testing.T) {
+	t.Helper()
+	provinces := []models.Province{
+		{Code: "01", Name: "Thành phố Hà Nội", Districts: []models.District{
+			{Code: "001", Name: "Quận Ba Đình", Wards: []models.Ward{{Code: "00001", Name: "Phường Phúc Xá"}}},
+		}},
+		{Code: "92", Name: "Thành phố Cần Thơ"},
+	}
+	if err := database.DB.Create(&provinces).Error; err != nil {
+		t.Fatalf("create divisions: %v", err)
+	}
+}
+
+func TestWebLocations(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	createTestDivisions(t)
+
+	e := testutil.NewWebEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+
+	list := func(path string) []map[string]any {
+		t.Helper()
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("get %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		var items []map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+		return items
+	}
+
+	if p := list("/locations/provinces"); len(p) != 2 || p[0]["code"] != "01" || p[0]["districts"] != nil {
+		t.Errorf("unexpected provinces: %v", p)
+	}
+	if d := list("/locations/provinces/01/districts"); len(d) != 1 || d[0]["name"] != "Quận Ba Đình" {
+		t.Errorf("unexpected districts: %v", d)
+	}
+	if w := list("/locations/districts/001/wards"); len(w) != 1 || w[0]["code"] != "00001" {
+		t.Errorf("unexpected wards: %v", w)
+	}
+	if d := list("/locations/provinces/92/districts"); len(d) != 0 {
+		t.Errorf("expected no districts for Cần Thơ, got %v", d)
+	}
+}
+
+func TestWebAddressBook(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	user := testutil.CreateTestUser(t)
+	createTestDivisions(t)
+
+	e := testutil.NewWebEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+
+	t.Run("requires login", func(t *testing.T) {
+		resp, err := testutil.GetWithCookies(ts, "/account/addresses", nil)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		resp.Body.Close()
+		if loc := resp.Header.Get("Location"); resp.StatusCode != http.StatusFound || !strings.HasPrefix(loc, "/?login=1") {
+			t.Errorf("expected redirect to login modal, got %d %q", resp.StatusCode, loc)
+		}
+	})
+
+	cookies := testutil.WebLoginCookies(t, ts)
+	post := func(path string, values url.Values) {
+		t.Helper()
+		resp, err := testutil.PostForm(ts, path, cookies, values)
+		if err != nil {
+			t.Fatalf("post %s failed: %v", path, err)
+		}
+		resp.Body.Close()
+	}
+	addresses := func() []models.UserAddress {
+		var list []models.UserAddress
+		database.DB.Where("user_id = ?", user.ID).Order("created_at ASC").Find(&list)
+		return list
+	}
+
+	t.Run("validates hierarchy", func(t *testing.T) {
+		post("/account/addresses", url.Values{
+			"name": {"A"}, "phone": {"0901"}, "address": {"1 Đội Cấn"},
+			"province_code": {"92"}, "district_code": {"001"},
+		})
+		post("/account/addresses", url.Values{
+			"name": {"A"}, "phone": {"0901"}, "address": {"1 Đội Cấn"},
+			"province_code": {"01"}, "district_code": {"001"},
+		})
+		if n := len(addresses()); n != 0 {
+			t.Errorf("expected invalid addresses to be rejected, got %d", n)
+		}
+	})
+
+	post("/account/addresses", url.Values{
+		"name": {"Nhà"}, "phone": {"0901234567"}, "address": {"1 Đội Cấn"},
+		"province_code": {"01"}, "district_code": {"001"}, "ward_code": {"00001"},
+	})
+	post("/account/addresses", url.Values{
+		"name": {"Công ty"}, "phone": {"0907654321"}, "address": {"2 Trần Phú"},
+		"province_code": {"92"},
+	})
+	list := addresses()
+	if len(list) != 2 {
+		t.Fatalf("expected 2 addresses, got %d", len(list))
+	}
+	home, office := list[0], list[1]
+	if !home.IsDefault || office.IsDefault {
+		t.Errorf("expected the first address to become the default")
+	}
+	if home.FullAddress() != "1 Đội Cấn, Phường Phúc Xá, Quận Ba Đình, Thành phố Hà Nội" {
+		t.Errorf("unexpected full address %q", home.FullAddress())
+	}
+
+	t.Run("set default", func(t *testing.T) {
+		post("/account/addresses/"+office.ID+"/default", nil)
+		var defaults []models.UserAddress
+		database.DB.Where("user_id = ? AND is_default = ?", user.ID, true).Find(&defaults)
+		if len(defaults) != 1 || defaults[0].ID != office.ID {
+			t.Errorf("expected only the office address to be default, got %v", defaults)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		post("/account/addresses/"+home.ID, url.Values{
+			"name": {"Nhà"}, "phone": {"0901234567"}, "address": {"5 Kim Mã"},
+			"province_code": {"01"}, "district_code": {"001"}, "ward_code": {"00001"},
+		})
+		var got models.UserAddress
+		database.DB.First(&got, "id = ?", home.ID)
+		if got.Address != "5 Kim Mã" || got.IsDefault {
+			t.Errorf("unexpected updated address %+v", got)
+		}
+	})
+
+	t.Run("other users' addresses are off limits", func(t *testing.T) {
+		other := models.UserAddress{UserID: "someone-else", Name: "X", Phone: "1", Address: "x", ProvinceCode: "92", Province: "Thành phố Cần Thơ"}
+		database.DB.Create(&other)
+		post("/account/addresses/"+other.ID+"/delete", nil)
+		var count int64
+		database.DB.Model(&models.UserAddress{}).Where("id = ?", other.ID).Count(&count)
+		if count != 1 {
+			t.Error("expected another user's address to survive")
+		}
+	})
+
+	t.Run("delete default hands it over", func(t *testing.T) {
+		post("/account/addresses/"+office.ID+"/delete", nil)
+		list := addresses()
+		if len(list) != 1 || list[0].ID != home.ID || !list[0].IsDefault {
+			t.Errorf("expected the remaining address to become default, got %v", list)
+		}
+	})
+}
+
+func TestWebCheckout_StructuredAddress(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	createTestDivisions(t)
+
+	e := testutil.NewWebEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+
+	checkout := func(client *http.Client, body map[string]string) (int, map[string]any) {
+		t.Helper()
+		b, _ := json.Marshal(body)
+		resp, err := client.Post(ts.URL+"/checkout", "application/json", bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("checkout failed: %v", err)
+		}
+		defer resp.Body.Close()
+		var out map[string]any
+		json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+
+	t.Run("rejects a ward outside the district", func(t *testing.T) {
+		client := shippingClient(t, ts, prod, "1")
+		status, out := checkout(client, map[string]string{
+			"name": "A", "phone": "0901", "address": "1 Đội Cấn",
+			"province_code": "01", "district_code": "001", "ward_code": "99999",
+		})
+		if status != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d %v", status, out)
+		}
+	})
+
+	t.Run("stores division names and saves the address", func(t *testing.T) {
+		client := shippingClient(t, ts, prod, "1")
+		status, out := checkout(client, map[string]string{
+			"name": "A", "phone": "0901", "address": "1 Đội Cấn",
+			"province_code": "01", "district_code": "001", "ward_code": "00001",
+			"save_address": "on",
+		})
+		if status != http.StatusOK {
+			t.Fatalf("expected 200, got %d %v", status, out)
+		}
+		var order models.Order
+		database.DB.First(&order, "id = ?", out["order_id"])
+		if order.Province != "Thành phố Hà Nội" || order.District != "Quận Ba Đình" || order.Ward != "Phường Phúc Xá" || order.WardCode != "00001" {
+			t.Errorf("unexpected order address %+v", order)
+		}
+		var saved []models.UserAddress
+		database.DB.Where("user_id = ?", user.ID).Find(&saved)
+		if len(saved) != 1 || !saved[0].IsDefault || saved[0].FullAddress() != order.FullAddress() {
+			t.Errorf("expected the checkout address to be saved as default, got %v", saved)
+		}
+	})
+
+	t.Run("uses a saved address", func(t *testing.T) {
+		var saved models.UserAddress
+		database.DB.Where("user_id = ?", user.ID).First(&saved)
+		client := shippingClient(t, ts, prod, "1")
+		status, out := checkout(client, map[string]string{"address_id": saved.ID})
+		if status != http.StatusOK {
+			t.Fatalf("expected 200, got %d %v", status, out)
+		}
+		var order models.Order
+		database.DB.First(&order, "id = ?", out["order_id"])
+		if order.Name != saved.Name || order.DistrictCode != "001" {
+			t.Errorf("expected order to copy the saved address, got %+v", order)
+		}
+
+		status, _ = checkout(shippingClient(t, ts, prod, "1"), map[string]string{"address_id": "missing"})
+		if status != http.StatusBadRequest {
+			t.Errorf("expected 400 for unknown address, got %d", status)
+		}
+	})
+}
@@ -242,7 +242,16 @@ func TestAdminCategories_Delete(t *testing.T) {
 	testutil.SetupTestDB(t)
 	testutil.SetupSession()
 	testutil.CreateTestAdmin(t)
+	parent := models.Category{Name: "Phong thủy", Slug: "phong-thuy", IsActive: true}
+	database.DB.Create(&parent)
 	cat := testutil.CreateTestCategory(t)
+	database.DB.Model(&cat).Update("parent_id", parent.ID)
+	child := models.Category{Name: "Vòng tay", Slug: "vong-tay", ParentID: &cat.ID, IsActive: true}
+	database.DB.Create(&child)
+	database.DB.Create(&models.CategoryTranslation{CategoryID: cat.ID, Locale: "en", Name: "Stones", Slug: "stones"})
+	database.DB.Create(&models.SlugRedirect{Kind: models.SlugCategory, Locale: "vi", Slug: "da-cu", TargetID: cat.ID})
+	database.DB.Create(&models.SEOBanner{Page: models.SEOCategoryPage(cat.ID), Title: "Đá quý",
+		Translations: []models.SEOBannerTranslation{{Locale: "en", Title: "Gemstones"}}})
 
 	e := testutil.NewAdminEcho()
 	ts := httptest.NewServer(e)
@@ -264,6 +273,63 @@ func TestAdminCategories_Delete(t *testing.T) {
 	if count != 0 {
 		t.Errorf("expected category deleted, count=%d", count)
 	}
+	database.DB.First(&child, "id = ?", child.ID)
+	if child.ParentID == nil || *child.ParentID != parent.ID {
+		t.Error("expected the child moved up to the deleted category's parent")
+	}
+	database.DB.Unscoped().Model(&models.CategoryTranslation{}).Where("category_id = ?", cat.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("expected translations removed, count=%d", count)
+	}
+	database.DB.Unscoped().Model(&models.SlugRedirect{}).Where("target_id = ?", cat.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("expected slug redirects removed, count=%d", count)
+	}
+	database.DB.Unscoped().Model(&models.SEOBanner{}).Where("page = ?", models.SEOCategoryPage(cat.ID)).Count(&count)
+	if count != 0 {
+		t.Errorf("expected the SEO entry removed, count=%d", count)
+	}
+	database.DB.Unscoped().Model(&models.SEOBannerTranslation{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected the SEO entry's translations removed, count=%d", count)
+	}
+}
+
+func TestAdminCategories_DeleteRollsBack(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	cat := testutil.CreateTestCategory(t)
+	child := models.Category{Name: "Vòng tay", Slug: "vong-tay", ParentID: &cat.ID, IsActive: true}
+	database.DB.Create(&child)
+	database.DB.Create(&models.CategoryTranslation{CategoryID: cat.ID, Locale: "en", Name: "Stones", Slug: "stones"})
+
+	e := testutil.NewAdminEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	cookies := testutil.AdminLoginCookies(t, ts)
+
+	// Without the redirects table the third statement fails.
+	database.DB.Migrator().DropTable(&models.SlugRedirect{})
+	resp, err := testutil.PostForm(ts, "/categories/"+cat.ID+"/delete", cookies, url.Values{})
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	resp.Body.Close()
+
+	var count int64
+	database.DB.Model(&models.Category{}).Where("id = ?", cat.ID).Count(&count)
+	if count != 1 {
+		t.Error("expected the category kept when deleting fails")
+	}
+	database.DB.First(&child, "id = ?", child.ID)
+	if child.ParentID == nil || *child.ParentID != cat.ID {
+		t.Error("expected the child left under its parent")
+	}
+	database.DB.Model(&models.CategoryTranslation{}).Where("category_id = ?", cat.ID).Count(&count)
+	if count != 1 {
+		t.Error("expected the translation kept")
+	}
 }
 
 func TestAdminProducts_List(t *testing.T) {
@@ -375,6 +441,7 @@ func TestAdminProducts_Delete(t *testing.T) {
 	testutil.CreateTestAdmin(t)
 	cat := testutil.CreateTestCategory(t)
 	prod := testutil.CreateTestProduct(t, cat.ID)
+	addProductData(t, prod.ID, "other-product")
 
 	e := testutil.NewAdminEcho()
 	ts := httptest.NewServer(e)
@@ -396,6 +463,9 @@ func TestAdminProducts_Delete(t *testing.T) {
 	if count != 0 {
 		t.Errorf("expected product deleted, count=%d", count)
 	}
+	if left := productLeftovers(prod.ID); len(left) > 0 {
+		t.Errorf("expected the product's data removed, left %v", left)
+	}
 }
 
 func TestAdminOrders_List(t *testing.T) {
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/tests/testutil"
+
+	"gorm.io/gorm"
+)
+
+func TestAttributes(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	cat := testutil.CreateTestCategory(t)
+	statues := models.Category{Name: "Tượng", Slug: "tuong", IsActive: true}
+	database.DB.Create(&statues)
+
+	admin := httptest.NewServer(testutil.NewAdminEcho())
+	defer admin.Close()
+	cookies := testutil.AdminLoginCookies(t, admin)
+	web := httptest.NewServer(testutil.NewWebRenderedEcho())
+	defer web.Close()
+
+	post := func(path string, values url.Values) {
+		t.Helper()
+		resp, err := testutil.PostForm(admin, path, cookies, values)
+		if err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	attribute := func(code string) models.Attribute {
+		t.Helper()
+		var attr models.Attribute
+		if err := database.DB.Preload("Values", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order ASC") }).First(&attr, "code = ?", code).Error; err != nil {
+			t.Fatalf("attribute %q not found", code)
+		}
+		return attr
+	}
+	get := func(path string) string {
+		t.Helper()
+		resp, err := testutil.GetWithCookies(web, path, nil)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	post("/attributes", url.Values{
+		"name": {"Ngũ hành"}, "code": {"element"}, "type": {"multi"}, "filterable": {"on"},
+		"value_id": {"", "", ""}, "value_name": {"Kim", "Mộc", "Thủy"},
+	})
+	post("/attributes", url.Values{
+		"name": {"Đường kính"}, "code": {"diameter"}, "type": {"number"}, "unit": {"mm"},
+		"category_ids": {cat.ID},
+	})
+	element := attribute("element")
+	if len(element.Values) != 3 || element.Values[1].Slug != "moc" || element.Values[2].Slug != "thuy" {
+		t.Fatalf("expected transliterated value slugs, got %+v", element.Values)
+	}
+	diameter := attribute("diameter")
+	if diameter.Filterable {
+		t.Error("expected an unchecked filterable kept false")
+	}
+
+	// Reserved and duplicate codes are refused.
+	for _, code := range []string{"category", "element"} {
+		post("/attributes", url.Values{"name": {"Trùng"}, "code": {code}, "type": {"select"}})
+	}
+	var count int64
+	database.DB.Model(&models.Attribute{}).Count(&count)
+	if count != 2 {
+		t.Errorf("expected reserved and taken codes refused, got %d attributes", count)
+	}
+
+	// Products pick values of the attributes of their category.
+	product := func(name, sku, categoryID string, values url.Values) models.Product {
+		t.Helper()
+		values.Set("name", name)
+		values.Set("sku", sku)
+		values.Set("original_price", "500000")
+		values.Set("stock", "5")
+		values.Set("category_id", categoryID)
+		values.Set("is_active", "on")
+		post("/products", values)
+		var p models.Product
+		if err := database.DB.First(&p, "sku = ?", sku).Error; err != nil {
+			t.Fatalf("product %s not created", sku)
+		}
+		return p
+	}
+	kim, moc, thuy := element.Values[0], element.Values[1], element.Values[2]
+	bracelet := product("Vòng tay thạch anh", "VT-1", cat.ID, url.Values{
+		"attr_" + element.ID:  {kim.ID, thuy.ID, "not-a-value"},
+		"attr_" + diameter.ID: {"8"},
+		"tags":                {"Quà tặng, may mắn, quà tặng"},
+	})
+	statue := product("Tượng Di Lặc", "TD-1", statues.ID, url.Values{
+		"attr_" + element.ID:  {moc.ID},
+		"attr_" + diameter.ID: {"20"},
+		"tags":                {"Qua tang"},
+	})
+
+	var rows []models.ProductAttribute
+	database.DB.Where("product_id = ?", statue.ID).Find(&rows)
+	if len(rows) != 1 {
+		t.Errorf("expected attributes of other categories ignored, got %d rows", len(rows))
+	}
+	var tags []models.Tag
+	database.DB.Order("name").Find(&tags)
+	if len(tags) != 2 {
+		t.Fatalf("expected tags shared by slug, got %+v", tags)
+	}
+
+	// Filters: values of one attribute match any, tags narrow further.
+	cases := []struct {
+		query   string
+		want    []string
+		notWant []string
+	}{
+		{"?element=kim", []string{bracelet.Name}, []string{statue.Name}},
+		{"?element=kim&element=moc", []string{bracelet.Name, statue.Name}, nil},
+		{"?element=moc&tag=may-man", nil, []string{bracelet.Name, statue.Name}},
+		{"?tag=qua-tang", []string{bracelet.Name, statue.Name, "#Quà tặng"}, nil},
+		{"?category=" + statues.Slug + "&element=kim", nil, []string{bracelet.Name, statue.Name}},
+	}
+	for _, tc := range cases {
+		body := get("/products" + tc.query)
+		for _, name := range tc.want {
+			if !strings.Contains(body, name) {
+				t.Errorf("%s: expected %q", tc.query, name)
+			}
+		}
+		for _, name := range tc.notWant {
+			if strings.Contains(body, name) {
+				t.Errorf("%s: did not expect %q", tc.query, name)
+			}
+		}
+	}
+	body := get("/products?element=thuy")
+	if !strings.Contains(body, `name="element" value="thuy" checked`) {
+		t.Error("expected the element filter in the sidebar with thuy checked")
+	}
+	if strings.Contains(body, `name="diameter"`) {
+		t.Error("expected number attributes left out of the filters")
+	}
+
+	// The detail page lists the specs and tags.
+	body = get("/products/" + bracelet.Slug)
+	for _, want := range []string{"Ngũ hành", "Kim, Thủy", "8 mm", `href="/products?tag=may-man"`, "VT-1"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the specs", want)
+		}
+	}
+
+	// Removing a value takes it off products; renaming keeps the rest.
+	post("/attributes/"+element.ID, url.Values{
+		"name": {"Ngũ hành"}, "code": {"element"}, "type": {"multi"}, "filterable": {"on"},
+		"value_id": {kim.ID, moc.ID}, "value_name": {"Kim loại", "Mộc"},
+	})
+	database.DB.Where("product_id = ?", bracelet.ID).Find(&rows)
+	var values []string
+	for _, r := range rows {
+		if r.ValueID != nil {
+			values = append(values, *r.ValueID)
+		}
+	}
+	if len(values) != 1 || values[0] != kim.ID {
+		t.Errorf("expected only the kept value left on the product, got %v", values)
+	}
+	if attribute("element").Values[0].Slug != "kim-loai" {
+		t.Error("expected the renamed value's slug to follow its name")
+	}
+
+	// Deleting the attribute clears it from products.
+	post("/attributes/"+element.ID+"/delete", nil)
+	database.DB.Model(&models.ProductAttribute{}).Where("attribute_id = ?", element.ID).Count(&count)
+	if count != 0 {
+		t.Error("expected the attribute's product rows deleted")
+	}
+
+	// Editing a product replaces its tags.
+	post("/products/"+bracelet.ID, url.Values{
+		"name": {bracelet.Name}, "sku": {"VT-1"}, "original_price": {"500000"}, "stock": {"5"},
+		"category_id": {cat.ID}, "is_active": {"on"}, "tags": {"phong thủy"},
+	})
+	var saved models.Product
+	database.DB.Preload("Tags").First(&saved, "id = ?", bracelet.ID)
+	if len(saved.Tags) != 1 || saved.Tags[0].Slug != "phong-thuy" {
+		t.Errorf("expected the tags replaced, got %+v", saved.Tags)
+	}
+}
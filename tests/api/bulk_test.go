@@ -0,0 +1,243 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/tests/testutil"
+
+	"gorm.io/gorm"
+)
+
+func createBulkProducts(t *testing.T, categoryID string, n int) []models.Product {
+	t.Helper()
+	products := make([]models.Product, n)
+	for i := range products {
+		products[i] = models.Product{
+			Name:          fmt.Sprintf("Bulk Product %d", i),
+			Slug:          fmt.Sprintf("bulk-product-%d", i),
+			OriginalPrice: 100000,
+			SalePrice:     80000,
+			SKU:           fmt.Sprintf("BULK-%03d", i),
+			Stock:         i,
+			CategoryID:    categoryID,
+			IsActive:      true,
+		}
+		database.DB.Create(&products[i])
+	}
+	return products
+}
+
+// addProductData gives productID a row in every table that refers to it
+// and only matters while it is for sale.
+func addProductData(t *testing.T, productID, otherID string) {
+	t.Helper()
+	tag := models.Tag{Name: "Tag " + productID, Slug: "tag-" + productID}
+	database.DB.Create(&tag)
+	rows := []any{
+		&models.ProductTranslation{ProductID: productID, Locale: "en", Name: "Translated", Slug: "translated-" + productID},
+		&models.SlugRedirect{Kind: models.SlugProduct, Locale: "vi", Slug: "old-" + productID, TargetID: productID},
+		&models.ProductAttribute{ProductID: productID, AttributeID: "attr", Text: "Ngọc bích"},
+		&models.Wishlist{UserID: "user-" + productID, ProductID: productID},
+		&models.StockSubscription{ProductID: productID, Email: "a@test.com", Token: "token-" + productID},
+		&models.Notification{Email: "a@test.com", Kind: models.NotifyRestock, ProductID: productID},
+		&models.RecentView{UserID: "user-" + productID, ProductID: productID, ViewedAt: time.Now()},
+		&models.ProductView{ProductID: productID, Day: time.Now().UTC().Truncate(24 * time.Hour), Views: 3},
+		&models.ProductAffinity{ProductID: otherID, RelatedID: productID, Orders: 2, Score: 1},
+		&models.SEOBanner{Page: models.SEOProductPage(productID), Title: "Override",
+			Translations: []models.SEOBannerTranslation{{Locale: "en", Title: "Override " + productID}}},
+	}
+	for _, row := range rows {
+		if err := database.DB.Create(row).Error; err != nil {
+			t.Fatalf("create %T: %v", row, err)
+		}
+	}
+	database.DB.Exec("INSERT INTO product_tags (product_id, tag_id) VALUES (?, ?)", productID, tag.ID)
+}
+
+// productLeftovers names the tables still holding rows for productID.
+func productLeftovers(productID string) []string {
+	var left []string
+	count := func(name string, q *gorm.DB) {
+		var n int64
+		q.Count(&n)
+		if n > 0 {
+			left = append(left, name)
+		}
+	}
+	db := database.DB.Unscoped().Session(&gorm.Session{})
+	count("translations", db.Model(&models.ProductTranslation{}).Where("product_id = ?", productID))
+	count("slug redirects", db.Model(&models.SlugRedirect{}).Where("target_id = ?", productID))
+	count("attributes", db.Model(&models.ProductAttribute{}).Where("product_id = ?", productID))
+	count("wishlists", db.Model(&models.Wishlist{}).Where("product_id = ?", productID))
+	count("stock subscriptions", db.Model(&models.StockSubscription{}).Where("product_id = ?", productID))
+	count("notifications", db.Model(&models.Notification{}).Where("product_id = ?", productID))
+	count("recent views", db.Model(&models.RecentView{}).Where("product_id = ?", productID))
+	count("product views", db.Model(&models.ProductView{}).Where("product_id = ?", productID))
+	count("affinities", db.Model(&models.ProductAffinity{}).Where("product_id = ? OR related_id = ?", productID, productID))
+	count("tags", db.Table("product_tags").Where("product_id = ?", productID))
+	count("SEO entries", db.Model(&models.SEOBanner{}).Where("page = ?", models.SEOProductPage(productID)))
+	count("SEO translations", db.Model(&models.SEOBannerTranslation{}).Where("title = ?", "Override "+productID))
+	return left
+}
+
+func TestAdminProducts_ListFilters(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	cat := testutil.CreateTestCategory(t)
+	createBulkProducts(t, cat.ID, 25)
+
+	e := testutil.NewAdminEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+
+	cookies := testutil.AdminLoginCookies(t, ts)
+	for _, q := range []string{
+		"?page=2",
+		"?page=99",
+		"?q=BULK-001",
+		"?category_id=" + cat.ID + "&active=1&featured=0",
+		"?stock=out",
+		"?stock=low",
+		"?stock=in",
+	} {
+		resp, err := testutil.GetWithCookies(ts, "/products"+q, cookies)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", q, resp.StatusCode)
+		}
+	}
+}
+
+func TestAdminProducts_BulkActions(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	cat := testutil.CreateTestCategory(t)
+	other := models.Category{Name: "Other", Slug: "other", IsActive: true}
+	database.DB.Create(&other)
+	products := createBulkProducts(t, cat.ID, 3)
+	selected := []string{products[0].ID, products[1].ID}
+
+	e := testutil.NewAdminEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+
+	cookies := testutil.AdminLoginCookies(t, ts)
+	bulk := func(values url.Values) {
+		t.Helper()
+		values["ids"] = selected
+		values.Set("return", "?page=1")
+		resp, err := testutil.PostForm(ts, "/products/bulk", cookies, values)
+		if err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusFound {
+			t.Errorf("expected 302, got %d", resp.StatusCode)
+		}
+		if loc := resp.Header.Get("Location"); loc != "/products?page=1" {
+			t.Errorf("expected Location /products?page=1, got %s", loc)
+		}
+	}
+	reload := func() []models.Product {
+		var got []models.Product
+		database.DB.Order("sku ASC").Find(&got)
+		return got
+	}
+
+	t.Run("deactivate", func(t *testing.T) {
+		bulk(url.Values{"action": {"deactivate"}})
+		got := reload()
+		if got[0].IsActive || got[1].IsActive || !got[2].IsActive {
+			t.Errorf("unexpected active flags: %v %v %v", got[0].IsActive, got[1].IsActive, got[2].IsActive)
+		}
+	})
+
+	t.Run("feature", func(t *testing.T) {
+		bulk(url.Values{"action": {"feature"}})
+		got := reload()
+		if !got[0].IsFeatured || !got[1].IsFeatured || got[2].IsFeatured {
+			t.Errorf("unexpected featured flags: %v %v %v", got[0].IsFeatured, got[1].IsFeatured, got[2].IsFeatured)
+		}
+	})
+
+	t.Run("move_category", func(t *testing.T) {
+		bulk(url.Values{"action": {"move_category"}, "category_id": {other.ID}})
+		got := reload()
+		if got[0].CategoryID != other.ID || got[2].CategoryID != cat.ID {
+			t.Errorf("unexpected categories: %s %s", got[0].CategoryID, got[2].CategoryID)
+		}
+	})
+
+	t.Run("adjust_price", func(t *testing.T) {
+		bulk(url.Values{"action": {"adjust_price"}, "percent": {"-10"}})
+		got := reload()
+		if got[0].OriginalPrice != 90000 || got[0].SalePrice != 72000 {
+			t.Errorf("expected 90000/72000, got %v/%v", got[0].OriginalPrice, got[0].SalePrice)
+		}
+		if got[2].OriginalPrice != 100000 {
+			t.Errorf("unselected product price changed to %v", got[2].OriginalPrice)
+		}
+	})
+
+	t.Run("adjust_price_invalid_is_rolled_back", func(t *testing.T) {
+		bulk(url.Values{"action": {"adjust_price"}, "percent": {"-150"}})
+		got := reload()
+		if got[0].OriginalPrice != 90000 {
+			t.Errorf("expected price unchanged at 90000, got %v", got[0].OriginalPrice)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		addProductData(t, products[0].ID, products[2].ID)
+		addProductData(t, products[2].ID, products[1].ID)
+		bulk(url.Values{"action": {"delete"}})
+		var count int64
+		database.DB.Model(&models.Product{}).Count(&count)
+		if count != 1 {
+			t.Errorf("expected 1 product left, got %d", count)
+		}
+		if left := productLeftovers(products[0].ID); len(left) > 0 {
+			t.Errorf("expected the deleted product's data removed, left %v", left)
+		}
+		// The kept product loses only its affinity with a deleted one.
+		if left := productLeftovers(products[2].ID); len(left) != 11 {
+			t.Errorf("expected the kept product's data left alone, got %v", left)
+		}
+	})
+}
+
+func TestAdminProducts_BulkNoSelection(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+
+	e := testutil.NewAdminEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+
+	cookies := testutil.AdminLoginCookies(t, ts)
+	resp, err := testutil.PostForm(ts, "/products/bulk", cookies, url.Values{"action": {"delete"}})
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("expected 302, got %d", resp.StatusCode)
+	}
+	if loc := resp.Header.Get("Location"); loc != "/products" {
+		t.Errorf("expected Location /products, got %s", loc)
+	}
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/tests/testutil"
+)
+
+func TestCategoryTree(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+
+	admin := httptest.NewServer(testutil.NewAdminEcho())
+	defer admin.Close()
+	cookies := testutil.AdminLoginCookies(t, admin)
+	web := httptest.NewServer(testutil.NewWebRenderedEcho())
+	defer web.Close()
+
+	post := func(path string, values url.Values) *http.Response {
+		t.Helper()
+		resp, err := testutil.PostForm(admin, path, cookies, values)
+		if err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	category := func(name, parentID string) models.Category {
+		t.Helper()
+		post("/categories", url.Values{"name": {name}, "parent_id": {parentID}, "is_active": {"on"}})
+		var cat models.Category
+		if err := database.DB.First(&cat, "name = ?", name).Error; err != nil {
+			t.Fatalf("category %q not created", name)
+		}
+		return cat
+	}
+	parentOf := func(id string) string {
+		var cat models.Category
+		database.DB.First(&cat, "id = ?", id)
+		if cat.ParentID == nil {
+			return ""
+		}
+		return *cat.ParentID
+	}
+	get := func(path string) string {
+		t.Helper()
+		resp, err := testutil.GetWithCookies(web, path, nil)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	bracelets := category("Vòng tay", "")
+	quartz := category("Thạch anh", bracelets.ID)
+	amethyst := category("Thạch anh tím", quartz.ID)
+	other := category("Tượng", "")
+
+	// A fourth level, a cycle and an unknown parent are refused.
+	post("/categories", url.Values{"name": {"Quá sâu"}, "parent_id": {amethyst.ID}, "is_active": {"on"}})
+	var count int64
+	database.DB.Model(&models.Category{}).Where("name = ?", "Quá sâu").Count(&count)
+	if count != 0 {
+		t.Error("expected a fourth level refused")
+	}
+	post("/categories/"+bracelets.ID, url.Values{"name": {"Vòng tay"}, "parent_id": {amethyst.ID}, "is_active": {"on"}})
+	if parentOf(bracelets.ID) != "" {
+		t.Error("expected a cycle refused")
+	}
+	post("/categories/"+other.ID, url.Values{"name": {"Tượng"}, "parent_id": {"missing"}, "is_active": {"on"}})
+	if parentOf(other.ID) != "" {
+		t.Error("expected an unknown parent refused")
+	}
+	// Moving a two-level subtree under a top-level category fits; under
+	// a second-level one it would not.
+	post("/categories/"+quartz.ID, url.Values{"name": {"Thạch anh"}, "parent_id": {other.ID}, "is_active": {"on"}})
+	if parentOf(quartz.ID) != other.ID {
+		t.Error("expected the subtree moved")
+	}
+	post("/categories/"+bracelets.ID, url.Values{"name": {"Vòng tay"}, "parent_id": {other.ID}, "is_active": {"on"}})
+	post("/categories/"+quartz.ID, url.Values{"name": {"Thạch anh"}, "parent_id": {bracelets.ID}, "is_active": {"on"}})
+	if parentOf(quartz.ID) != other.ID {
+		t.Error("expected a move past the depth limit refused")
+	}
+	post("/categories/"+quartz.ID, url.Values{"name": {"Thạch anh"}, "parent_id": {""}, "is_active": {"on"}})
+	post("/categories/"+bracelets.ID, url.Values{"name": {"Vòng tay"}, "parent_id": {""}, "is_active": {"on"}})
+	post("/categories/"+quartz.ID, url.Values{"name": {"Thạch anh"}, "parent_id": {bracelets.ID}, "is_active": {"on"}})
+	if parentOf(quartz.ID) != bracelets.ID || parentOf(bracelets.ID) != "" {
+		t.Fatal("expected the original tree restored")
+	}
+
+	top := testutil.CreateTestProduct(t, bracelets.ID)
+	deep := models.Product{Name: "Vòng thạch anh tím", Slug: "vong-thach-anh-tim", OriginalPrice: 500000, Stock: 3, CategoryID: amethyst.ID, IsActive: true}
+	database.DB.Create(&deep)
+	elsewhere := models.Product{Name: "Tượng Di Lặc", Slug: "tuong-di-lac", OriginalPrice: 900000, Stock: 3, CategoryID: other.ID, IsActive: true}
+	database.DB.Create(&elsewhere)
+
+	// A category lists its descendants' products.
+	body := get("/products?category=" + bracelets.Slug)
+	if !strings.Contains(body, top.Name) || !strings.Contains(body, deep.Name) || strings.Contains(body, elsewhere.Name) {
+		t.Error("expected the top category to list its own and its subcategories' products only")
+	}
+	body = get("/products?category=" + quartz.Slug)
+	if strings.Contains(body, top.Name) || !strings.Contains(body, deep.Name) {
+		t.Error("expected a subcategory to leave out its parent's products")
+	}
+
+	// Breadcrumbs and the mega-menu.
+	body = get("/products?category=" + amethyst.Slug)
+	for _, want := range []string{
+		`<a href="/products?category=` + bracelets.Slug + `" class="hover:text-feng-gold">Vòng tay</a>`,
+		`<a href="/products?category=` + quartz.Slug + `" class="hover:text-feng-gold">Thạch anh</a>`,
+		`<span class="text-feng-earth-dark">Thạch anh tím</span>`,
+		"Xem tất cả Vòng tay",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q on the category page", want)
+		}
+	}
+	body = get("/products/" + deep.Slug)
+	if !strings.Contains(body, `<a href="/products?category=`+quartz.Slug+`" class="hover:text-feng-gold">Thạch anh</a>`) || !strings.Contains(body, `"name":"Thạch anh","position":4`) {
+		t.Error("expected the category path in the product breadcrumbs")
+	}
+
+	// Deleting a category moves its children up.
+	post("/categories/"+quartz.ID+"/delete", nil)
+	if parentOf(amethyst.ID) != bracelets.ID {
+		t.Errorf("expected the child moved to the grandparent, got %q", parentOf(amethyst.ID))
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/tests/testutil"
+)
+
+func TestAdminExchangeRates(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+
+	e := testutil.NewAdminRenderedEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	cookies := testutil.AdminLoginCookies(t, ts)
+
+	resp, err := testutil.GetWithCookies(ts, "/exchange-rates", cookies)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "rate_USD") || !strings.Contains(string(body), "rate_EUR") {
+		t.Fatalf("expected a rate field per currency, got %d", resp.StatusCode)
+	}
+
+	post := func(values url.Values) {
+		t.Helper()
+		resp, err := testutil.PostForm(ts, "/exchange-rates", cookies, values)
+		if err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	post(url.Values{"rate_USD": {"25.400"}, "active_USD": {"on"}, "rate_EUR": {""}, "active_EUR": {"on"}})
+
+	var usd, eur models.ExchangeRate
+	database.DB.First(&usd, "currency = ?", "USD")
+	database.DB.First(&eur, "currency = ?", "EUR")
+	if usd.Rate != 25400 || !usd.IsActive {
+		t.Errorf("expected USD at 25400 and active, got %+v", usd)
+	}
+	if eur.IsActive {
+		t.Error("expected EUR without a rate to stay hidden")
+	}
+
+	post(url.Values{"rate_USD": {"abc"}, "active_USD": {"on"}})
+	database.DB.First(&usd, "currency = ?", "USD")
+	if usd.Rate != 25400 {
+		t.Errorf("expected an invalid rate to be rejected, got %v", usd.Rate)
+	}
+}
+
+func TestWebCurrencySwitch(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	database.DB.Create(&[]models.ExchangeRate{
+		{Currency: "USD", Rate: 25000, IsActive: true},
+		{Currency: "EUR", Rate: 27500},
+	})
+
+	e := testutil.NewWebRenderedEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	client := shippingClient(t, ts, prod, "2")
+
+	get := func(path string) string {
+		t.Helper()
+		resp, err := client.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("get %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	if body := get("/products/" + prod.Slug); !strings.Contains(body, "80.000₫") || !strings.Contains(body, "/currency/USD") ||
+		strings.Contains(body, "/currency/EUR") {
+		t.Error("expected VND prices and only active currencies in the switcher")
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/currency/USD", nil)
+	req.Header.Set("Referer", "http://evil.example/products?q=a")
+	noFollow := &http.Client{Jar: client.Jar, CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := noFollow.Do(req)
+	if err != nil {
+		t.Fatalf("switch failed: %v", err)
+	}
+	resp.Body.Close()
+	if loc := resp.Header.Get("Location"); loc != "/products?q=a" {
+		t.Errorf("expected to return to the local path, got %q", loc)
+	}
+
+	if body := get("/products/" + prod.Slug); !strings.Contains(body, "$3.20") || !strings.Contains(body, "$4.00") {
+		t.Error("expected prices converted to USD")
+	}
+	if body := get("/cart"); !strings.Contains(body, "$6.40") || !strings.Contains(body, "thanh toán bằng VND") {
+		t.Error("expected the cart in USD with a note that payment is in VND")
+	}
+
+	status, _ := postCheckout(t, client, ts, map[string]string{"name": "A", "phone": "1", "address": "x"})
+	if status != http.StatusOK {
+		t.Fatalf("expected checkout to succeed, got %d", status)
+	}
+	var order models.Order
+	database.DB.First(&order)
+	if order.Currency != "VND" || order.TotalAmount != 160000 || order.DisplayCode != "USD" || order.DisplayRate != 25000 {
+		t.Errorf("expected a VND order recording the USD rate, got %s %d (%s %v)", order.Currency, order.TotalAmount, order.DisplayCode, order.DisplayRate)
+	}
+
+	get("/currency/EUR")
+	if body := get("/products/" + prod.Slug); !strings.Contains(body, "80.000₫") {
+		t.Error("expected a currency without an active rate to fall back to VND")
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/fengshui"
+	"shoop-golang/tests/testutil"
+)
+
+func TestElementRecommendations(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+
+	element := models.Attribute{Name: "Ngũ hành", Code: models.ElementAttribute, Type: models.AttributeMulti, Values: []models.AttributeValue{
+		{Name: "Kim", Slug: fengshui.Kim}, {Name: "Mộc", Slug: fengshui.Moc}, {Name: "Thủy", Slug: fengshui.Thuy},
+		{Name: "Hỏa", Slug: fengshui.Hoa}, {Name: "Thổ", Slug: fengshui.Tho},
+	}}
+	database.DB.Create(&element)
+	valueIDs := map[string]string{}
+	for _, v := range element.Values {
+		valueIDs[v.Slug] = v.ID
+	}
+	product := func(name, slug string, elements ...string) models.Product {
+		p := models.Product{Name: name, Slug: slug, SKU: slug, OriginalPrice: 300000, Stock: 5, CategoryID: cat.ID, IsActive: true}
+		database.DB.Create(&p)
+		for _, e := range elements {
+			id := valueIDs[e]
+			database.DB.Create(&models.ProductAttribute{ProductID: p.ID, AttributeID: element.ID, ValueID: &id})
+		}
+		return p
+	}
+	fire := product("Vòng thạch anh đỏ", "vong-thach-anh-do", fengshui.Hoa)
+	earth := product("Tượng Tỳ Hưu ngọc vàng", "ty-huu-ngoc-vang", fengshui.Tho, fengshui.Kim)
+	wood := product("Cây tài lộc", "cay-tai-loc", fengshui.Moc)
+	plain := product("Hộp quà", "hop-qua")
+
+	web := httptest.NewServer(testutil.NewWebRenderedEcho())
+	defer web.Close()
+	get := func(path string, cookies []*http.Cookie) string {
+		t.Helper()
+		resp, err := testutil.GetWithCookies(web, path, cookies)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	// 1990 is Canh Ngọ, Lộ Bàng Thổ: earth, fed by fire.
+	body := get("/products?birth_year=1990&gender=male", nil)
+	for _, want := range []string{"Canh Ngọ", "Lộ Bàng Thổ", "Khảm", fire.Name, earth.Name} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q for 1990", want)
+		}
+	}
+	for _, notWant := range []string{wood.Name, plain.Name} {
+		if strings.Contains(body, notWant) {
+			t.Errorf("did not expect %q for 1990", notWant)
+		}
+	}
+	body = get("/products?birth_year=1850", nil)
+	if !strings.Contains(body, "Năm sinh phải trong khoảng 1900–2100") || !strings.Contains(body, plain.Name) {
+		t.Error("expected an out of range year reported and ignored")
+	}
+
+	// Saving the profile personalizes the home page.
+	cookies := testutil.WebLoginCookies(t, web)
+	if body := get("/", cookies); strings.Contains(body, "Hợp mệnh") {
+		t.Error("did not expect element suggestions before saving a profile")
+	}
+	resp, err := testutil.PostForm(web, "/account/element", cookies, url.Values{"birth_year": {"1984"}, "gender": {"female"}})
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	resp.Body.Close()
+	if loc := resp.Header.Get("Location"); loc != "/products?birth_year=1984&gender=female" {
+		t.Errorf("expected a redirect to the matching products, got %q", loc)
+	}
+	var saved models.User
+	database.DB.First(&saved, "id = ?", user.ID)
+	if saved.BirthYear != 1984 || saved.Gender != fengshui.Female {
+		t.Fatalf("expected the profile saved, got %d %q", saved.BirthYear, saved.Gender)
+	}
+	// 1984 is Hải Trung Kim: metal, fed by earth.
+	body = get("/", cookies)
+	if !strings.Contains(body, "Hợp mệnh Kim của bạn") || !strings.Contains(body, earth.Name) {
+		t.Error("expected the home page to suggest products for metal")
+	}
+	if !strings.Contains(body, `href="/products?birth_year=1984&amp;gender=female"`) {
+		t.Error("expected a link to all matching products")
+	}
+	if body := get("/products", cookies); !strings.Contains(body, `name="birth_year" min="1900" max="2100" required value="1984"`) {
+		t.Error("expected the saved year filled in on the product list")
+	}
+
+	resp, _ = testutil.PostForm(web, "/account/element", cookies, url.Values{"birth_year": {""}})
+	resp.Body.Close()
+	database.DB.First(&saved, "id = ?", user.ID)
+	if saved.BirthYear != 0 || saved.Gender != "" {
+		t.Error("expected an empty year to clear the profile")
+	}
+}
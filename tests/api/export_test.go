@@ -0,0 +1,211 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/tests/testutil"
+)
+
+func TestAdminProductExport_CSV(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	database.DB.Create(&models.Image{ProductID: prod.ID, URL: "/uploads/products/a.jpg", IsPrimary: true})
+
+	e := testutil.NewAdminEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+
+	cookies := testutil.AdminLoginCookies(t, ts)
+	resp, err := testutil.GetWithCookies(ts, "/products/export?format=csv", cookies)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("expected text/csv content type, got %s", ct)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(body), "\xEF\xBB\xBF"))).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected header + 1 row, got %d rows", len(records))
+	}
+	if records[0][1] != "sku" {
+		t.Errorf("expected sku header, got %q", records[0][1])
+	}
+	row := records[1]
+	if row[1] != "TEST-001" || row[4] != "Test Category" || row[10] != "/uploads/products/a.jpg" {
+		t.Errorf("unexpected export row: %v", row)
+	}
+}
+
+func TestAdminProductExport_JSON(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	cat := testutil.CreateTestCategory(t)
+	testutil.CreateTestProduct(t, cat.ID)
+
+	e := testutil.NewAdminEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+
+	cookies := testutil.AdminLoginCookies(t, ts)
+	resp, err := testutil.GetWithCookies(ts, "/products/export?format=json", cookies)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	var rows []map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 product, got %d", len(rows))
+	}
+	if rows[0]["category"] != "Test Category" {
+		t.Errorf("expected category Test Category, got %v", rows[0]["category"])
+	}
+	if rows[0]["sale_price"] != float64(80000) {
+		t.Errorf("expected sale_price 80000, got %v", rows[0]["sale_price"])
+	}
+}
+
+func TestAdminProductExport_Unauthenticated(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+
+	e := testutil.NewAdminEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+
+	resp, err := testutil.GetWithCookies(ts, "/products/export", nil)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("expected 302, got %d", resp.StatusCode)
+	}
+}
+
+func TestWebGoogleMerchantFeed(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	database.DB.Create(&models.Image{ProductID: prod.ID, URL: "/uploads/products/a.jpg", IsPrimary: true})
+	outOfStock := models.Product{
+		Name: "Sold Out", Slug: "sold-out", OriginalPrice: 50000, SKU: "TEST-002",
+		Stock: 0, CategoryID: cat.ID, IsActive: true,
+	}
+	database.DB.Create(&outOfStock)
+
+	e := testutil.NewWebEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/feeds/google.xml")
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if cc := resp.Header.Get("Cache-Control"); !strings.Contains(cc, "public") {
+		t.Errorf("expected public Cache-Control, got %q", cc)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	feed := string(body)
+	for _, want := range []string{
+		`xmlns:g="http://base.google.com/ns/1.0"`,
+		"<g:id>TEST-001</g:id>",
+		"<g:price>100000 VND</g:price>",
+		"<g:sale_price>80000 VND</g:sale_price>",
+		"<g:availability>in_stock</g:availability>",
+		"<g:availability>out_of_stock</g:availability>",
+		"<g:image_link>" + testutil.BaseURL + "/uploads/products/a.jpg</g:image_link>",
+		"<g:link>" + testutil.BaseURL + "/products/test-product</g:link>",
+	} {
+		if !strings.Contains(feed, want) {
+			t.Errorf("feed missing %s", want)
+		}
+	}
+
+	forged, _ := http.NewRequest("GET", ts.URL+"/feeds/google.xml", nil)
+	forged.Host = "evil.example"
+	spoofed, err := http.DefaultClient.Do(forged)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	body, _ = io.ReadAll(spoofed.Body)
+	spoofed.Body.Close()
+	if strings.Contains(string(body), "evil.example") {
+		t.Error("expected feed links from the configured base URL, not the Host header")
+	}
+
+	req, _ := http.NewRequest("GET", ts.URL+"/feeds/google.xml", nil)
+	req.Header.Set("If-None-Match", resp.Header.Get("ETag"))
+	resp2, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("conditional get failed: %v", err)
+	}
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusNotModified {
+		t.Errorf("expected 304 for matching ETag, got %d", resp2.StatusCode)
+	}
+
+	// Whatever the feed shows invalidates the tag, not only product rows.
+	etag := resp.Header.Get("ETag")
+	for _, tt := range []struct {
+		name       string
+		change     func()
+		want, gone string
+	}{
+		{"company info", func() { database.DB.Create(&models.CompanyInfo{Name: "Đá Quý OCC"}) }, "<title>Đá Quý OCC</title>", ""},
+		{"category name", func() { database.DB.Model(&cat).Update("name", "Tỳ Hưu Ngọc") }, "<g:product_type>Tỳ Hưu Ngọc</g:product_type>", ""},
+		{"restock", func() { database.DB.Model(&outOfStock).UpdateColumns(models.StockChange(2)) }, "in_stock", "out_of_stock"},
+	} {
+		tt.change()
+		req, _ := http.NewRequest("GET", ts.URL+"/feeds/google.xml", nil)
+		req.Header.Set("If-None-Match", etag)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("conditional get failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), tt.want) ||
+			(tt.gone != "" && strings.Contains(string(body), tt.gone)) {
+			t.Errorf("%s: expected a fresh feed with %q, got %d\n%s", tt.name, tt.want, resp.StatusCode, body)
+		}
+		etag = resp.Header.Get("ETag")
+	}
+}
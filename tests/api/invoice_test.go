@@ -0,0 +1,234 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/tests/testutil"
+)
+
+func getPDF(t *testing.T, resp *http.Response, err error) []byte {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/pdf" {
+		t.Fatalf("expected application/pdf, got %s", ct)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	if !strings.HasPrefix(string(b), "%PDF-") {
+		t.Fatal("expected a PDF document")
+	}
+	return b
+}
+
+// pdfText decodes the text drawn in a PDF from pkg/pdf, which shows glyph
+// IDs: the ToUnicode map of each font, in font order, turns them back into
+// characters. Each Tj becomes a line.
+func pdfText(t *testing.T, b []byte) string {
+	t.Helper()
+	var maps []map[string]string
+	for _, cmap := range regexp.MustCompile(`(?s)begincmap(.*?)endcmap`).FindAllSubmatch(b, -1) {
+		m := map[string]string{}
+		for _, e := range regexp.MustCompile(`<([0-9A-F]{4})> <([0-9A-F]+)>`).FindAllSubmatch(cmap[1], -1) {
+			var units []uint16
+			for i := 0; i+4 <= len(e[2]); i += 4 {
+				u, _ := strconv.ParseUint(string(e[2][i:i+4]), 16, 16)
+				units = append(units, uint16(u))
+			}
+			m[string(e[1])] = string(utf16.Decode(units))
+		}
+		maps = append(maps, m)
+	}
+	var text strings.Builder
+	for _, tj := range regexp.MustCompile(`/F(\d) [\d.]+ Tf [\d.]+ [\d.]+ Td <([0-9A-F]*)> Tj`).FindAllSubmatch(b, -1) {
+		n, _ := strconv.Atoi(string(tj[1]))
+		if n < 1 || n > len(maps) {
+			t.Fatalf("text set in unknown font F%d", n)
+		}
+		for i := 0; i+4 <= len(tj[2]); i += 4 {
+			text.WriteString(maps[n-1][string(tj[2][i:i+4])])
+		}
+		text.WriteByte('\n')
+	}
+	return text.String()
+}
+
+func TestAdminOrderExport_CSVFilters(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+
+	pending := testutil.CreateTestOrder(t, user.ID, prod.ID)
+	delivered := testutil.CreateTestOrder(t, user.ID, prod.ID)
+	database.DB.Model(&delivered).Update("status", "delivered")
+	old := testutil.CreateTestOrder(t, user.ID, prod.ID)
+	database.DB.Model(&old).Update("created_at", time.Now().AddDate(0, -3, 0))
+
+	e := testutil.NewAdminEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	cookies := testutil.AdminLoginCookies(t, ts)
+
+	from := time.Now().AddDate(0, 0, -7).Format("2006-01-02")
+	resp, err := testutil.GetWithCookies(ts, "/orders/export?status=pending&from="+from, cookies)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("expected text/csv content type, got %s", ct)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(body), "\xEF\xBB\xBF"))).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected header + 1 row, got %d rows", len(records))
+	}
+	row := records[1]
+	if row[0] != pending.Number || row[1] != pending.ID || row[4] != "pending" || row[6] != user.Email {
+		t.Errorf("unexpected export row: %v", row)
+	}
+	if row[17] != "Test Product x1" {
+		t.Errorf("expected item summary, got %q", row[17])
+	}
+}
+
+func TestAdminOrderInvoice_NumberIssuedOnce(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	first := testutil.CreateTestOrder(t, user.ID, prod.ID)
+	second := testutil.CreateTestOrder(t, user.ID, prod.ID)
+
+	e := testutil.NewAdminEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	cookies := testutil.AdminLoginCookies(t, ts)
+
+	resp, err := testutil.GetWithCookies(ts, "/orders/"+first.ID+"/invoice", cookies)
+	getPDF(t, resp, err)
+	resp, err = testutil.GetWithCookies(ts, "/orders/"+first.ID+"/invoice", cookies)
+	getPDF(t, resp, err)
+	resp, err = testutil.GetWithCookies(ts, "/orders/"+second.ID+"/invoice", cookies)
+	getPDF(t, resp, err)
+
+	var invoices []models.Invoice
+	database.DB.Order("number ASC").Find(&invoices)
+	if len(invoices) != 2 {
+		t.Fatalf("expected 2 invoices, got %d", len(invoices))
+	}
+	year := time.Now().Year()
+	if invoices[0].OrderID != first.ID || invoices[0].Number != fmt.Sprintf("INV-%d-000001", year) {
+		t.Errorf("unexpected first invoice: %+v", invoices[0])
+	}
+	if invoices[1].OrderID != second.ID || invoices[1].Number != fmt.Sprintf("INV-%d-000002", year) {
+		t.Errorf("unexpected second invoice: %+v", invoices[1])
+	}
+}
+
+func TestAdminOrderDocuments_PackingSlipAndBatch(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	a := testutil.CreateTestOrder(t, user.ID, prod.ID)
+	b := testutil.CreateTestOrder(t, user.ID, prod.ID)
+
+	e := testutil.NewAdminEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	cookies := testutil.AdminLoginCookies(t, ts)
+
+	resp, err := testutil.GetWithCookies(ts, "/orders/"+a.ID+"/packing-slip", cookies)
+	getPDF(t, resp, err)
+
+	var count int64
+	database.DB.Model(&models.Invoice{}).Count(&count)
+	if count != 0 {
+		t.Errorf("packing slip must not issue an invoice, got %d", count)
+	}
+
+	resp, err = testutil.PostForm(ts, "/orders/documents", cookies, url.Values{
+		"type": {"invoice"},
+		"ids":  {a.ID, b.ID},
+	})
+	pdf := getPDF(t, resp, err)
+	if !strings.Contains(string(pdf), "/Count 2") {
+		t.Error("expected one page per order in the batch PDF")
+	}
+	database.DB.Model(&models.Invoice{}).Count(&count)
+	if count != 2 {
+		t.Errorf("expected invoices issued for both orders, got %d", count)
+	}
+}
+
+func TestAdminOrderDocuments_OrderLocale(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	vi := testutil.CreateTestOrder(t, user.ID, prod.ID)
+	en := testutil.CreateTestOrder(t, user.ID, prod.ID)
+	database.DB.Model(&en).Update("locale", "en")
+	database.DB.Create(&models.CompanyInfo{Name: "Đá Quý Phong Thủy"})
+
+	e := testutil.NewAdminEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	cookies := testutil.AdminLoginCookies(t, ts)
+
+	resp, err := testutil.GetWithCookies(ts, "/orders/"+vi.ID+"/invoice", cookies)
+	text := pdfText(t, getPDF(t, resp, err))
+	for _, want := range []string{"HÓA ĐƠN", "Khách hàng / Giao đến", "Tổng cộng", "Test Product", "Cảm ơn quý khách đã mua hàng tại Đá Quý Phong Thủy"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q on the Vietnamese invoice, got:\n%s", want, text)
+		}
+	}
+
+	resp, err = testutil.GetWithCookies(ts, "/orders/"+en.ID+"/invoice", cookies)
+	text = pdfText(t, getPDF(t, resp, err))
+	for _, want := range []string{"INVOICE", "Customer / Ship to", "Total", "Thank you for shopping at"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q on the English invoice, got:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "HÓA ĐƠN") {
+		t.Error("did not expect Vietnamese labels on the English invoice")
+	}
+
+	resp, err = testutil.GetWithCookies(ts, "/orders/"+en.ID+"/packing-slip", cookies)
+	text = pdfText(t, getPDF(t, resp, err))
+	if !strings.Contains(text, "PACKING SLIP") || !strings.Contains(text, "Total quantity") {
+		t.Errorf("expected English labels on the packing slip, got:\n%s", text)
+	}
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"shoop-golang/tests/testutil"
+)
+
+func TestWebLocale(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+
+	e := testutil.NewWebRenderedEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	client := shippingClient(t, ts, prod, "1")
+
+	get := func(c *http.Client, path, acceptLanguage string) string {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("get %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("get %s: status %d", path, resp.StatusCode)
+		}
+		return string(body)
+	}
+
+	if body := get(client, "/products/"+prod.Slug, ""); !strings.Contains(body, `lang="vi"`) || !strings.Contains(body, "Thêm vào giỏ") {
+		t.Error("expected Vietnamese by default")
+	}
+	if body := get(client, "/en/products/"+prod.Slug, ""); !strings.Contains(body, `lang="en"`) || !strings.Contains(body, "Add to cart") {
+		t.Error("expected the /en prefix to render English")
+	}
+	if body := get(client, "/cart", ""); !strings.Contains(body, "Place order") {
+		t.Error("expected the prefix to be remembered for later pages")
+	}
+
+	status, out := postCheckout(t, client, ts, map[string]string{"name": "A", "phone": "1", "address": "x", "payment_method": "nope"})
+	if status != http.StatusBadRequest || out["error"] != "Invalid payment method" {
+		t.Errorf("expected an English JSON error, got %d %v", status, out)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/lang/vi", nil)
+	req.Header.Set("Referer", ts.URL+"/en/products?q=a")
+	noFollow := &http.Client{Jar: client.Jar, CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := noFollow.Do(req)
+	if err != nil {
+		t.Fatalf("switch failed: %v", err)
+	}
+	resp.Body.Close()
+	if loc := resp.Header.Get("Location"); loc != "/products?q=a" {
+		t.Errorf("expected to return without the locale prefix, got %q", loc)
+	}
+	if body := get(client, "/cart", ""); !strings.Contains(body, "Đặt hàng") {
+		t.Error("expected Vietnamese after switching back")
+	}
+
+	guest := &http.Client{}
+	if body := get(guest, "/products", "en-US,en;q=0.9"); !strings.Contains(body, `lang="en"`) {
+		t.Error("expected Accept-Language to pick English")
+	}
+	if body := get(guest, "/products", "fr-FR"); !strings.Contains(body, `lang="vi"`) {
+		t.Error("expected unsupported languages to fall back to Vietnamese")
+	}
+}
+
+func TestAdminLocale(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+
+	e := testutil.NewAdminRenderedEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	cookies := testutil.AdminLoginCookies(t, ts)
+
+	resp, err := testutil.GetWithCookies(ts, "/lang/en", cookies)
+	if err != nil {
+		t.Fatalf("switch failed: %v", err)
+	}
+	resp.Body.Close()
+	if loc := resp.Header.Get("Location"); loc != "/dashboard" {
+		t.Errorf("expected a redirect to the dashboard, got %q", loc)
+	}
+	cookies = append(cookies, resp.Cookies()...)
+
+	resp, err = testutil.GetWithCookies(ts, "/orders", cookies)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "Export CSV") || !strings.Contains(string(body), "Log out") {
+		t.Error("expected the admin in English")
+	}
+
+	resp, err = testutil.PostForm(ts, "/exchange-rates", cookies, url.Values{"rate_USD": {"25000"}, "active_USD": {"on"}})
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	resp.Body.Close()
+	// The flash rides in the updated session cookie, which must win over
+	// the one from login.
+	cookies = append(resp.Cookies(), cookies...)
+	resp, err = testutil.GetWithCookies(ts, "/exchange-rates", cookies)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "Exchange rates updated") {
+		t.Error("expected the flash message in English")
+	}
+}
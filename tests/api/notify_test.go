@@ -0,0 +1,193 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/internal/notifications"
+	"shoop-golang/pkg/mail"
+	"shoop-golang/tests/testutil"
+)
+
+func TestStockSubscriptions(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+
+	web := httptest.NewServer(testutil.NewWebRenderedEcho())
+	defer web.Close()
+	cookies := testutil.WebLoginCookies(t, web)
+
+	subscribe := func(cookies []*http.Cookie, email string) {
+		t.Helper()
+		resp, err := testutil.PostForm(web, "/products/"+prod.Slug+"/notify", cookies, url.Values{"email": {email}})
+		if err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+		resp.Body.Close()
+		if loc := resp.Header.Get("Location"); loc != "/products/"+prod.Slug {
+			t.Errorf("expected to return to the product, got %q", loc)
+		}
+	}
+	page := func(cookies []*http.Cookie) string {
+		t.Helper()
+		resp, err := testutil.GetWithCookies(web, "/products/"+prod.Slug, cookies)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	countSubs := func() int64 {
+		var n int64
+		database.DB.Model(&models.StockSubscription{}).Count(&n)
+		return n
+	}
+
+	if strings.Contains(page(nil), "/notify") {
+		t.Error("expected no notify form while in stock")
+	}
+	subscribe(nil, "khach@example.com")
+	if countSubs() != 0 {
+		t.Fatal("expected subscribing to an in-stock product to be refused")
+	}
+
+	database.DB.Model(&prod).Update("stock", 0)
+	if body := page(nil); !strings.Contains(body, `action="/products/`+prod.Slug+`/notify"`) || !strings.Contains(body, `name="email"`) {
+		t.Error("expected the notify form with an email field for guests")
+	}
+	subscribe(nil, "not-an-email")
+	subscribe(nil, " Khach@Example.com ")
+	subscribe(nil, "khach@example.com")
+	subscribe(nil, "la@example.com")
+	subscribe(cookies, "")
+	if countSubs() != 3 {
+		t.Fatalf("expected two guest and a customer subscription, got %d", countSubs())
+	}
+
+	// Guests confirm their address before anything else is sent to it.
+	rec := &mail.Recorder{}
+	mail.SetSender(rec)
+	defer mail.SetSender(mail.Log{})
+	if sent, err := notifications.Deliver(database.DB, "https://shop.test", 10); err != nil || sent != 2 {
+		t.Fatalf("expected a confirmation email for each guest, got %d (%v)", sent, err)
+	}
+	var guest models.StockSubscription
+	database.DB.First(&guest, "email = ?", "khach@example.com")
+	confirm := "/notify/confirm/" + guest.Token
+	if msgs := rec.Sent(); msgs[0].To != "khach@example.com" || !strings.Contains(msgs[0].Body, "https://shop.test"+confirm) {
+		t.Fatalf("expected a confirm link, got %+v", msgs[0])
+	}
+	// Opening the link, as a mail scanner would, only asks.
+	resp, err := testutil.GetWithCookies(web, confirm, nil)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `action="`+confirm+`"`) {
+		t.Errorf("expected a page asking to confirm, got %d", resp.StatusCode)
+	}
+	if database.DB.First(&guest, "id = ?", guest.ID); guest.ConfirmedAt != nil {
+		t.Fatal("expected opening the link not to confirm")
+	}
+	resp, err = testutil.PostForm(web, confirm, nil, nil)
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	resp.Body.Close()
+	if database.DB.First(&guest, "id = ?", guest.ID); guest.ConfirmedAt == nil {
+		t.Fatal("expected the subscription confirmed")
+	}
+	var mine models.StockSubscription
+	database.DB.First(&mine, "email = ?", user.Email)
+	if mine.UserID == nil || *mine.UserID != user.ID || mine.Token == "" {
+		t.Errorf("unexpected customer subscription %+v", mine)
+	}
+	if !strings.Contains(page(cookies), "Chúng tôi sẽ gửi email cho bạn khi sản phẩm có hàng trở lại") {
+		t.Error("expected the customer to see they are subscribed")
+	}
+
+	admin := httptest.NewServer(testutil.NewAdminEcho())
+	defer admin.Close()
+	resp, err = testutil.PostForm(admin, "/products/"+prod.ID, testutil.AdminLoginCookies(t, admin), url.Values{
+		"name":           {prod.Name},
+		"original_price": {"100000"},
+		"sale_price":     {"80000"},
+		"stock":          {"5"},
+		"category_id":    {cat.ID},
+		"is_active":      {"on"},
+	})
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	resp.Body.Close()
+
+	var queued []models.Notification
+	database.DB.Where("status = ?", models.NotificationQueued).Find(&queued)
+	if len(queued) != 2 {
+		t.Fatalf("expected 2 restock emails queued, skipping the unconfirmed guest, got %d", len(queued))
+	}
+	var open int64
+	database.DB.Model(&models.StockSubscription{}).Where("notified_at IS NULL").Count(&open)
+	if open != 1 {
+		t.Errorf("expected only the unconfirmed subscription left open, %d left", open)
+	}
+
+	// khach@example.com already got three emails this hour.
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		database.DB.Create(&models.Notification{Email: "khach@example.com", Kind: models.NotifyRestock, ProductID: prod.ID, Status: models.NotificationSent, SentAt: &now})
+	}
+
+	rec = &mail.Recorder{}
+	mail.SetSender(rec)
+	sent, err := notifications.Deliver(database.DB, "https://shop.test", 10)
+	if err != nil || sent != 1 {
+		t.Fatalf("expected only the customer's email to be sent, got %d (%v)", sent, err)
+	}
+	msgs := rec.Sent()
+	if len(msgs) != 1 || msgs[0].To != user.Email {
+		t.Fatalf("unexpected messages %+v", msgs)
+	}
+	unsubscribe := "https://shop.test/notify/unsubscribe/" + mine.Token
+	if !strings.Contains(msgs[0].Body, unsubscribe) {
+		t.Errorf("expected an unsubscribe link in %q", msgs[0].Body)
+	}
+
+	resp, err = testutil.GetWithCookies(web, "/notify/unsubscribe/"+guest.Token, nil)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || countSubs() != 3 {
+		t.Errorf("expected opening the unsubscribe link only to ask, got %d", resp.StatusCode)
+	}
+	resp, err = testutil.PostForm(web, "/notify/unsubscribe/"+guest.Token, nil, nil)
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("expected 302, got %d", resp.StatusCode)
+	}
+	if countSubs() != 2 {
+		t.Error("expected the guest subscription removed")
+	}
+	var left int64
+	database.DB.Model(&models.Notification{}).Where("status = ?", models.NotificationQueued).Count(&left)
+	if left != 0 {
+		t.Errorf("expected the guest's queued email dropped, %d left", left)
+	}
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/tests/testutil"
+)
+
+func getBody(t *testing.T, ts *httptest.Server, path string, cookies []*http.Cookie) string {
+	t.Helper()
+	resp, err := testutil.GetWithCookies(ts, path, cookies)
+	if err != nil {
+		t.Fatalf("get %s failed: %v", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("get %s: expected 200, got %d", path, resp.StatusCode)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	return string(b)
+}
+
+func TestAdminUsers_ListPagination(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	for i := 1; i <= 25; i++ {
+		database.DB.Create(&models.User{
+			Name:     fmt.Sprintf("Customer %02d", i),
+			Email:    fmt.Sprintf("customer%02d@test.com", i),
+			Password: "x",
+		})
+	}
+
+	e := testutil.NewAdminRenderedEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	cookies := testutil.AdminLoginCookies(t, ts)
+
+	t.Run("last_page", func(t *testing.T) {
+		body := getBody(t, ts, "/users?per_page=10&page=3&sort=email&dir=asc", cookies)
+		if !strings.Contains(body, "Hiển thị 21–25 / 25") {
+			t.Error("expected rows 21-25 of 25")
+		}
+		if !strings.Contains(body, "customer25@test.com") || strings.Contains(body, "customer20@test.com") {
+			t.Error("expected only customers 21-25 on page 3")
+		}
+	})
+
+	t.Run("page_out_of_range_clamps", func(t *testing.T) {
+		body := getBody(t, ts, "/users?per_page=10&page=99", cookies)
+		if !strings.Contains(body, "Hiển thị 21–25 / 25") {
+			t.Error("expected out-of-range page to show the last page")
+		}
+	})
+
+	t.Run("invalid_per_page_uses_default", func(t *testing.T) {
+		body := getBody(t, ts, "/users?per_page=7", cookies)
+		if !strings.Contains(body, "Hiển thị 1–20 / 25") {
+			t.Error("expected default page size of 20")
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		body := getBody(t, ts, "/users?q=customer07", cookies)
+		if !strings.Contains(body, "Hiển thị 1–1 / 1") || !strings.Contains(body, "Customer 07") {
+			t.Error("expected search to match a single customer")
+		}
+	})
+
+	t.Run("sort_desc", func(t *testing.T) {
+		body := getBody(t, ts, "/users?sort=email&dir=desc&per_page=10", cookies)
+		if strings.Index(body, "customer25@test.com") > strings.Index(body, "customer24@test.com") {
+			t.Error("expected customer25 before customer24 when sorting by email desc")
+		}
+	})
+}
+
+func TestAdminOrders_ListDateRange(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+
+	old := testutil.CreateTestOrder(t, user.ID, prod.ID)
+	database.DB.Model(&old).Updates(map[string]any{"created_at": time.Now().AddDate(0, -2, 0), "phone": "0900000001"})
+	recent := testutil.CreateTestOrder(t, user.ID, prod.ID)
+	database.DB.Model(&recent).Update("phone", "0900000002")
+
+	e := testutil.NewAdminRenderedEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	cookies := testutil.AdminLoginCookies(t, ts)
+
+	from := time.Now().AddDate(0, 0, -7).Format("2006-01-02")
+	to := time.Now().Format("2006-01-02")
+	body := getBody(t, ts, "/orders?from="+from+"&to="+to, cookies)
+	if !strings.Contains(body, "Hiển thị 1–1 / 1") {
+		t.Error("expected only the recent order inside the date range")
+	}
+	if !strings.Contains(body, recent.ID[:8]) || strings.Contains(body, old.ID[:8]) {
+		t.Error("expected recent order listed and old order excluded")
+	}
+
+	body = getBody(t, ts, "/orders?q=0900000001", cookies)
+	if !strings.Contains(body, old.ID[:8]) || strings.Contains(body, recent.ID[:8]) {
+		t.Error("expected search by phone to match the old order only")
+	}
+
+	body = getBody(t, ts, "/orders?q="+recent.Number, cookies)
+	if !strings.Contains(body, "Hiển thị 1–1 / 1") || !strings.Contains(body, recent.Number) {
+		t.Error("expected search by order number to match the recent order only")
+	}
+
+	body = getBody(t, ts, "/orders?status=cancelled", cookies)
+	if !strings.Contains(body, "Hiển thị 0–0 / 0") {
+		t.Error("expected no cancelled orders")
+	}
+}
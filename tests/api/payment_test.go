@@ -0,0 +1,270 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/payments"
+	"shoop-golang/tests/testutil"
+)
+
+const testWebhookSecret = "webhook-secret"
+
+func registerTestVietQR() {
+	payments.Register(payments.VietQR{
+		BankBIN:       "970436",
+		BankName:      "Vietcombank",
+		AccountNumber: "0011001234567",
+		AccountName:   "CONG TY OCC",
+		WebhookSecret: testWebhookSecret,
+		OrderPrefix:   models.OrderNumberPrefix,
+	})
+}
+
+func postCheckout(t *testing.T, client *http.Client, ts *httptest.Server, body map[string]string) (int, map[string]any) {
+	t.Helper()
+	b, _ := json.Marshal(body)
+	resp, err := client.Post(ts.URL+"/checkout", "application/json", bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("checkout failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var out map[string]any
+	json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out
+}
+
+func postWebhook(t *testing.T, ts *httptest.Server, provider, secret string, body string) (int, string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/payments/webhook/"+provider, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(payments.SignatureHeader, payments.Sign(secret, []byte(body)))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("webhook failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var out map[string]string
+	json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out["status"]
+}
+
+func TestWebCheckout_Payment(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	registerTestVietQR()
+
+	e := testutil.NewWebEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+
+	t.Run("defaults to cash on delivery", func(t *testing.T) {
+		status, out := postCheckout(t, shippingClient(t, ts, prod, "1"), ts, map[string]string{"name": "A", "phone": "1", "address": "x"})
+		if status != http.StatusOK || out["redirect"] != "/" {
+			t.Fatalf("expected COD checkout back to the store, got %d %v", status, out)
+		}
+		var order models.Order
+		database.DB.Preload("Payments").First(&order, "id = ?", out["order_id"])
+		if order.PaymentMethod != "cod" || order.PaymentStatus != models.PaymentUnpaid {
+			t.Errorf("unexpected payment fields %q / %q", order.PaymentMethod, order.PaymentStatus)
+		}
+		if len(order.Payments) != 1 || order.Payments[0].Status != payments.StatusPending || order.Payments[0].Amount != order.TotalAmount {
+			t.Errorf("expected one pending charge, got %+v", order.Payments)
+		}
+	})
+
+	t.Run("rejects unknown methods", func(t *testing.T) {
+		status, _ := postCheckout(t, shippingClient(t, ts, prod, "1"), ts, map[string]string{"name": "A", "payment_method": "paypal"})
+		if status != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", status)
+		}
+	})
+
+	t.Run("bank transfer shows a QR code", func(t *testing.T) {
+		client := shippingClient(t, ts, prod, "1")
+		status, out := postCheckout(t, client, ts, map[string]string{"name": "A", "phone": "1", "address": "x", "payment_method": "vietqr"})
+		if status != http.StatusOK {
+			t.Fatalf("expected 200, got %d %v", status, out)
+		}
+		number, _ := out["order_number"].(string)
+		if out["redirect"] != "/payments/"+number {
+			t.Errorf("expected redirect to the payment page, got %v", out["redirect"])
+		}
+		payment, _ := out["payment"].(map[string]any)
+		if payment["memo"] != number || !strings.Contains(payment["qr_payload"].(string), number) {
+			t.Errorf("expected the order number in the memo, got %v", payment)
+		}
+
+		resp, err := client.Get(ts.URL + "/payments/" + number + "/qr.png")
+		if err != nil {
+			t.Fatalf("get qr failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "image/png" {
+			t.Errorf("expected a PNG, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+
+		resp, err = testutil.GetWithCookies(ts, "/payments/"+number+"/qr.png", nil)
+		if err != nil {
+			t.Fatalf("get qr failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusFound {
+			t.Errorf("expected anonymous visitors to be sent to login, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestPaymentWebhook(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	registerTestVietQR()
+
+	e := testutil.NewWebEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+
+	order := testutil.CreateTestOrder(t, user.ID, prod.ID)
+	database.DB.Model(&order).Update("payment_method", "vietqr")
+	// Banks commonly strip the dashes from the memo.
+	notification := func(id string, amount int) string {
+		return fmt.Sprintf(`{"id": %q, "amount": %d, "description": "CK %s"}`, id, amount, strings.ReplaceAll(order.Number, "-", ""))
+	}
+	reload := func() models.Order {
+		var o models.Order
+		database.DB.First(&o, "id = ?", order.ID)
+		return o
+	}
+
+	t.Run("rejects bad signatures", func(t *testing.T) {
+		status, _ := postWebhook(t, ts, "vietqr", "wrong", notification("FT1", 80000))
+		if status != http.StatusUnauthorized {
+			t.Errorf("expected 401, got %d", status)
+		}
+		if status, _ := postWebhook(t, ts, "paypal", testWebhookSecret, "{}"); status != http.StatusNotFound {
+			t.Errorf("expected 404 for unknown provider, got %d", status)
+		}
+		if status, _ := postWebhook(t, ts, "cod", testWebhookSecret, "{}"); status != http.StatusUnauthorized {
+			t.Errorf("expected COD to refuse webhooks, got %d", status)
+		}
+	})
+
+	t.Run("partial transfer leaves the order unpaid", func(t *testing.T) {
+		status, result := postWebhook(t, ts, "vietqr", testWebhookSecret, notification("FT1", 30000))
+		if status != http.StatusOK || result != "recorded" {
+			t.Fatalf("expected recorded, got %d %q", status, result)
+		}
+		if o := reload(); o.PaymentStatus != models.PaymentUnpaid {
+			t.Errorf("expected unpaid, got %q", o.PaymentStatus)
+		}
+	})
+
+	t.Run("settles once the total is covered", func(t *testing.T) {
+		postWebhook(t, ts, "vietqr", testWebhookSecret, notification("FT2", 50000))
+		o := reload()
+		if o.PaymentStatus != models.PaymentPaid || o.PaidAt == nil {
+			t.Errorf("expected paid with timestamp, got %q %v", o.PaymentStatus, o.PaidAt)
+		}
+	})
+
+	t.Run("repeated delivery is idempotent", func(t *testing.T) {
+		status, result := postWebhook(t, ts, "vietqr", testWebhookSecret, notification("FT2", 50000))
+		if status != http.StatusOK || result != "duplicate" {
+			t.Errorf("expected duplicate, got %d %q", status, result)
+		}
+		var count int64
+		database.DB.Model(&models.Payment{}).Where("order_id = ?", order.ID).Count(&count)
+		if count != 2 {
+			t.Errorf("expected 2 recorded transfers, got %d", count)
+		}
+	})
+
+	t.Run("ignores transfers for unknown orders", func(t *testing.T) {
+		body := `{"id": "FT3", "amount": 10000, "description": "tien nha"}`
+		if status, result := postWebhook(t, ts, "vietqr", testWebhookSecret, body); status != http.StatusOK || result != "ignored" {
+			t.Errorf("expected ignored, got %d %q", status, result)
+		}
+	})
+}
+
+func TestPaymentWebhook_FailedThenPaid(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	payments.Register(payments.NewFake(testWebhookSecret))
+
+	e := testutil.NewWebEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+
+	order := testutil.CreateTestOrder(t, user.ID, prod.ID)
+	database.DB.Model(&order).Update("payment_method", "fake")
+	event := func(id, status string) string {
+		return fmt.Sprintf(`{"transaction_id": %q, "reference": %q, "amount": %v, "status": %q}`, id, order.Number, order.TotalAmount, status)
+	}
+
+	postWebhook(t, ts, "fake", testWebhookSecret, event("tx-1", payments.StatusFailed))
+	var o models.Order
+	database.DB.First(&o, "id = ?", order.ID)
+	if o.PaymentStatus != models.PaymentFailed {
+		t.Errorf("expected failed, got %q", o.PaymentStatus)
+	}
+
+	postWebhook(t, ts, "fake", testWebhookSecret, event("tx-2", payments.StatusSucceeded))
+	database.DB.First(&o, "id = ?", order.ID)
+	if o.PaymentStatus != models.PaymentPaid {
+		t.Errorf("expected a retry to settle the order, got %q", o.PaymentStatus)
+	}
+}
+
+func TestAdminOrderDelivered_SettlesCOD(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	order := testutil.CreateTestOrder(t, user.ID, prod.ID)
+
+	e := testutil.NewAdminEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	cookies := testutil.AdminLoginCookies(t, ts)
+
+	resp, err := testutil.PostForm(ts, "/orders/"+order.ID+"/status", cookies, url.Values{"status": {"shipping"}})
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	resp.Body.Close()
+	var o models.Order
+	database.DB.First(&o, "id = ?", order.ID)
+	if o.PaymentStatus != models.PaymentUnpaid {
+		t.Errorf("expected unpaid while shipping, got %q", o.PaymentStatus)
+	}
+
+	resp, err = testutil.PostForm(ts, "/orders/"+order.ID+"/status", cookies, url.Values{"status": {"delivered"}})
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	resp.Body.Close()
+	database.DB.Preload("Payments").First(&o, "id = ?", order.ID)
+	if o.PaymentStatus != models.PaymentPaid || len(o.Payments) != 1 || o.Payments[0].Amount != o.TotalAmount {
+		t.Errorf("expected delivery to settle COD, got %q %+v", o.PaymentStatus, o.Payments)
+	}
+}
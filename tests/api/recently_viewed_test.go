@@ -0,0 +1,105 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/tests/testutil"
+)
+
+func TestRecentlyViewed(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+
+	var products []models.Product
+	for i := 1; i <= 10; i++ {
+		p := models.Product{Name: fmt.Sprintf("Vòng tay số %02d", i), Slug: fmt.Sprintf("vong-tay-%02d", i), SKU: fmt.Sprintf("VT-%02d", i),
+			OriginalPrice: 200000, Stock: 5, CategoryID: cat.ID, IsActive: true}
+		database.DB.Create(&p)
+		products = append(products, p)
+	}
+
+	ts := httptest.NewServer(testutil.NewWebRenderedEcho())
+	defer ts.Close()
+	jar, _ := cookiejar.New(nil)
+	guest := &http.Client{Jar: jar}
+	get := func(client *http.Client, path string) string {
+		t.Helper()
+		resp, err := client.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	if body := get(guest, "/"); strings.Contains(body, "Sản phẩm bạn đã xem") {
+		t.Error("did not expect recently viewed products before viewing any")
+	}
+	get(guest, "/products/vong-tay-01")
+	get(guest, "/products/vong-tay-02")
+	body := get(guest, "/products/vong-tay-02")
+	if !strings.Contains(body, "Sản phẩm bạn đã xem") || !strings.Contains(body, products[0].Name) {
+		t.Error("expected the previously viewed product on the product page")
+	}
+	body = get(guest, "/")
+	if i, j := strings.Index(body, products[1].Name), strings.Index(body, products[0].Name); i < 0 || j < 0 || i > j {
+		t.Error("expected the guest's viewed products on the home page, latest first")
+	}
+
+	var views []models.ProductView
+	database.DB.Order("views DESC").Find(&views)
+	if len(views) != 2 || views[0].Views != 1 || views[1].Views != 1 {
+		t.Errorf("expected one view per product with reloads counted once, got %+v", views)
+	}
+
+	// Signing in keeps what the guest viewed.
+	if _, err := guest.PostForm(ts.URL+"/login", url.Values{"email": {"user@test.com"}, "password": {"user123"}}); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	var saved []models.RecentView
+	database.DB.Order("viewed_at DESC").Find(&saved, "user_id = ?", user.ID)
+	if len(saved) != 2 || saved[0].ProductID != products[1].ID {
+		t.Fatalf("expected the guest's views moved to the account, got %+v", saved)
+	}
+	for _, p := range products[2:] {
+		get(guest, "/products/"+p.Slug)
+	}
+	var count int64
+	database.DB.Model(&models.RecentView{}).Where("user_id = ?", user.ID).Count(&count)
+	if count != 8 {
+		t.Errorf("expected the account to keep the last 8 views, got %d", count)
+	}
+	_, recent, _ := strings.Cut(get(guest, "/"), "Sản phẩm bạn đã xem")
+	if !strings.Contains(recent, products[9].Name) || strings.Contains(recent, products[0].Name) {
+		t.Error("expected the account's latest views on the home page")
+	}
+
+	// Other visitors make an older product trend.
+	for i := 0; i < 3; i++ {
+		jar, _ := cookiejar.New(nil)
+		get(&http.Client{Jar: jar}, "/products/vong-tay-03")
+	}
+	body = get(guest, "/products?sort=trending")
+	if i, j := strings.Index(body, products[2].Name), strings.Index(body, products[9].Name); i < 0 || j < 0 || i > j {
+		t.Error("expected the most viewed product first when trending")
+	}
+	if !strings.Contains(body, `<option value="trending" selected>`) {
+		t.Error("expected the trending sort selected")
+	}
+	body = get(guest, "/products")
+	if i, j := strings.Index(body, products[2].Name), strings.Index(body, products[9].Name); i < 0 || j < 0 || i < j {
+		t.Error("expected the newest product first by default")
+	}
+}
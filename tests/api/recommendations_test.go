@@ -0,0 +1,129 @@
+package api
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/internal/recommendations"
+	"shoop-golang/tests/testutil"
+)
+
+func TestRecommendations(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	other := models.Category{Name: "Quà tặng", Slug: "qua-tang", IsActive: true}
+	database.DB.Create(&other)
+
+	product := func(name, slug, categoryID string, tags ...models.Tag) models.Product {
+		p := models.Product{Name: name, Slug: slug, SKU: slug, OriginalPrice: 100000, Stock: 5, CategoryID: categoryID, IsActive: true, Tags: tags}
+		database.DB.Create(&p)
+		return p
+	}
+	lamp := product("Đèn muối Himalaya", "den-muoi", cat.ID)
+	stand := product("Đế gỗ cho đèn", "de-go", cat.ID)
+	incense := product("Nụ trầm hương", "nu-tram", cat.ID)
+	bowl := product("Bát cúng", "bat-cung", cat.ID)
+	bell := product("Chuông gió", "chuong-gio", other.ID)
+	tag := models.Tag{Name: "Phong thủy", Slug: "phong-thuy"}
+	database.DB.Create(&tag)
+	frog := product("Cóc ngậm tiền", "coc-ngam-tien", other.ID, tag)
+	coins := product("Đồng xu may mắn", "dong-xu", cat.ID, tag)
+
+	order := func(status string, products ...models.Product) {
+		o := models.Order{UserID: user.ID, Status: status, Name: "Test User", Phone: "0909111222", Address: "123 Test St"}
+		for _, p := range products {
+			o.Items = append(o.Items, models.OrderItem{ProductID: p.ID, Quantity: 1, Price: p.OriginalPrice})
+		}
+		database.DB.Create(&o)
+	}
+	order("delivered", lamp, stand, incense)
+	order("confirmed", lamp, stand)
+	order("pending", stand, bowl)
+	order("cancelled", lamp, bell)
+	order("cancelled", lamp, bell)
+
+	n, err := recommendations.Rebuild(database.DB)
+	if err != nil {
+		t.Fatalf("rebuild failed: %v", err)
+	}
+	// lamp-stand, lamp-incense, stand-incense and stand-bowl, both ways.
+	if n != 8 {
+		t.Errorf("expected 8 affinities, got %d", n)
+	}
+	var pair models.ProductAffinity
+	database.DB.First(&pair, "product_id = ? AND related_id = ?", lamp.ID, stand.ID)
+	if pair.Orders != 2 || pair.Score != 1 {
+		t.Errorf("expected lamp and stand in both lamp orders, got %d %.2f", pair.Orders, pair.Score)
+	}
+	if got := recommendations.BoughtTogether(database.DB, []string{lamp.ID}, 4); len(got) != 1 || got[0] != stand.ID {
+		t.Errorf("expected only the stand bought together with the lamp, got %v", got)
+	}
+	if got := recommendations.AlsoBought(database.DB, []string{lamp.ID}, []string{stand.ID}, 4); len(got) != 1 || got[0] != incense.ID {
+		t.Errorf("expected the incense also bought with the lamp, got %v", got)
+	}
+	// Rebuilding replaces the table rather than adding to it.
+	if n, _ := recommendations.Rebuild(database.DB); n != 8 {
+		t.Errorf("expected a rebuild to keep 8 affinities, got %d", n)
+	}
+
+	ts := httptest.NewServer(testutil.NewWebRenderedEcho())
+	defer ts.Close()
+	get := func(path string) string {
+		t.Helper()
+		resp, err := ts.Client().Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	body := get("/products/den-muoi")
+	for _, want := range []string{"Thường được mua cùng", "Thêm tất cả vào giỏ", stand.Name, "Khách hàng cũng mua", incense.Name} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q on the lamp page", want)
+		}
+	}
+	if strings.Contains(body, bell.Name) {
+		t.Error("did not expect products from cancelled orders")
+	}
+
+	// Without orders, products sharing a tag come first.
+	body = get("/products/coc-ngam-tien")
+	if !strings.Contains(body, "Sản phẩm liên quan") || !strings.Contains(body, coins.Name) {
+		t.Error("expected similar products for a product nobody ordered")
+	}
+	if strings.Contains(body, "Thường được mua cùng") {
+		t.Error("did not expect a bundle without orders")
+	}
+	if body := get("/products/dong-xu"); !strings.Contains(body, frog.Name) || !strings.Contains(body, bowl.Name) || strings.Index(body, frog.Name) > strings.Index(body, bowl.Name) {
+		t.Error("expected the product sharing a tag before the rest of the category")
+	}
+
+	// A stockless lamp has no bundle to add.
+	database.DB.Model(&stand).Update("stock", 0)
+	if body := get("/products/den-muoi"); strings.Contains(body, "Thêm tất cả vào giỏ") {
+		t.Error("did not expect a bundle with an out of stock product")
+	}
+	database.DB.Model(&stand).Update("stock", 5)
+
+	client := shippingClient(t, ts, lamp, "1")
+	resp, err := client.Get(ts.URL + "/cart")
+	if err != nil {
+		t.Fatalf("cart failed: %v", err)
+	}
+	defer resp.Body.Close()
+	cart, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{"Thường được mua cùng các sản phẩm trong giỏ", stand.Name, incense.Name} {
+		if !strings.Contains(string(cart), want) {
+			t.Errorf("expected %q on the cart page", want)
+		}
+	}
+}
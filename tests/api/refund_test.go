@@ -0,0 +1,381 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/money"
+	"shoop-golang/pkg/payments"
+	"shoop-golang/tests/testutil"
+)
+
+// createRefundOrder places 3 × 80.000đ plus 30.000đ shipping with a pending
+// charge through method.
+func createRefundOrder(t *testing.T, userID, productID, method string) models.Order {
+	t.Helper()
+	order := models.Order{
+		UserID:        userID,
+		Status:        "confirmed",
+		Subtotal:      240000,
+		ShippingFee:   30000,
+		TotalAmount:   270000,
+		Name:          "Test User",
+		Phone:         "0909111222",
+		Address:       "123 Test St",
+		PaymentMethod: method,
+		Items:         []models.OrderItem{{ProductID: productID, Quantity: 3, Price: 80000}},
+	}
+	if err := database.DB.Create(&order).Error; err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+	models.RecordPayment(database.DB, &models.Payment{
+		OrderID: order.ID, Provider: method, Kind: models.PaymentCharge,
+		Status: payments.StatusPending, Amount: order.TotalAmount, Reference: order.Number,
+	})
+	return order
+}
+
+func TestAdminOrderRefund(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	order := createRefundOrder(t, user.ID, prod.ID, "cod")
+	item := order.Items[0]
+
+	e := testutil.NewAdminEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	cookies := testutil.AdminLoginCookies(t, ts)
+
+	refund := func(values url.Values) {
+		t.Helper()
+		resp, err := testutil.PostForm(ts, "/orders/"+order.ID+"/refund", cookies, values)
+		if err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	reload := func() (models.Order, models.Product) {
+		var o models.Order
+		var p models.Product
+		database.DB.Preload("Items").Preload("Refunds.Items").Preload("Payments").First(&o, "id = ?", order.ID)
+		database.DB.First(&p, "id = ?", prod.ID)
+		return o, p
+	}
+
+	t.Run("validates", func(t *testing.T) {
+		refund(url.Values{"qty_" + item.ID: {"1"}})
+		refund(url.Values{"qty_" + item.ID: {"4"}, "reason": {"Hết hàng"}})
+		refund(url.Values{"qty_" + item.ID: {"0"}, "reason": {"Hết hàng"}})
+		if o, _ := reload(); len(o.Refunds) != 0 || o.TotalAmount != 270000 {
+			t.Errorf("expected invalid refunds to be rejected, got %+v", o.Refunds)
+		}
+	})
+
+	t.Run("partial cancellation restocks and recomputes", func(t *testing.T) {
+		refund(url.Values{"qty_" + item.ID: {"1"}, "reason": {"Hết hàng"}, "restock": {"on"}})
+		o, p := reload()
+		if o.Items[0].Quantity != 2 || o.Items[0].CancelledQuantity != 1 {
+			t.Errorf("unexpected line %+v", o.Items[0])
+		}
+		if o.Subtotal != 160000 || o.TotalAmount != 190000 || o.RefundedAmount != 80000 || o.Status != "confirmed" {
+			t.Errorf("unexpected totals %v / %v / %v (%s)", o.Subtotal, o.TotalAmount, o.RefundedAmount, o.Status)
+		}
+		if p.Stock != prod.Stock+1 {
+			t.Errorf("expected stock %d, got %d", prod.Stock+1, p.Stock)
+		}
+		if len(o.Refunds) != 1 || o.Refunds[0].Amount != 80000 || o.Refunds[0].Reason != "Hết hàng" || !o.Refunds[0].Restocked ||
+			len(o.Refunds[0].Items) != 1 || o.Refunds[0].Items[0].Quantity != 1 {
+			t.Errorf("unexpected refund %+v", o.Refunds)
+		}
+		if len(o.Payments) != 1 || o.Payments[0].Amount != 190000 || o.Payments[0].Status != payments.StatusPending {
+			t.Errorf("expected the pending charge to ask for the new total, got %+v", o.Payments)
+		}
+	})
+
+	t.Run("cancelling the rest cancels the order", func(t *testing.T) {
+		refund(url.Values{"qty_" + item.ID: {"2"}, "reason": {"Khách đổi ý"}})
+		o, p := reload()
+		if o.Status != "cancelled" || o.TotalAmount != 0 || o.ShippingFee != 0 || o.RefundedAmount != 270000 {
+			t.Errorf("unexpected order %s total %v shipping %v refunded %v", o.Status, o.TotalAmount, o.ShippingFee, o.RefundedAmount)
+		}
+		if p.Stock != prod.Stock+1 {
+			t.Errorf("expected stock to stay at %d without restock, got %d", prod.Stock+1, p.Stock)
+		}
+		if o.Payments[0].Status != payments.StatusFailed {
+			t.Errorf("expected the pending charge to be dropped, got %q", o.Payments[0].Status)
+		}
+	})
+}
+
+func TestAdminOrderRefund_PaidOrder(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	fake := payments.NewFake(testWebhookSecret)
+	payments.Register(fake)
+
+	order := createRefundOrder(t, user.ID, prod.ID, "fake")
+	models.RecordPayment(database.DB, &models.Payment{
+		OrderID: order.ID, Provider: "fake", TransactionID: "tx-1", Kind: models.PaymentCharge,
+		Status: payments.StatusSucceeded, Amount: order.TotalAmount, Reference: order.Number,
+	})
+
+	e := testutil.NewAdminEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	cookies := testutil.AdminLoginCookies(t, ts)
+
+	refund := func(qty string) {
+		t.Helper()
+		resp, err := testutil.PostForm(ts, "/orders/"+order.ID+"/refund", cookies,
+			url.Values{"qty_" + order.Items[0].ID: {qty}, "reason": {"Hàng lỗi"}})
+		if err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	refund("1")
+	var o models.Order
+	database.DB.Preload("Refunds").First(&o, "id = ?", order.ID)
+	if got := fake.Refunds(); len(got) != 1 || o.PaymentStatus != models.PaymentPaid {
+		t.Fatalf("expected one provider refund on a paid order, got %v (%s)", got, o.PaymentStatus)
+	}
+	var payment models.Payment
+	database.DB.First(&payment, "order_id = ? AND kind = ?", order.ID, models.PaymentRefund)
+	if payment.Amount != 80000 || o.Refunds[0].PaymentID == nil || *o.Refunds[0].PaymentID != payment.ID {
+		t.Errorf("expected the refund to link an 80.000đ refund payment, got %+v", payment)
+	}
+
+	refund("2")
+	database.DB.First(&o, "id = ?", order.ID)
+	if o.PaymentStatus != models.PaymentRefunded || len(fake.Refunds()) != 2 {
+		t.Errorf("expected the order to be fully refunded, got %q", o.PaymentStatus)
+	}
+	var refunded money.Money
+	database.DB.Model(&models.Payment{}).Where("order_id = ? AND kind = ?", order.ID, models.PaymentRefund).
+		Select("SUM(amount)").Scan(&refunded)
+	if refunded != 270000 {
+		t.Errorf("expected 270.000đ given back, got %v", refunded)
+	}
+}
+
+func TestWebAccountOrders(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+
+	e := testutil.NewWebRenderedEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+
+	t.Run("checkout takes items out of stock", func(t *testing.T) {
+		status, _ := postCheckout(t, shippingClient(t, ts, prod, "2"), ts, map[string]string{"name": "A", "phone": "1", "address": "x"})
+		var p models.Product
+		database.DB.First(&p, "id = ?", prod.ID)
+		if status != http.StatusOK || p.Stock != prod.Stock-2 {
+			t.Errorf("expected stock %d after checkout, got %d (%d)", prod.Stock-2, p.Stock, status)
+		}
+	})
+
+	order := createRefundOrder(t, user.ID, prod.ID, "cod")
+	database.DB.Model(&order.Items[0]).Updates(map[string]any{"quantity": 2, "cancelled_quantity": 1})
+	database.DB.Model(&order).Updates(map[string]any{"subtotal": 160000, "total_amount": 190000, "refunded_amount": 80000})
+	database.DB.Create(&models.Refund{OrderID: order.ID, Amount: 80000, Reason: "Hết hàng", Items: []models.RefundItem{
+		{OrderItemID: order.Items[0].ID, Quantity: 1, Amount: 80000},
+	}})
+	other := createRefundOrder(t, "someone-else", prod.ID, "cod")
+
+	cookies := testutil.WebLoginCookies(t, ts)
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := testutil.GetWithCookies(ts, path, cookies)
+		if err != nil {
+			t.Fatalf("get %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if status, body := get("/account/orders"); status != http.StatusOK || !strings.Contains(body, order.Number) || strings.Contains(body, other.Number) {
+		t.Errorf("expected only the customer's orders, got %d", status)
+	}
+	status, body := get("/account/orders/" + order.Number)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	for _, want := range []string{"Đã hủy 1/3", "Hết hàng", "190.000"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q on the order page", want)
+		}
+	}
+	if status, _ := get("/account/orders/" + other.Number); status != http.StatusFound {
+		t.Errorf("expected another customer's order to redirect, got %d", status)
+	}
+}
+
+func TestAdminOrderCancel(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	fake := payments.NewFake(testWebhookSecret)
+	payments.Register(fake)
+
+	e := testutil.NewAdminEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	cookies := testutil.AdminLoginCookies(t, ts)
+
+	cancel := func(order models.Order) models.Order {
+		t.Helper()
+		resp, err := testutil.PostForm(ts, "/orders/"+order.ID+"/status", cookies, url.Values{"status": {"cancelled"}})
+		if err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+		resp.Body.Close()
+		var o models.Order
+		database.DB.Preload("Items").Preload("Refunds").Preload("Payments").First(&o, "id = ?", order.ID)
+		return o
+	}
+	stock := func() int {
+		var p models.Product
+		database.DB.First(&p, "id = ?", prod.ID)
+		return p.Stock
+	}
+
+	t.Run("unpaid order restocks and voids the charge", func(t *testing.T) {
+		before := stock()
+		o := cancel(createRefundOrder(t, user.ID, prod.ID, "cod"))
+		if o.Status != "cancelled" || o.TotalAmount != 0 || o.Items[0].Quantity != 0 || o.Items[0].CancelledQuantity != 3 {
+			t.Errorf("expected every line cancelled, got %s %v %+v", o.Status, o.TotalAmount, o.Items[0])
+		}
+		if got := stock(); got != before+3 {
+			t.Errorf("expected stock %d, got %d", before+3, got)
+		}
+		if len(o.Refunds) != 1 || o.Refunds[0].Reason != "Hủy đơn hàng" || !o.Refunds[0].Restocked {
+			t.Errorf("expected a restocking refund, got %+v", o.Refunds)
+		}
+		if len(o.Payments) != 1 || o.Payments[0].Status != payments.StatusFailed {
+			t.Errorf("expected the pending charge voided, got %+v", o.Payments)
+		}
+
+		// Cancelling again changes nothing.
+		if o := cancel(o); len(o.Refunds) != 1 || stock() != before+3 {
+			t.Error("expected a second cancel to be a no-op")
+		}
+	})
+
+	t.Run("paid order is refunded after commit", func(t *testing.T) {
+		order := createRefundOrder(t, user.ID, prod.ID, "fake")
+		models.RecordPayment(database.DB, &models.Payment{
+			OrderID: order.ID, Provider: "fake", TransactionID: "tx-paid", Kind: models.PaymentCharge,
+			Status: payments.StatusSucceeded, Amount: order.TotalAmount, Reference: order.Number,
+		})
+		o := cancel(order)
+		var refund models.Payment
+		database.DB.First(&refund, "order_id = ? AND kind = ?", order.ID, models.PaymentRefund)
+		if refund.Amount != 270000 || refund.Status != payments.StatusSucceeded || refund.TransactionID == "" {
+			t.Errorf("expected a settled 270.000đ provider refund, got %+v", refund)
+		}
+		if o.PaymentStatus != models.PaymentRefunded || len(fake.Refunds()) != 1 {
+			t.Errorf("expected the order refunded, got %q", o.PaymentStatus)
+		}
+	})
+
+	t.Run("provider failure is recorded", func(t *testing.T) {
+		fake.RefundErr = errors.New("gateway down")
+		defer func() { fake.RefundErr = nil }()
+		order := createRefundOrder(t, user.ID, prod.ID, "fake")
+		models.RecordPayment(database.DB, &models.Payment{
+			OrderID: order.ID, Provider: "fake", TransactionID: "tx-down", Kind: models.PaymentCharge,
+			Status: payments.StatusSucceeded, Amount: order.TotalAmount, Reference: order.Number,
+		})
+		o := cancel(order)
+		var refund models.Payment
+		database.DB.First(&refund, "order_id = ? AND kind = ?", order.ID, models.PaymentRefund)
+		if refund.Status != payments.StatusFailed {
+			t.Errorf("expected the refund marked failed, got %q", refund.Status)
+		}
+		if o.Status != "cancelled" || o.PaymentStatus != models.PaymentPaid {
+			t.Errorf("expected a cancelled order still marked paid, got %s / %s", o.Status, o.PaymentStatus)
+		}
+	})
+
+	setStatus := func(order models.Order, status string) models.Order {
+		t.Helper()
+		resp, err := testutil.PostForm(ts, "/orders/"+order.ID+"/status", cookies, url.Values{"status": {status}})
+		if err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+		resp.Body.Close()
+		var o models.Order
+		database.DB.Preload("Items").Preload("Refunds").Preload("Payments").First(&o, "id = ?", order.ID)
+		return o
+	}
+
+	t.Run("cancelled order cannot be delivered", func(t *testing.T) {
+		o := cancel(createRefundOrder(t, user.ID, prod.ID, "cod"))
+		before := stock()
+		for _, status := range []string{"pending", "shipping", "delivered"} {
+			if o := setStatus(o, status); o.Status != "cancelled" {
+				t.Errorf("expected a cancelled order to stay cancelled, moved to %s", o.Status)
+			}
+		}
+		o = setStatus(o, "delivered")
+		if o.PaymentStatus == models.PaymentPaid || len(o.Payments) != 1 || o.Payments[0].Status != payments.StatusFailed {
+			t.Errorf("expected no cash on delivery collected, got %q %+v", o.PaymentStatus, o.Payments)
+		}
+		if stock() != before {
+			t.Error("expected stock untouched")
+		}
+	})
+
+	t.Run("delivered order is cancelled through the refund form", func(t *testing.T) {
+		order := createRefundOrder(t, user.ID, prod.ID, "cod")
+		setStatus(order, "shipping")
+		o := setStatus(order, "delivered")
+		if o.Status != "delivered" || o.PaymentStatus != models.PaymentPaid {
+			t.Fatalf("expected a delivered, paid order, got %s / %s", o.Status, o.PaymentStatus)
+		}
+		before := stock()
+		if o := cancel(o); o.Status != "delivered" || len(o.Refunds) != 0 || stock() != before {
+			t.Errorf("expected the status form to refuse cancelling a delivered order, got %s", o.Status)
+		}
+
+		// The refund form leaves stock alone unless staff tick restock.
+		resp, err := testutil.PostForm(ts, "/orders/"+order.ID+"/refund", cookies, url.Values{
+			"reason": {"Khách trả hàng"}, "qty_" + o.Items[0].ID: {"3"},
+		})
+		if err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+		resp.Body.Close()
+		database.DB.Preload("Refunds").First(&o, "id = ?", order.ID)
+		if o.Status != "cancelled" || len(o.Refunds) != 1 || o.Refunds[0].Restocked {
+			t.Errorf("expected a cancelled order with a refund that did not restock, got %s %+v", o.Status, o.Refunds)
+		}
+		if stock() != before {
+			t.Errorf("expected stock %d, got %d", before, stock())
+		}
+	})
+}
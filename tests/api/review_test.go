@@ -0,0 +1,220 @@
+package api
+
+import (
+	"bytes"
+	"image"
+	pngenc "image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/tests/testutil"
+)
+
+func TestReviews(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	order := testutil.CreateTestOrder(t, user.ID, prod.ID)
+
+	web := httptest.NewServer(testutil.NewWebRenderedEcho())
+	defer web.Close()
+	cookies := testutil.WebLoginCookies(t, web)
+
+	submit := func(values url.Values) string {
+		t.Helper()
+		resp, err := testutil.PostForm(web, "/products/"+prod.Slug+"/reviews", cookies, values)
+		if err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusFound {
+			t.Fatalf("expected 302, got %d", resp.StatusCode)
+		}
+		return resp.Header.Get("Location")
+	}
+	page := func() string {
+		t.Helper()
+		resp, err := testutil.GetWithCookies(web, "/products/"+prod.Slug, cookies)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	countReviews := func() int64 {
+		var n int64
+		database.DB.Model(&models.Review{}).Where("product_id = ?", prod.ID).Count(&n)
+		return n
+	}
+
+	review := url.Values{"rating": {"4"}, "title": {"Đẹp lắm"}, "body": {"Tượng rất đẹp"}}
+	submit(review)
+	if countReviews() != 0 {
+		t.Fatal("expected a review without a delivered order to be refused")
+	}
+	if strings.Contains(page(), `name="rating"`) {
+		t.Error("expected no review form before delivery")
+	}
+
+	database.DB.Model(&order).Update("status", "delivered")
+	if !strings.Contains(page(), `name="rating"`) {
+		t.Error("expected the review form after delivery")
+	}
+	submit(url.Values{"rating": {"6"}})
+	if countReviews() != 0 {
+		t.Fatal("expected an out-of-range rating to be refused")
+	}
+	if loc := submit(review); loc != "/products/"+prod.Slug+"#reviews" {
+		t.Errorf("expected to return to the reviews, got %q", loc)
+	}
+	submit(url.Values{"rating": {"1"}})
+	if countReviews() != 1 {
+		t.Fatalf("expected exactly one review, got %d", countReviews())
+	}
+
+	var saved models.Review
+	database.DB.First(&saved, "product_id = ?", prod.ID)
+	if saved.Status != models.ReviewPending || saved.OrderID != order.ID || saved.Rating != 4 {
+		t.Errorf("unexpected review %+v", saved)
+	}
+	if body := page(); strings.Contains(body, "Tượng rất đẹp") || !strings.Contains(body, "chờ duyệt") {
+		t.Error("expected a pending review to be hidden and its author told")
+	}
+
+	admin := httptest.NewServer(testutil.NewAdminRenderedEcho())
+	defer admin.Close()
+	adminCookies := testutil.AdminLoginCookies(t, admin)
+
+	resp, err := testutil.GetWithCookies(admin, "/reviews", adminCookies)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "Tượng rất đẹp") {
+		t.Error("expected the review in the moderation queue")
+	}
+
+	resp, err = testutil.PostForm(admin, "/reviews/"+saved.ID+"/status", adminCookies, url.Values{"status": {"approved"}, "return": {"?status=pending"}})
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	resp.Body.Close()
+	if loc := resp.Header.Get("Location"); loc != "/reviews?status=pending" {
+		t.Errorf("expected to return to the queue, got %q", loc)
+	}
+	resp, err = testutil.PostForm(admin, "/reviews/"+saved.ID+"/reply", adminCookies, url.Values{"reply": {"Cảm ơn anh!"}})
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	resp.Body.Close()
+
+	database.DB.First(&prod, "id = ?", prod.ID)
+	if prod.RatingCount != 1 || prod.RatingAverage != 4 {
+		t.Errorf("expected the cached rating 4 (1), got %v (%d)", prod.RatingAverage, prod.RatingCount)
+	}
+	body2 := page()
+	for _, want := range []string{"Tượng rất đẹp", "Cảm ơn anh!", `"@type":"AggregateRating"`, `"ratingValue":4`} {
+		if !strings.Contains(body2, want) {
+			t.Errorf("expected %q on the product page", want)
+		}
+	}
+
+	resp, err = testutil.PostForm(admin, "/reviews/"+saved.ID+"/status", adminCookies, url.Values{"status": {"rejected"}})
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	resp.Body.Close()
+	database.DB.First(&prod, "id = ?", prod.ID)
+	if prod.RatingCount != 0 || prod.RatingAverage != 0 {
+		t.Errorf("expected rejecting to clear the rating, got %v (%d)", prod.RatingAverage, prod.RatingCount)
+	}
+}
+
+func TestReviewPhotos(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	order := testutil.CreateTestOrder(t, user.ID, prod.ID)
+	database.DB.Model(&order).Update("status", "delivered")
+
+	web := httptest.NewServer(testutil.NewWebRenderedEcho())
+	defer web.Close()
+	cookies := testutil.WebLoginCookies(t, web)
+	// Uploads land in a scratch directory.
+	t.Chdir(t.TempDir())
+
+	var png bytes.Buffer
+	if err := pngenc.Encode(&png, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	submit := func(name string, content []byte) {
+		t.Helper()
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		w.WriteField("rating", "5")
+		part, _ := w.CreateFormFile("photos", name)
+		part.Write(content)
+		w.Close()
+		req, _ := http.NewRequest(http.MethodPost, web.URL+"/products/"+prod.Slug+"/reviews", &body)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	state := func() (reviews int64, files int) {
+		database.DB.Model(&models.Review{}).Count(&reviews)
+		entries, _ := os.ReadDir("uploads/reviews")
+		return reviews, len(entries)
+	}
+
+	submit("tuong.jpg", []byte("<html>not a photo</html>"))
+	if reviews, files := state(); reviews != 0 || files != 0 {
+		t.Fatalf("expected a file that is not an image refused, got %d reviews, %d files", reviews, files)
+	}
+
+	// A photo that cannot be recorded takes its file and review with it.
+	database.DB.Migrator().DropTable(&models.ReviewPhoto{})
+	submit("tuong.png", png.Bytes())
+	if reviews, files := state(); reviews != 0 || files != 0 {
+		t.Fatalf("expected nothing kept when saving fails, got %d reviews, %d files", reviews, files)
+	}
+	database.DB.AutoMigrate(&models.ReviewPhoto{})
+
+	// A photo that cannot be written fails the review instead of vanishing.
+	os.RemoveAll("uploads/reviews")
+	os.WriteFile("uploads/reviews", nil, 0o644)
+	submit("tuong.png", png.Bytes())
+	if reviews, _ := state(); reviews != 0 {
+		t.Fatal("expected the review refused when its photo cannot be written")
+	}
+	os.Remove("uploads/reviews")
+
+	submit("tuong.png", png.Bytes())
+	var review models.Review
+	if err := database.DB.Preload("Photos").First(&review).Error; err != nil || len(review.Photos) != 1 {
+		t.Fatalf("expected a review with its photo, got %+v (%v)", review, err)
+	}
+	if saved, err := os.ReadFile(strings.TrimPrefix(review.Photos[0].URL, "/")); err != nil || !bytes.Equal(saved, png.Bytes()) {
+		t.Errorf("expected the photo at %s, got %v", review.Photos[0].URL, err)
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/tests/testutil"
+)
+
+func TestSEO(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	database.DB.Create(&models.Image{ProductID: prod.ID, URL: "/uploads/products/tyhuu.jpg"})
+	database.DB.Create(&models.CompanyInfo{Name: "OCC.IO.VN", Tagline: "Đồ phong thủy chính hãng"})
+
+	admin := httptest.NewServer(testutil.NewAdminRenderedEcho())
+	defer admin.Close()
+	cookies := testutil.AdminLoginCookies(t, admin)
+	web := httptest.NewServer(testutil.NewWebRenderedEcho())
+	defer web.Close()
+
+	get := func(ts *httptest.Server, path string, cookies []*http.Cookie) string {
+		t.Helper()
+		resp, err := testutil.GetWithCookies(ts, path, cookies)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	save := func(path string, values url.Values) {
+		t.Helper()
+		resp, err := testutil.PostForm(admin, path, cookies, values)
+		if err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	count := func() int64 {
+		var n int64
+		database.DB.Model(&models.SEOBanner{}).Count(&n)
+		return n
+	}
+	expect := func(body string, want ...string) {
+		t.Helper()
+		for _, w := range want {
+			if !strings.Contains(body, w) {
+				t.Errorf("expected %q in the page", w)
+			}
+		}
+	}
+
+	// Defaults come from the company info and the product itself.
+	expect(get(web, "/", nil), "<title>Trang chủ - OCC.IO.VN</title>", `<meta name="description" content="Đồ phong thủy chính hãng">`, `<link rel="canonical" href="`+testutil.BaseURL+`/">`)
+	expect(get(web, "/products/"+prod.Slug, nil),
+		`<meta name="description" content="A test product">`,
+		`<meta property="og:type" content="product">`,
+		`<meta property="og:image" content="`+testutil.BaseURL+`/uploads/products/tyhuu.jpg">`,
+		`<meta name="twitter:card" content="summary_large_image">`)
+
+	expect(get(admin, "/seo", cookies), "Trang chủ", "Danh sách sản phẩm", "Chưa thiết lập")
+
+	save("/seo", url.Values{"page": {"home"}, "title": {"Đồ phong thủy OCC"}, "description": {"Tỳ hưu, thiềm thừ, vòng đá"}, "title_en": {"OCC Feng Shui"}, "og_image": {"/uploads/share.jpg"}})
+	save("/seo", url.Values{"page": {"home"}, "title": {"Lần hai"}})
+	save("/seo", url.Values{"page": {"nowhere"}, "title": {"Sai"}})
+	if count() != 1 {
+		t.Fatalf("expected one entry, got %d", count())
+	}
+	expect(get(web, "/", nil), "<title>Đồ phong thủy OCC</title>", `content="Tỳ hưu, thiềm thừ, vòng đá"`, `<meta property="og:image" content="`+testutil.BaseURL+`/uploads/share.jpg">`)
+	expect(get(web, "/en/", nil), "<title>OCC Feng Shui</title>", `<link rel="canonical" href="`+testutil.BaseURL+`/en/">`, `content="en_US"`)
+
+	// Canonical and share links ignore a forged Host header.
+	req, _ := http.NewRequest("GET", web.URL+"/products/"+prod.Slug, nil)
+	req.Host = "evil.example"
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	forged, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if strings.Contains(string(forged), "evil.example") {
+		t.Error("expected canonical, og:url and JSON-LD links from the configured base URL")
+	}
+	expect(string(forged), `<link rel="canonical" href="`+testutil.BaseURL+`/products/`+prod.Slug+`">`)
+
+	// The override picker searches instead of listing the whole catalog.
+	if body := get(admin, "/seo", cookies); strings.Contains(body, prod.Name) {
+		t.Error("did not expect products listed on the SEO page")
+	}
+	var targets []struct{ Page, Label string }
+	json.Unmarshal([]byte(get(admin, "/seo/targets?q=Test", cookies)), &targets)
+	if len(targets) != 2 || targets[0].Page != models.SEOCategoryPage(cat.ID) || targets[1].Page != models.SEOProductPage(prod.ID) ||
+		targets[1].Label != "Sản phẩm: "+prod.Name {
+		t.Errorf("expected the matching category and product, got %+v", targets)
+	}
+	if json.Unmarshal([]byte(get(admin, "/seo/targets?q=nowhere", cookies)), &targets); len(targets) != 0 {
+		t.Errorf("expected no matches, got %+v", targets)
+	}
+
+	save("/seo", url.Values{"page": {models.SEOProductPage(prod.ID)}, "description": {"Tỳ hưu ngọc bích chiêu tài"}})
+	save("/seo", url.Values{"page": {models.SEOCategoryPage(cat.ID)}, "title": {"Đá phong thủy"}})
+	expect(get(admin, "/seo", cookies), "Sản phẩm: "+prod.Name, "Danh mục: "+cat.Name)
+	body := get(web, "/products/"+prod.Slug, nil)
+	expect(body, "<title>Test Product - OCC.IO.VN</title>", `<meta name="description" content="Tỳ hưu ngọc bích chiêu tài">`)
+
+	var entry models.SEOBanner
+	database.DB.First(&entry, "page = ?", models.SEOProductPage(prod.ID))
+	save("/seo/"+entry.ID, url.Values{"title": {"Tỳ hưu ngọc"}, "description": {""}})
+	expect(get(web, "/products/"+prod.Slug, nil), "<title>Tỳ hưu ngọc</title>", `<meta name="description" content="A test product">`)
+
+	save("/seo/"+entry.ID+"/delete", nil)
+	if count() != 2 {
+		t.Errorf("expected the override removed, %d entries left", count())
+	}
+	expect(get(web, "/products/"+prod.Slug, nil), "<title>Test Product - OCC.IO.VN</title>")
+}
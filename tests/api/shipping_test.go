@@ -0,0 +1,301 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/tests/testutil"
+)
+
+func createTestShippingMethods(t *testing.T) {
+	t.Helper()
+	methods := []models.ShippingMethod{
+		{Code: "standard", Name: "Tiêu chuẩn", SortOrder: 1, IsActive: true, Rules: []models.ShippingRule{
+			{Province: "Hà Nội", BaseFee: 30000, IncludedWeight: 1000, PerKgFee: 10000},
+			{BaseFee: 40000, FreeOver: 1000000},
+		}},
+		{Code: "express", Name: "Nhanh", SortOrder: 2, IsActive: true, Rules: []models.ShippingRule{
+			{Province: "Hà Nội", BaseFee: 60000},
+		}},
+	}
+	if err := database.DB.Create(&methods).Error; err != nil {
+		t.Fatalf("create shipping methods: %v", err)
+	}
+}
+
+// shippingClient logs in the test user and puts qty of prod in the cart.
+func shippingClient(t *testing.T, ts *httptest.Server, prod models.Product, qty string) *http.Client {
+	t.Helper()
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+	if _, err := client.PostForm(ts.URL+"/login", url.Values{"email": {"user@test.com"}, "password": {"user123"}}); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if _, err := client.PostForm(ts.URL+"/cart/add", url.Values{"product_id": {prod.ID}, "quantity": {qty}}); err != nil {
+		t.Fatalf("cart add failed: %v", err)
+	}
+	return client
+}
+
+func TestWebShippingQuote(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	database.DB.Model(&prod).Update("weight", 600)
+	createTestShippingMethods(t)
+
+	e := testutil.NewWebEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	client := shippingClient(t, ts, prod, "3")
+
+	quote := func(query string) map[string]any {
+		t.Helper()
+		resp, err := client.Get(ts.URL + "/cart/shipping?" + query)
+		if err != nil {
+			t.Fatalf("quote failed: %v", err)
+		}
+		defer resp.Body.Close()
+		var body map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("decode json: %v", err)
+		}
+		return body
+	}
+
+	// 3 x 80,000 = 240,000; 3 x 600 g = 1.8 kg
+	q := quote(url.Values{"province": {"Hà Nội"}}.Encode())
+	if q["subtotal"] != 240000.0 || q["weight"] != 1800.0 {
+		t.Errorf("unexpected subtotal/weight: %v / %v", q["subtotal"], q["weight"])
+	}
+	if q["selected"] != "standard" || q["shipping_fee"] != 40000.0 || q["total"] != 280000.0 {
+		t.Errorf("expected standard 30,000 + 1 extra kg, got %v %v total %v", q["selected"], q["shipping_fee"], q["total"])
+	}
+
+	q = quote(url.Values{"province": {"Hà Nội"}, "method": {"express"}}.Encode())
+	if q["selected"] != "express" || q["shipping_fee"] != 60000.0 {
+		t.Errorf("expected express 60,000, got %v %v", q["selected"], q["shipping_fee"])
+	}
+
+	q = quote(url.Values{"province": {"Cần Thơ"}, "method": {"express"}}.Encode())
+	if q["selected"] != "standard" || q["shipping_fee"] != 40000.0 {
+		t.Errorf("expected fallback to standard outside Hà Nội, got %v %v", q["selected"], q["shipping_fee"])
+	}
+	options := q["options"].([]any)
+	if len(options) != 2 || options[1].(map[string]any)["available"] != false {
+		t.Errorf("expected express listed as unavailable, got %v", options)
+	}
+}
+
+func TestWebCheckout_WithShipping(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	createTestShippingMethods(t)
+
+	e := testutil.NewWebEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+
+	t.Run("unavailable_method_rejected", func(t *testing.T) {
+		client := shippingClient(t, ts, prod, "1")
+		resp, err := client.PostForm(ts.URL+"/checkout", url.Values{
+			"name": {"A"}, "phone": {"0909"}, "address": {"1 St"},
+			"province": {"Cần Thơ"}, "shipping_method": {"express"},
+		})
+		if err != nil {
+			t.Fatalf("checkout failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("amounts_stored", func(t *testing.T) {
+		client := shippingClient(t, ts, prod, "1")
+		resp, err := client.PostForm(ts.URL+"/checkout", url.Values{
+			"name": {"A"}, "phone": {"0909"}, "address": {"1 St"},
+			"province": {"Hà Nội"}, "shipping_method": {"express"},
+		})
+		if err != nil {
+			t.Fatalf("checkout failed: %v", err)
+		}
+		defer resp.Body.Close()
+		var body map[string]any
+		json.NewDecoder(resp.Body).Decode(&body)
+
+		var order models.Order
+		if err := database.DB.First(&order, "id = ?", body["order_id"]).Error; err != nil {
+			t.Fatalf("order not found: %v", err)
+		}
+		if order.Subtotal != 80000 || order.ShippingFee != 60000 || order.Discount != 0 || order.TotalAmount != 140000 {
+			t.Errorf("unexpected amounts: subtotal %v shipping %v discount %v total %v",
+				order.Subtotal, order.ShippingFee, order.Discount, order.TotalAmount)
+		}
+		if order.Shipping != "express" || order.Province != "Hà Nội" {
+			t.Errorf("expected express to Hà Nội, got %q / %q", order.Shipping, order.Province)
+		}
+	})
+}
+
+func TestWebCartPage_ShowsShippingQuote(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	createTestShippingMethods(t)
+	database.DB.Create(&models.Province{Code: "01", Name: "Hà Nội"})
+
+	e := testutil.NewWebRenderedEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	client := shippingClient(t, ts, prod, "1")
+
+	resp, err := client.Get(ts.URL + "/cart?province_code=01")
+	if err != nil {
+		t.Fatalf("get cart failed: %v", err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	body := string(b)
+	for _, want := range []string{`name="shipping_method" value="standard"`, "Tiêu chuẩn", "Nhanh", `<option value="01" selected>`, "30.000"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected cart page to contain %q", want)
+		}
+	}
+}
+
+func TestAdminShipping_CRUD(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+
+	e := testutil.NewAdminEcho()
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	cookies := testutil.AdminLoginCookies(t, ts)
+
+	resp, err := testutil.PostForm(ts, "/shipping", cookies, url.Values{
+		"code":                 {"Standard"},
+		"name":                 {"Tiêu chuẩn"},
+		"is_active":            {"on"},
+		"rule_province":        {"Hà Nội", ""},
+		"rule_base_fee":        {"30000", "40000"},
+		"rule_included_weight": {"1000", "0"},
+		"rule_per_kg_fee":      {"5000", "0"},
+		"rule_max_weight":      {"0", "0"},
+		"rule_free_over":       {"0", "1000000"},
+	})
+	if err != nil {
+		t.Fatalf("store failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected 302, got %d", resp.StatusCode)
+	}
+
+	var method models.ShippingMethod
+	if err := database.DB.Preload("Rules").First(&method, "code = ?", "standard").Error; err != nil {
+		t.Fatalf("method not created: %v", err)
+	}
+	if len(method.Rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(method.Rules))
+	}
+
+	resp, err = testutil.PostForm(ts, "/shipping", cookies, url.Values{"code": {"standard"}, "name": {"Dup"}})
+	if err != nil {
+		t.Fatalf("store failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected duplicate code to re-render the form, got %d", resp.StatusCode)
+	}
+
+	resp, err = testutil.PostForm(ts, "/shipping/"+method.ID, cookies, url.Values{
+		"code":          {"standard"},
+		"name":          {"Tiêu chuẩn"},
+		"rule_province": {""},
+		"rule_base_fee": {"25000"},
+	})
+	if err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	resp.Body.Close()
+
+	var rules []models.ShippingRule
+	database.DB.Where("method_id = ?", method.ID).Find(&rules)
+	if len(rules) != 1 || rules[0].BaseFee != 25000 || rules[0].Province != "" {
+		t.Errorf("expected rules replaced by a single catch-all rule, got %+v", rules)
+	}
+	database.DB.First(&method, "id = ?", method.ID)
+	if method.IsActive {
+		t.Error("expected method deactivated when is_active is unchecked")
+	}
+
+	resp, err = testutil.PostForm(ts, "/shipping/"+method.ID+"/delete", cookies, nil)
+	if err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	resp.Body.Close()
+	var count int64
+	database.DB.Model(&models.ShippingMethod{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected method deleted, got %d", count)
+	}
+}
+
+func TestWebCheckout_OutOfStock(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+
+	ts := httptest.NewServer(testutil.NewWebEcho())
+	defer ts.Close()
+
+	checkout := func(qty string) *http.Response {
+		t.Helper()
+		client := shippingClient(t, ts, prod, qty)
+		resp, err := client.PostForm(ts.URL+"/checkout", url.Values{"name": {"A"}, "phone": {"0909"}, "address": {"1 St"}})
+		if err != nil {
+			t.Fatalf("checkout failed: %v", err)
+		}
+		return resp
+	}
+
+	resp := checkout("11")
+	defer resp.Body.Close()
+	var body map[string]string
+	json.NewDecoder(resp.Body).Decode(&body)
+	if resp.StatusCode != http.StatusConflict || !strings.Contains(body["error"], prod.Name) {
+		t.Errorf("expected a conflict naming the product, got %d %q", resp.StatusCode, body["error"])
+	}
+	var p models.Product
+	database.DB.First(&p, "id = ?", prod.ID)
+	var orders int64
+	database.DB.Model(&models.Order{}).Count(&orders)
+	if p.Stock != 10 || orders != 0 {
+		t.Errorf("expected the order rolled back with stock untouched, got stock %d and %d orders", p.Stock, orders)
+	}
+
+	checkout("10").Body.Close()
+	database.DB.First(&p, "id = ?", prod.ID)
+	if p.Stock != 0 {
+		t.Errorf("expected the whole stock sold, got %d left", p.Stock)
+	}
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/tests/testutil"
+)
+
+func TestSitemap(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	database.DB.Create(&models.Image{ProductID: prod.ID, URL: "/uploads/products/tyhuu.jpg"})
+	database.DB.Create(&models.ProductTranslation{ProductID: prod.ID, Locale: "en", Name: "Jade Pi Xiu", Slug: "jade-pi-xiu"})
+
+	web := httptest.NewServer(testutil.NewWebEcho())
+	defer web.Close()
+
+	get := func(path string) string {
+		t.Helper()
+		resp, err := http.Get(web.URL + path)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", path, resp.StatusCode)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	expect := func(body string, want ...string) {
+		t.Helper()
+		for _, w := range want {
+			if !strings.Contains(body, w) {
+				t.Errorf("expected %q in\n%s", w, body)
+			}
+		}
+	}
+
+	index := get("/sitemap.xml")
+	var parsed struct {
+		Sitemaps []struct {
+			Loc string `xml:"loc"`
+		} `xml:"sitemap"`
+	}
+	if err := xml.Unmarshal([]byte(index), &parsed); err != nil || len(parsed.Sitemaps) != 3 {
+		t.Fatalf("expected an index of 3 sitemaps, got %v (%v)", parsed, err)
+	}
+	for _, s := range parsed.Sitemaps {
+		get(strings.TrimPrefix(s.Loc, testutil.BaseURL))
+	}
+
+	products := get("/sitemaps/products.xml")
+	expect(products,
+		"<loc>"+testutil.BaseURL+"/products/"+prod.Slug+"</loc>",
+		"<loc>"+testutil.BaseURL+"/en/products/jade-pi-xiu</loc>",
+		`hreflang="en" href="`+testutil.BaseURL+`/en/products/jade-pi-xiu"`,
+		"<image:loc>"+testutil.BaseURL+"/uploads/products/tyhuu.jpg</image:loc>",
+		"<lastmod>"+prod.UpdatedAt.UTC().Format("2006-01-02T15:04:05Z")+"</lastmod>")
+	expect(get("/sitemaps/categories.xml"), "<loc>"+testutil.BaseURL+"/products?category="+cat.Slug+"</loc>")
+	expect(get("/sitemaps/pages.xml"), "<loc>"+testutil.BaseURL+"/about</loc>", "<loc>"+testutil.BaseURL+"/en/contact</loc>")
+
+	// Catalog changes show up despite the cache.
+	other := models.Product{Name: "Thiềm Thừ", Slug: "thiem-thu", CategoryID: cat.ID, IsActive: true}
+	database.DB.Create(&other)
+	expect(get("/sitemaps/products.xml"), "/products/thiem-thu</loc>")
+	database.DB.Delete(&other)
+	if strings.Contains(get("/sitemaps/products.xml"), "thiem-thu") {
+		t.Error("expected a deleted product to leave the sitemap")
+	}
+	database.DB.Model(&prod).Update("is_active", false)
+	if strings.Contains(get("/sitemaps/products.xml"), prod.Slug) {
+		t.Error("expected a hidden product to leave the sitemap")
+	}
+
+	robots := get("/robots.txt")
+	expect(robots, "Disallow: /cart", "Sitemap: "+testutil.BaseURL+"/sitemap.xml")
+
+	// A forged Host header does not reach the links.
+	req, _ := http.NewRequest("GET", web.URL+"/sitemap.xml", nil)
+	req.Host = "evil.example"
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	forged, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if strings.Contains(string(forged), "evil.example") || !strings.Contains(string(forged), "<loc>"+testutil.BaseURL+"/sitemaps/products.xml</loc>") {
+		t.Errorf("expected the configured base URL whatever the Host, got\n%s", forged)
+	}
+
+	admin := httptest.NewServer(testutil.NewAdminEcho())
+	defer admin.Close()
+	resp, err = testutil.PostForm(admin, "/seo/robots", testutil.AdminLoginCookies(t, admin), url.Values{"rules": {"User-agent: *\r\nDisallow: /private"}})
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	resp.Body.Close()
+	robots = get("/robots.txt")
+	expect(robots, "User-agent: *\nDisallow: /private\n\nSitemap: "+testutil.BaseURL+"/sitemap.xml")
+	if strings.Contains(robots, "/cart") {
+		t.Error("expected the admin's rules to replace the defaults")
+	}
+}
+
+func TestSitemap_StockChanges(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	edited := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	database.DB.Model(&prod).UpdateColumn("updated_at", edited)
+
+	ts := httptest.NewServer(testutil.NewWebEcho())
+	defer ts.Close()
+	lastmod := func() string {
+		t.Helper()
+		resp, err := http.Get(ts.URL + "/sitemaps/products.xml")
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		_, after, _ := strings.Cut(string(body), "<lastmod>")
+		stamp, _, _ := strings.Cut(after, "</lastmod>")
+		return stamp
+	}
+	checkout := func(qty string) {
+		t.Helper()
+		status, out := postCheckout(t, shippingClient(t, ts, prod, qty), ts, map[string]string{"name": "A", "phone": "1", "address": "x"})
+		if status != http.StatusOK {
+			t.Fatalf("expected checkout to succeed, got %d %v", status, out)
+		}
+	}
+
+	want := edited.Format("2006-01-02T15:04:05Z")
+	if got := lastmod(); got != want {
+		t.Fatalf("expected lastmod %s, got %s", want, got)
+	}
+	checkout("3")
+	if got := lastmod(); got != want {
+		t.Errorf("expected an order to leave lastmod at %s, got %s", want, got)
+	}
+	checkout("7")
+	if got := lastmod(); got == want {
+		t.Error("expected selling out to bump lastmod")
+	}
+}
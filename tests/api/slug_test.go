@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/tests/testutil"
+)
+
+func TestSlugs(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	cat := testutil.CreateTestCategory(t)
+
+	admin := httptest.NewServer(testutil.NewAdminEcho())
+	defer admin.Close()
+	cookies := testutil.AdminLoginCookies(t, admin)
+	web := httptest.NewServer(testutil.NewWebEcho())
+	defer web.Close()
+
+	post := func(path string, values url.Values) {
+		t.Helper()
+		resp, err := testutil.PostForm(admin, path, cookies, values)
+		if err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusFound {
+			t.Fatalf("%s: expected 302, got %d", path, resp.StatusCode)
+		}
+	}
+	product := func(values url.Values) url.Values {
+		values.Set("original_price", "650000")
+		values.Set("stock", "3")
+		values.Set("category_id", cat.ID)
+		values.Set("is_active", "on")
+		return values
+	}
+	slugOf := func(sku string) (models.Product, string) {
+		var p models.Product
+		database.DB.First(&p, "sku = ?", sku)
+		return p, p.Slug
+	}
+	redirect := func(path string) (int, string) {
+		t.Helper()
+		resp, err := testutil.GetWithCookies(web, path, nil)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode, resp.Header.Get("Location")
+	}
+
+	// Vietnamese names are transliterated and collisions numbered.
+	post("/products", product(url.Values{"name": {"Vòng tay đá thạch anh"}, "sku": {"VT-1"}}))
+	post("/products", product(url.Values{"name": {"Vòng tay đá thạch anh"}, "sku": {"VT-2"}}))
+	post("/products", product(url.Values{"name": {"Vòng tay"}, "slug": {"Vòng Tay Đá Thạch Anh"}, "sku": {"VT-3"}}))
+	first, slug := slugOf("VT-1")
+	if slug != "vong-tay-da-thach-anh" {
+		t.Errorf("expected vong-tay-da-thach-anh, got %q", slug)
+	}
+	if _, slug := slugOf("VT-2"); slug != "vong-tay-da-thach-anh-2" {
+		t.Errorf("expected the -2 suffix, got %q", slug)
+	}
+	if _, slug := slugOf("VT-3"); slug != "vong-tay-da-thach-anh-3" {
+		t.Errorf("expected the typed slug numbered -3, got %q", slug)
+	}
+
+	// Renaming keeps the slug.
+	post("/products/"+first.ID, product(url.Values{"name": {"Vòng tay thạch anh hồng"}, "slug": {""}, "sku": {"VT-1"}}))
+	if _, slug := slugOf("VT-1"); slug != "vong-tay-da-thach-anh" {
+		t.Errorf("expected the slug kept on rename, got %q", slug)
+	}
+
+	// Editing it redirects the old URL, without chains after a second edit.
+	post("/products/"+first.ID, product(url.Values{"name": {"Vòng tay thạch anh hồng"}, "slug": {"vong-tay-thach-anh-hong"}, "sku": {"VT-1"}}))
+	post("/products/"+first.ID, product(url.Values{"name": {"Vòng tay thạch anh hồng"}, "slug": {"thach-anh-hong"}, "sku": {"VT-1"}}))
+	for _, old := range []string{"vong-tay-da-thach-anh", "vong-tay-thach-anh-hong"} {
+		if code, loc := redirect("/products/" + old + "?ref=zalo"); code != http.StatusMovedPermanently || loc != "/products/thach-anh-hong?ref=zalo" {
+			t.Errorf("/products/%s: expected a 301 to the new slug, got %d %q", old, code, loc)
+		}
+	}
+	if code, _ := redirect("/products/thach-anh-hong"); code != http.StatusOK {
+		t.Errorf("expected the new slug to serve the page, got %d", code)
+	}
+	if code, loc := redirect("/products/khong-co"); code != http.StatusFound || loc != "/products" {
+		t.Errorf("expected unknown slugs to go to the list, got %d %q", code, loc)
+	}
+
+	// Going back to an old slug makes it live again.
+	post("/products/"+first.ID, product(url.Values{"name": {"Vòng tay thạch anh hồng"}, "slug": {"vong-tay-da-thach-anh"}, "sku": {"VT-1"}}))
+	if code, _ := redirect("/products/vong-tay-da-thach-anh"); code != http.StatusOK {
+		t.Errorf("expected the restored slug to serve the page, got %d", code)
+	}
+	var redirects int64
+	database.DB.Model(&models.SlugRedirect{}).Where("slug = ?", "vong-tay-da-thach-anh").Count(&redirects)
+	if redirects != 0 {
+		t.Error("expected the redirect from a live slug removed")
+	}
+
+	// Categories work the same way and keep the other filters.
+	post("/categories/"+cat.ID, url.Values{"name": {"Đá phong thủy"}, "slug": {"da-phong-thuy"}, "is_active": {"on"}})
+	if code, loc := redirect("/products?category=" + cat.Slug + "&q=anh"); code != http.StatusMovedPermanently || loc != "/products?category=da-phong-thuy&q=anh" {
+		t.Errorf("expected a 301 to the new category slug, got %d %q", code, loc)
+	}
+	post("/categories/"+cat.ID, url.Values{"name": {"Đá quý"}, "is_active": {"on"}})
+	var saved models.Category
+	database.DB.First(&saved, "id = ?", cat.ID)
+	if saved.Slug != "da-phong-thuy" {
+		t.Errorf("expected the category slug kept on rename, got %q", saved.Slug)
+	}
+}
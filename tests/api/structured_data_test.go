@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/tests/testutil"
+)
+
+var ldScript = regexp.MustCompile(`(?s)<script type="application/ld\+json">(.*?)</script>`)
+
+func TestStructuredData(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+	database.DB.Create(&models.Image{ProductID: prod.ID, URL: "/uploads/products/tyhuu.jpg"})
+	database.DB.Create(&models.CompanyInfo{Name: "OCC.IO.VN", Phone: "0900000000", FacebookURL: "https://facebook.com/occ"})
+
+	web := httptest.NewServer(testutil.NewWebRenderedEcho())
+	defer web.Close()
+
+	// blocks returns the JSON-LD objects on a page by @type.
+	blocks := func(path string) map[string]map[string]any {
+		t.Helper()
+		resp, err := testutil.GetWithCookies(web, path, nil)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		found := map[string]map[string]any{}
+		for _, m := range ldScript.FindAllSubmatch(body, -1) {
+			var v map[string]any
+			if err := json.Unmarshal(m[1], &v); err != nil {
+				t.Fatalf("%s: invalid JSON-LD %s: %v", path, m[1], err)
+			}
+			found[v["@type"].(string)] = v
+		}
+		return found
+	}
+
+	home := blocks("/")
+	if org := home["Organization"]; org == nil || org["name"] != "OCC.IO.VN" || org["telephone"] != "0900000000" {
+		t.Errorf("expected the organization on the home page, got %v", home)
+	}
+	if home["Product"] != nil {
+		t.Error("expected no product on the home page")
+	}
+
+	page := blocks("/products/" + prod.Slug)
+	if page["Organization"] == nil {
+		t.Error("expected the organization on the product page")
+	}
+	p := page["Product"]
+	if p == nil {
+		t.Fatalf("expected a product, got %v", page)
+	}
+	if p["sku"] != "TEST-001" || p["url"] != testutil.BaseURL+"/products/"+prod.Slug {
+		t.Errorf("unexpected product %v", p)
+	}
+	if images, _ := p["image"].([]any); len(images) != 1 || images[0] != testutil.BaseURL+"/uploads/products/tyhuu.jpg" {
+		t.Errorf("expected absolute image URLs, got %v", p["image"])
+	}
+	offer, _ := p["offers"].(map[string]any)
+	if offer["price"] != float64(80000) || offer["priceCurrency"] != "VND" || offer["availability"] != "https://schema.org/InStock" {
+		t.Errorf("unexpected offer %v", offer)
+	}
+	if spec, _ := offer["priceSpecification"].(map[string]any); spec["price"] != float64(100000) {
+		t.Errorf("expected the original price as strikethrough, got %v", offer["priceSpecification"])
+	}
+	if _, ok := p["aggregateRating"]; ok {
+		t.Error("expected no rating without reviews")
+	}
+
+	crumbs, _ := page["BreadcrumbList"]["itemListElement"].([]any)
+	if len(crumbs) != 4 {
+		t.Fatalf("expected home, products, category and product crumbs, got %v", crumbs)
+	}
+	if third := crumbs[2].(map[string]any); third["name"] != cat.Name || third["item"] != testutil.BaseURL+"/products?category="+cat.Slug {
+		t.Errorf("unexpected category crumb %v", third)
+	}
+
+	database.DB.Model(&prod).Updates(map[string]any{"stock": 0, "rating_average": 4.5, "rating_count": 2})
+	p = blocks("/products/" + prod.Slug)["Product"]
+	if offer, _ := p["offers"].(map[string]any); offer["availability"] != "https://schema.org/OutOfStock" {
+		t.Errorf("expected out of stock, got %v", offer)
+	}
+	if rating, _ := p["aggregateRating"].(map[string]any); rating["ratingValue"] != 4.5 || rating["reviewCount"] != float64(2) {
+		t.Errorf("unexpected rating %v", p["aggregateRating"])
+	}
+}
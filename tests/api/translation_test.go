@@ -0,0 +1,108 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/tests/testutil"
+)
+
+func TestCatalogTranslations(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	testutil.CreateTestAdmin(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+
+	admin := httptest.NewServer(testutil.NewAdminEcho())
+	defer admin.Close()
+	cookies := testutil.AdminLoginCookies(t, admin)
+
+	resp, err := testutil.PostForm(admin, "/products/"+prod.ID, cookies, url.Values{
+		"name":           {prod.Name},
+		"description":    {"Mô tả"},
+		"original_price": {"100000"},
+		"stock":          {"10"},
+		"category_id":    {cat.ID},
+		"is_active":      {"on"},
+		"name_en":        {"Jade Dragon"},
+		"description_en": {""},
+	})
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected 302, got %d", resp.StatusCode)
+	}
+	database.DB.First(&prod, "id = ?", prod.ID)
+
+	var tr models.ProductTranslation
+	if err := database.DB.First(&tr, "product_id = ? AND locale = ?", prod.ID, "en").Error; err != nil {
+		t.Fatalf("expected an en translation: %v", err)
+	}
+	if tr.Slug != "jade-dragon" {
+		t.Errorf("expected slug jade-dragon, got %q", tr.Slug)
+	}
+
+	web := httptest.NewServer(testutil.NewWebRenderedEcho())
+	defer web.Close()
+	get := func(c *http.Client, path string) string {
+		t.Helper()
+		resp, err := c.Get(web.URL + path)
+		if err != nil {
+			t.Fatalf("get %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("get %s: status %d", path, resp.StatusCode)
+		}
+		return string(body)
+	}
+	jar, _ := cookiejar.New(nil)
+	en := &http.Client{Jar: jar}
+
+	body := get(en, "/en/products/jade-dragon")
+	if !strings.Contains(body, "Jade Dragon") {
+		t.Error("expected the English name on the English slug")
+	}
+	if !strings.Contains(body, "Mô tả") {
+		t.Error("expected the empty English description to fall back to Vietnamese")
+	}
+	if body := get(en, "/en/products/"+prod.Slug); !strings.Contains(body, "Jade Dragon") {
+		t.Error("expected the Vietnamese slug to resolve in English too")
+	}
+	if body := get(en, "/en/products?q=dragon"); !strings.Contains(body, "/products/jade-dragon") {
+		t.Error("expected search to match the English name and link the English slug")
+	}
+
+	vi := &http.Client{}
+	if body := get(vi, "/products/"+prod.Slug); strings.Contains(body, "Jade Dragon") || !strings.Contains(body, prod.Name) {
+		t.Error("expected the Vietnamese page to keep the default name")
+	}
+
+	// Clearing the English fields removes the translation.
+	resp, err = testutil.PostForm(admin, "/products/"+prod.ID, cookies, url.Values{
+		"name":           {prod.Name},
+		"original_price": {"100000"},
+		"category_id":    {cat.ID},
+		"is_active":      {"on"},
+	})
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	resp.Body.Close()
+	var count int64
+	database.DB.Unscoped().Model(&models.ProductTranslation{}).Where("product_id = ?", prod.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("expected the translation to be removed, found %d", count)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http/cookiejar"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"shoop-golang/database"
@@ -432,6 +433,22 @@ func TestWebCart_AddUnauthenticated(t *testing.T) {
 	if body["error"] != "login_required" {
 		t.Errorf("expected error login_required, got %s", body["error"])
 	}
+	if body["code"] != "login_required" || body["message"] != "Vui lòng đăng nhập để thêm sản phẩm vào giỏ" {
+		t.Errorf("expected code login_required with a translated message, got %v", body)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/cart/add", strings.NewReader(url.Values{"product_id": {prod.ID}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept-Language", "en")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	defer resp.Body.Close()
+	json.NewDecoder(resp.Body).Decode(&body)
+	if body["code"] != "login_required" || body["message"] != "Please log in to add products to your cart" {
+		t.Errorf("expected the login message in English, got %v", body)
+	}
 }
 
 func TestWebCart_AddAuthenticated(t *testing.T) {
@@ -607,6 +624,9 @@ func TestWebCheckout_Unauthenticated(t *testing.T) {
 	if body["error"] != "login_required" {
 		t.Errorf("expected error login_required, got %s", body["error"])
 	}
+	if body["message"] != "Vui lòng đăng nhập để đặt hàng" {
+		t.Errorf("expected a translated login message, got %s", body["message"])
+	}
 }
 
 func TestWebCheckout_EmptyCart(t *testing.T) {
@@ -714,4 +734,10 @@ func TestWebCheckout_Success(t *testing.T) {
 	if len(order.Items) != 1 {
 		t.Errorf("expected 1 order item, got %d", len(order.Items))
 	}
+	if order.Locale != "vi" {
+		t.Errorf("expected the order to keep the shopper's locale, got %q", order.Locale)
+	}
+	if order.Number == "" || body["order_number"] != order.Number {
+		t.Errorf("expected order_number %q in response, got %v", order.Number, body["order_number"])
+	}
 }
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/internal/notifications"
+	"shoop-golang/pkg/mail"
+	"shoop-golang/tests/testutil"
+)
+
+func TestWishlist(t *testing.T) {
+	testutil.SetupTestDB(t)
+	testutil.SetupSession()
+	user := testutil.CreateTestUser(t)
+	cat := testutil.CreateTestCategory(t)
+	prod := testutil.CreateTestProduct(t, cat.ID)
+
+	web := httptest.NewServer(testutil.NewWebRenderedEcho())
+	defer web.Close()
+
+	post := func(path string, cookies []*http.Cookie) (int, map[string]any) {
+		t.Helper()
+		resp, err := testutil.PostForm(web, path, cookies, url.Values{"product_id": {prod.ID}})
+		if err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+		defer resp.Body.Close()
+		var out map[string]any
+		json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+
+	if status, out := post("/wishlist/add", nil); status != http.StatusUnauthorized || out["error"] != "login_required" {
+		t.Fatalf("expected 401 login_required for guests, got %d %v", status, out)
+	}
+
+	cookies := testutil.WebLoginCookies(t, web)
+	post("/wishlist/add", cookies)
+	if status, out := post("/wishlist/add", cookies); status != http.StatusOK || out["wishlisted"] != true || out["wishlistCount"] != float64(1) {
+		t.Fatalf("expected adding twice to keep one entry, got %d %v", status, out)
+	}
+
+	resp, err := testutil.GetWithCookies(web, "/account/wishlist", cookies)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), prod.Name) || !strings.Contains(string(body), "Chuyển vào giỏ") {
+		t.Error("expected the saved product with a move-to-cart button")
+	}
+
+	if status, out := post("/wishlist/remove", cookies); status != http.StatusOK || out["wishlisted"] != false || out["wishlistCount"] != float64(0) {
+		t.Fatalf("expected the entry removed, got %d %v", status, out)
+	}
+
+	post("/wishlist/add", cookies)
+	if status, out := post("/wishlist/move", cookies); status != http.StatusOK || out["cartCount"] != float64(1) || out["wishlistCount"] != float64(0) {
+		t.Fatalf("expected the product moved to the cart, got %d %v", status, out)
+	}
+
+	t.Run("notifications", func(t *testing.T) {
+		testutil.CreateTestAdmin(t)
+		database.DB.Model(&prod).Update("stock", 0)
+		database.DB.Create(&models.Wishlist{UserID: user.ID, ProductID: prod.ID, Locale: "en"})
+
+		admin := httptest.NewServer(testutil.NewAdminEcho())
+		defer admin.Close()
+		adminCookies := testutil.AdminLoginCookies(t, admin)
+		update := func(salePrice, stock string) {
+			t.Helper()
+			resp, err := testutil.PostForm(admin, "/products/"+prod.ID, adminCookies, url.Values{
+				"name":           {prod.Name},
+				"original_price": {"100000"},
+				"sale_price":     {salePrice},
+				"stock":          {stock},
+				"category_id":    {cat.ID},
+				"is_active":      {"on"},
+			})
+			if err != nil {
+				t.Fatalf("post failed: %v", err)
+			}
+			resp.Body.Close()
+		}
+		kinds := func() []string {
+			var out []string
+			database.DB.Model(&models.Notification{}).Where("status = ?", models.NotificationQueued).Order("kind").Pluck("kind", &out)
+			return out
+		}
+
+		update("80000", "0")
+		if got := kinds(); len(got) != 0 {
+			t.Fatalf("expected no notifications without a change, got %v", got)
+		}
+		update("70000", "5")
+		if got := kinds(); len(got) != 2 || got[0] != models.NotifyPriceDrop || got[1] != models.NotifyRestock {
+			t.Fatalf("expected price_drop and restock, got %v", got)
+		}
+		update("60000", "5")
+		if got := kinds(); len(got) != 2 {
+			t.Fatalf("expected a queued notification not to be repeated, got %v", got)
+		}
+
+		rec := &mail.Recorder{}
+		mail.SetSender(rec)
+		defer mail.SetSender(mail.Log{})
+		sent, err := notifications.Deliver(database.DB, "https://shop.test", 10)
+		if err != nil || sent != 2 {
+			t.Fatalf("expected 2 sent, got %d (%v)", sent, err)
+		}
+		if got := kinds(); len(got) != 0 {
+			t.Errorf("expected the outbox drained, got %v", got)
+		}
+		msgs := rec.Sent()
+		if len(msgs) != 2 || msgs[0].To != user.Email {
+			t.Fatalf("unexpected messages %+v", msgs)
+		}
+		if !strings.Contains(msgs[0].Body, "https://shop.test/en/products/"+prod.Slug) {
+			t.Errorf("expected an English product link, got %q", msgs[0].Body)
+		}
+	})
+}
@@ -10,6 +10,7 @@ import (
 
 	"shoop-golang/database"
 	"shoop-golang/internal/models"
+	"shoop-golang/pkg/money"
 	"shoop-golang/tests/testutil"
 )
 
@@ -196,9 +197,9 @@ func TestE2E_CustomerRegistrationAndPurchaseFlow(t *testing.T) {
 	if len(order.Items) != 2 {
 		t.Errorf("step 11: expected 2 items, got %d", len(order.Items))
 	}
-	expectedTotal := 80000.0 + 45000.0 // prod1 sale 80000, prod2 sale 45000
+	expectedTotal := money.Money(80000 + 45000) // prod1 sale 80000, prod2 sale 45000
 	if order.TotalAmount != expectedTotal {
-		t.Errorf("step 11: expected total %.0f, got %.0f", expectedTotal, order.TotalAmount)
+		t.Errorf("step 11: expected total %d, got %d", expectedTotal, order.TotalAmount)
 	}
 
 	// 12. Verify cart is empty after checkout
@@ -332,7 +333,7 @@ func TestE2E_AdminCategoryProductManagement(t *testing.T) {
 	// 8. Verify updated price in DB
 	database.DB.First(&prod, "id = ?", prod.ID)
 	if prod.SalePrice != 95000 {
-		t.Errorf("step 8: expected sale_price 95000, got %.0f", prod.SalePrice)
+		t.Errorf("step 8: expected sale_price 95000, got %d", prod.SalePrice)
 	}
 
 	// 9. Delete product (soft delete)
@@ -15,6 +15,7 @@ import (
 	webHandlers "shoop-golang/internal/handlers/web"
 	"shoop-golang/internal/middleware"
 	"shoop-golang/internal/models"
+	"shoop-golang/pkg/payments"
 	"shoop-golang/pkg/session"
 	"shoop-golang/pkg/utils"
 
@@ -46,12 +47,51 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 		&models.CompanyInfo{},
 		&models.AboutPage{},
 		&models.SEOBanner{},
+		&models.Invoice{},
+		&models.Sequence{},
+		&models.ShippingMethod{},
+		&models.ShippingRule{},
+		&models.Province{},
+		&models.District{},
+		&models.Ward{},
+		&models.UserAddress{},
+		&models.Payment{},
+		&models.Refund{},
+		&models.RefundItem{},
+		&models.ExchangeRate{},
+		&models.ProductTranslation{},
+		&models.CategoryTranslation{},
+		&models.BannerTranslation{},
+		&models.AboutPageTranslation{},
+		&models.SEOBannerTranslation{},
+		&models.Review{},
+		&models.ReviewPhoto{},
+		&models.Wishlist{},
+		&models.Notification{},
+		&models.StockSubscription{},
+		&models.RobotsConfig{},
+		&models.SlugRedirect{},
+		&models.Attribute{},
+		&models.AttributeValue{},
+		&models.ProductAttribute{},
+		&models.Tag{},
+		&models.ProductAffinity{},
+		&models.RecentView{},
+		&models.ProductView{},
 	)
 
 	database.DB = db
+	SetupPayments()
 	return db
 }
 
+// SetupPayments registers the providers the store starts with when no bank
+// account is configured: cash on delivery only.
+func SetupPayments() {
+	payments.Reset()
+	payments.RegisterDefaults(payments.VietQR{})
+}
+
 func SetupTestDBWithSeed(t *testing.T) *gorm.DB {
 	t.Helper()
 	db := SetupTestDB(t)
@@ -59,6 +99,10 @@ func SetupTestDBWithSeed(t *testing.T) *gorm.DB {
 	return db
 }
 
+// BaseURL stands in for config.BaseURL: the public address web handlers
+// build absolute links from, whatever host the test server listens on.
+const BaseURL = "https://shop.example.vn"
+
 func SetupSession() {
 	session.Init("test-secret-key")
 }
@@ -75,10 +119,12 @@ func (n *NoopRenderer) Render(w io.Writer, name string, data interface{}, c echo
 func NewAdminEcho() *echo.Echo {
 	e := echo.New()
 	e.Renderer = &NoopRenderer{}
+	e.Use(middleware.AdminLocale)
 
 	e.GET("/login", adminHandlers.LoginPage)
 	e.POST("/login", adminHandlers.Login)
 	e.GET("/logout", adminHandlers.Logout)
+	e.GET("/lang/:code", adminHandlers.SetLanguage)
 
 	admin := e.Group("", middleware.AdminAuth)
 	admin.GET("/dashboard", adminHandlers.Dashboard)
@@ -92,8 +138,17 @@ func NewAdminEcho() *echo.Echo {
 	admin.GET("/categories/:id/edit", adminHandlers.CategoryEdit)
 	admin.POST("/categories/:id", adminHandlers.CategoryUpdate)
 	admin.POST("/categories/:id/delete", adminHandlers.CategoryDelete)
+	admin.GET("/attributes", adminHandlers.AttributeList)
+	admin.GET("/attributes/create", adminHandlers.AttributeCreate)
+	admin.POST("/attributes", adminHandlers.AttributeStore)
+	admin.GET("/attributes/:id/edit", adminHandlers.AttributeEdit)
+	admin.POST("/attributes/:id", adminHandlers.AttributeUpdate)
+	admin.POST("/attributes/:id/delete", adminHandlers.AttributeDelete)
+	admin.POST("/tags/:id/delete", adminHandlers.TagDelete)
 
 	admin.GET("/products", adminHandlers.ProductList)
+	admin.GET("/products/export", adminHandlers.ProductExport)
+	admin.POST("/products/bulk", adminHandlers.ProductBulk)
 	admin.GET("/products/create", adminHandlers.ProductCreate)
 	admin.POST("/products", adminHandlers.ProductStore)
 	admin.GET("/products/:id/edit", adminHandlers.ProductEdit)
@@ -102,8 +157,24 @@ func NewAdminEcho() *echo.Echo {
 	admin.POST("/images/:id/delete", adminHandlers.ImageDelete)
 
 	admin.GET("/orders", adminHandlers.OrderList)
+	admin.GET("/orders/export", adminHandlers.OrderExport)
+	admin.POST("/orders/documents", adminHandlers.OrderDocumentsBatch)
 	admin.GET("/orders/:id", adminHandlers.OrderDetail)
 	admin.POST("/orders/:id/status", adminHandlers.OrderUpdateStatus)
+	admin.POST("/orders/:id/refund", adminHandlers.OrderRefund)
+	admin.GET("/orders/:id/invoice", adminHandlers.OrderInvoice)
+	admin.GET("/orders/:id/packing-slip", adminHandlers.OrderPackingSlip)
+
+	admin.GET("/reviews", adminHandlers.ReviewList)
+	admin.POST("/reviews/:id/status", adminHandlers.ReviewUpdateStatus)
+	admin.POST("/reviews/:id/reply", adminHandlers.ReviewReply)
+
+	admin.GET("/shipping", adminHandlers.ShippingList)
+	admin.GET("/shipping/create", adminHandlers.ShippingCreate)
+	admin.POST("/shipping", adminHandlers.ShippingStore)
+	admin.GET("/shipping/:id/edit", adminHandlers.ShippingEdit)
+	admin.POST("/shipping/:id", adminHandlers.ShippingUpdate)
+	admin.POST("/shipping/:id/delete", adminHandlers.ShippingDelete)
 
 	admin.GET("/users", adminHandlers.UserList)
 	admin.GET("/users/:id", adminHandlers.UserDetail)
@@ -115,8 +186,19 @@ func NewAdminEcho() *echo.Echo {
 	admin.POST("/banners/:id", adminHandlers.BannerUpdate)
 	admin.POST("/banners/:id/delete", adminHandlers.BannerDelete)
 
+	admin.GET("/seo", adminHandlers.SEOList)
+	admin.GET("/seo/create", adminHandlers.SEOCreate)
+	admin.GET("/seo/targets", adminHandlers.SEOTargets)
+	admin.POST("/seo", adminHandlers.SEOStore)
+	admin.POST("/seo/robots", adminHandlers.SEORobotsUpdate)
+	admin.GET("/seo/:id/edit", adminHandlers.SEOEdit)
+	admin.POST("/seo/:id", adminHandlers.SEOUpdate)
+	admin.POST("/seo/:id/delete", adminHandlers.SEODelete)
+
 	admin.GET("/company", adminHandlers.CompanyEdit)
 	admin.POST("/company", adminHandlers.CompanyUpdate)
+	admin.GET("/exchange-rates", adminHandlers.ExchangeRateEdit)
+	admin.POST("/exchange-rates", adminHandlers.ExchangeRateUpdate)
 
 	admin.GET("/about", adminHandlers.AboutEdit)
 	admin.POST("/about", adminHandlers.AboutUpdate)
@@ -125,43 +207,123 @@ func NewAdminEcho() *echo.Echo {
 }
 
 func NewWebEcho() *echo.Echo {
+	webHandlers.SetBaseURL(BaseURL)
 	e := echo.New()
 	e.Renderer = &NoopRenderer{}
+	e.Pre(middleware.WebLocale)
 	e.Use(middleware.WebUserContext)
 
 	e.GET("/", webHandlers.Home)
 	e.GET("/products", webHandlers.ProductList)
 	e.GET("/products/:slug", webHandlers.ProductDetail)
+	e.POST("/products/:slug/reviews", webHandlers.ReviewStore, middleware.WebAuth)
+	e.POST("/products/:slug/notify", webHandlers.NotifyMeStore)
 	e.POST("/register", webHandlers.Register)
 	e.POST("/login", webHandlers.Login)
 	e.GET("/logout", webHandlers.Logout)
+	e.GET("/currency/:code", webHandlers.SetCurrency)
+	e.GET("/lang/:code", webHandlers.SetLanguage)
+
 	e.GET("/cart", webHandlers.CartPage)
 	e.POST("/cart/add", webHandlers.AddToCart)
 	e.POST("/cart/update", webHandlers.UpdateCart)
+	e.POST("/wishlist/add", webHandlers.AddToWishlist)
+	e.POST("/wishlist/remove", webHandlers.RemoveFromWishlist)
+	e.POST("/wishlist/move", webHandlers.MoveWishlistToCart)
+	e.GET("/notify/confirm/:token", webHandlers.NotifyConfirmPage)
+	e.POST("/notify/confirm/:token", webHandlers.NotifyConfirm)
+	e.GET("/notify/unsubscribe/:token", webHandlers.NotifyUnsubscribePage)
+	e.POST("/notify/unsubscribe/:token", webHandlers.NotifyUnsubscribe)
+	e.GET("/cart/shipping", webHandlers.ShippingQuote)
 	e.POST("/checkout", webHandlers.Checkout)
+	e.POST("/payments/webhook/:provider", webHandlers.PaymentWebhook)
+	pay := e.Group("/payments", middleware.WebAuth)
+	pay.GET("/:number", webHandlers.PaymentPage)
+	pay.GET("/:number/status", webHandlers.PaymentStatus)
+	pay.GET("/:number/qr.png", webHandlers.PaymentQR)
+	e.GET("/locations/provinces", webHandlers.ProvinceList)
+	e.GET("/locations/provinces/:code/districts", webHandlers.DistrictList)
+	e.GET("/locations/districts/:code/wards", webHandlers.WardList)
+	account := e.Group("/account", middleware.WebAuth)
+	account.GET("/orders", webHandlers.AccountOrderList)
+	account.GET("/orders/:number", webHandlers.AccountOrderDetail)
+	account.GET("/addresses", webHandlers.AddressList)
+	account.POST("/addresses", webHandlers.AddressStore)
+	account.GET("/addresses/:id/edit", webHandlers.AddressEdit)
+	account.POST("/addresses/:id", webHandlers.AddressUpdate)
+	account.POST("/addresses/:id/delete", webHandlers.AddressDelete)
+	account.POST("/addresses/:id/default", webHandlers.AddressSetDefault)
+	account.GET("/wishlist", webHandlers.WishlistPage)
+	account.POST("/element", webHandlers.SaveElementProfile)
 	e.GET("/about", webHandlers.AboutPage)
 	e.GET("/contact", webHandlers.ContactPage)
+	e.GET("/feeds/google.xml", webHandlers.GoogleMerchantFeed)
+	e.GET("/sitemap.xml", webHandlers.Sitemap)
+	e.GET("/sitemaps/pages.xml", webHandlers.SitemapPages)
+	e.GET("/sitemaps/categories.xml", webHandlers.SitemapCategories)
+	e.GET("/sitemaps/products.xml", webHandlers.SitemapProducts)
+	e.GET("/robots.txt", webHandlers.RobotsTxt)
 
 	return e
 }
 
 func NewWebRenderedEcho() *echo.Echo {
+	webHandlers.SetBaseURL(BaseURL)
 	e := echo.New()
 	e.Renderer = utils.NewWebRenderer("../../templates")
+	e.Pre(middleware.WebLocale)
 	e.Use(middleware.WebUserContext)
 
 	e.GET("/", webHandlers.Home)
 	e.GET("/products", webHandlers.ProductList)
 	e.GET("/products/:slug", webHandlers.ProductDetail)
+	e.POST("/products/:slug/reviews", webHandlers.ReviewStore, middleware.WebAuth)
+	e.POST("/products/:slug/notify", webHandlers.NotifyMeStore)
 	e.POST("/register", webHandlers.Register)
 	e.POST("/login", webHandlers.Login)
 	e.GET("/logout", webHandlers.Logout)
+	e.GET("/currency/:code", webHandlers.SetCurrency)
+	e.GET("/lang/:code", webHandlers.SetLanguage)
+
 	e.GET("/cart", webHandlers.CartPage)
 	e.POST("/cart/add", webHandlers.AddToCart)
 	e.POST("/cart/update", webHandlers.UpdateCart)
+	e.POST("/wishlist/add", webHandlers.AddToWishlist)
+	e.POST("/wishlist/remove", webHandlers.RemoveFromWishlist)
+	e.POST("/wishlist/move", webHandlers.MoveWishlistToCart)
+	e.GET("/notify/confirm/:token", webHandlers.NotifyConfirmPage)
+	e.POST("/notify/confirm/:token", webHandlers.NotifyConfirm)
+	e.GET("/notify/unsubscribe/:token", webHandlers.NotifyUnsubscribePage)
+	e.POST("/notify/unsubscribe/:token", webHandlers.NotifyUnsubscribe)
+	e.GET("/cart/shipping", webHandlers.ShippingQuote)
 	e.POST("/checkout", webHandlers.Checkout)
+	e.POST("/payments/webhook/:provider", webHandlers.PaymentWebhook)
+	pay := e.Group("/payments", middleware.WebAuth)
+	pay.GET("/:number", webHandlers.PaymentPage)
+	pay.GET("/:number/status", webHandlers.PaymentStatus)
+	pay.GET("/:number/qr.png", webHandlers.PaymentQR)
+	e.GET("/locations/provinces", webHandlers.ProvinceList)
+	e.GET("/locations/provinces/:code/districts", webHandlers.DistrictList)
+	e.GET("/locations/districts/:code/wards", webHandlers.WardList)
+	account := e.Group("/account", middleware.WebAuth)
+	account.GET("/orders", webHandlers.AccountOrderList)
+	account.GET("/orders/:number", webHandlers.AccountOrderDetail)
+	account.GET("/addresses", webHandlers.AddressList)
+	account.POST("/addresses", webHandlers.AddressStore)
+	account.GET("/addresses/:id/edit", webHandlers.AddressEdit)
+	account.POST("/addresses/:id", webHandlers.AddressUpdate)
+	account.POST("/addresses/:id/delete", webHandlers.AddressDelete)
+	account.POST("/addresses/:id/default", webHandlers.AddressSetDefault)
+	account.GET("/wishlist", webHandlers.WishlistPage)
+	account.POST("/element", webHandlers.SaveElementProfile)
 	e.GET("/about", webHandlers.AboutPage)
 	e.GET("/contact", webHandlers.ContactPage)
+	e.GET("/feeds/google.xml", webHandlers.GoogleMerchantFeed)
+	e.GET("/sitemap.xml", webHandlers.Sitemap)
+	e.GET("/sitemaps/pages.xml", webHandlers.SitemapPages)
+	e.GET("/sitemaps/categories.xml", webHandlers.SitemapCategories)
+	e.GET("/sitemaps/products.xml", webHandlers.SitemapProducts)
+	e.GET("/robots.txt", webHandlers.RobotsTxt)
 
 	return e
 }
@@ -169,10 +331,12 @@ func NewWebRenderedEcho() *echo.Echo {
 func NewAdminRenderedEcho() *echo.Echo {
 	e := echo.New()
 	e.Renderer = utils.NewAdminRenderer("../../templates")
+	e.Use(middleware.AdminLocale)
 
 	e.GET("/login", adminHandlers.LoginPage)
 	e.POST("/login", adminHandlers.Login)
 	e.GET("/logout", adminHandlers.Logout)
+	e.GET("/lang/:code", adminHandlers.SetLanguage)
 
 	admin := e.Group("", middleware.AdminAuth)
 	admin.GET("/dashboard", adminHandlers.Dashboard)
@@ -182,16 +346,41 @@ func NewAdminRenderedEcho() *echo.Echo {
 	admin.GET("/categories/:id/edit", adminHandlers.CategoryEdit)
 	admin.POST("/categories/:id", adminHandlers.CategoryUpdate)
 	admin.POST("/categories/:id/delete", adminHandlers.CategoryDelete)
+	admin.GET("/attributes", adminHandlers.AttributeList)
+	admin.GET("/attributes/create", adminHandlers.AttributeCreate)
+	admin.POST("/attributes", adminHandlers.AttributeStore)
+	admin.GET("/attributes/:id/edit", adminHandlers.AttributeEdit)
+	admin.POST("/attributes/:id", adminHandlers.AttributeUpdate)
+	admin.POST("/attributes/:id/delete", adminHandlers.AttributeDelete)
+	admin.POST("/tags/:id/delete", adminHandlers.TagDelete)
 
 	admin.GET("/products", adminHandlers.ProductList)
+	admin.GET("/products/export", adminHandlers.ProductExport)
+	admin.POST("/products/bulk", adminHandlers.ProductBulk)
 	admin.POST("/products", adminHandlers.ProductStore)
 	admin.GET("/products/:id/edit", adminHandlers.ProductEdit)
 	admin.POST("/products/:id", adminHandlers.ProductUpdate)
 	admin.POST("/products/:id/delete", adminHandlers.ProductDelete)
 
 	admin.GET("/orders", adminHandlers.OrderList)
+	admin.GET("/orders/export", adminHandlers.OrderExport)
+	admin.POST("/orders/documents", adminHandlers.OrderDocumentsBatch)
 	admin.GET("/orders/:id", adminHandlers.OrderDetail)
 	admin.POST("/orders/:id/status", adminHandlers.OrderUpdateStatus)
+	admin.POST("/orders/:id/refund", adminHandlers.OrderRefund)
+	admin.GET("/orders/:id/invoice", adminHandlers.OrderInvoice)
+	admin.GET("/orders/:id/packing-slip", adminHandlers.OrderPackingSlip)
+
+	admin.GET("/reviews", adminHandlers.ReviewList)
+	admin.POST("/reviews/:id/status", adminHandlers.ReviewUpdateStatus)
+	admin.POST("/reviews/:id/reply", adminHandlers.ReviewReply)
+
+	admin.GET("/shipping", adminHandlers.ShippingList)
+	admin.GET("/shipping/create", adminHandlers.ShippingCreate)
+	admin.POST("/shipping", adminHandlers.ShippingStore)
+	admin.GET("/shipping/:id/edit", adminHandlers.ShippingEdit)
+	admin.POST("/shipping/:id", adminHandlers.ShippingUpdate)
+	admin.POST("/shipping/:id/delete", adminHandlers.ShippingDelete)
 
 	admin.GET("/users", adminHandlers.UserList)
 	admin.GET("/users/:id", adminHandlers.UserDetail)
@@ -201,8 +390,19 @@ func NewAdminRenderedEcho() *echo.Echo {
 	admin.POST("/banners/:id", adminHandlers.BannerUpdate)
 	admin.POST("/banners/:id/delete", adminHandlers.BannerDelete)
 
+	admin.GET("/seo", adminHandlers.SEOList)
+	admin.GET("/seo/create", adminHandlers.SEOCreate)
+	admin.GET("/seo/targets", adminHandlers.SEOTargets)
+	admin.POST("/seo", adminHandlers.SEOStore)
+	admin.POST("/seo/robots", adminHandlers.SEORobotsUpdate)
+	admin.GET("/seo/:id/edit", adminHandlers.SEOEdit)
+	admin.POST("/seo/:id", adminHandlers.SEOUpdate)
+	admin.POST("/seo/:id/delete", adminHandlers.SEODelete)
+
 	admin.GET("/company", adminHandlers.CompanyEdit)
 	admin.POST("/company", adminHandlers.CompanyUpdate)
+	admin.GET("/exchange-rates", adminHandlers.ExchangeRateEdit)
+	admin.POST("/exchange-rates", adminHandlers.ExchangeRateUpdate)
 
 	admin.GET("/about", adminHandlers.AboutEdit)
 	admin.POST("/about", adminHandlers.AboutUpdate)
@@ -285,6 +485,7 @@ func CreateTestOrder(t *testing.T, userID, productID string) models.Order {
 	order := models.Order{
 		UserID:      userID,
 		Status:      "pending",
+		Subtotal:    80000,
 		TotalAmount: 80000,
 		Name:        "Test User",
 		Phone:       "0909111222",
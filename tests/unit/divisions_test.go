@@ -0,0 +1,32 @@
+package unit
+
+import (
+	"testing"
+
+	"shoop-golang/database/seeders"
+	"shoop-golang/internal/models"
+	"shoop-golang/tests/testutil"
+)
+
+func TestSeedDivisionsUpserts(t *testing.T) {
+	db := testutil.SetupTestDBWithSeed(t)
+
+	var before int64
+	db.Model(&models.Ward{}).Count(&before)
+	// An outdated name from an older dataset is corrected on the next seed.
+	db.Model(&models.Province{}).Where("code = ?", "01").Update("name", "Hà Nội cũ")
+	db.Delete(&models.Ward{}, "code = ?", "00001")
+
+	seeders.Seed(db)
+
+	var province models.Province
+	db.First(&province, "code = ?", "01")
+	if province.Name != "Thành phố Hà Nội" {
+		t.Errorf("expected the province renamed from the dataset, got %q", province.Name)
+	}
+	var after int64
+	db.Model(&models.Ward{}).Count(&after)
+	if after != before {
+		t.Errorf("expected %d wards after reseeding, got %d", before, after)
+	}
+}
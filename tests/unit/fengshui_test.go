@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"reflect"
+	"testing"
+
+	"shoop-golang/pkg/fengshui"
+)
+
+func TestFengshuiLookup(t *testing.T) {
+	tests := []struct {
+		year    int
+		gender  string
+		canChi  string
+		napAm   string
+		element string
+		trigram string
+	}{
+		{1984, fengshui.Male, "Giáp Tý", "Hải Trung Kim", fengshui.Kim, "Đoài"},
+		{1984, fengshui.Female, "Giáp Tý", "Hải Trung Kim", fengshui.Kim, "Cấn"},
+		{1990, fengshui.Male, "Canh Ngọ", "Lộ Bàng Thổ", fengshui.Tho, "Khảm"},
+		{1990, fengshui.Female, "Canh Ngọ", "Lộ Bàng Thổ", fengshui.Tho, "Cấn"},
+		{2000, fengshui.Male, "Canh Thìn", "Bạch Lạp Kim", fengshui.Kim, "Ly"},
+		{2000, fengshui.Female, "Canh Thìn", "Bạch Lạp Kim", fengshui.Kim, "Càn"},
+		{1983, "", "Quý Hợi", "Đại Hải Thủy", fengshui.Thuy, ""},
+		{1988, "other", "Mậu Thìn", "Đại Lâm Mộc", fengshui.Moc, ""},
+		{1995, "", "Ất Hợi", "Sơn Đầu Hỏa", fengshui.Hoa, ""},
+		{1900, "", "Canh Tý", "Bích Thượng Thổ", fengshui.Tho, ""},
+	}
+	for _, tt := range tests {
+		p, ok := fengshui.Lookup(tt.year, tt.gender)
+		if !ok {
+			t.Fatalf("Lookup(%d) refused", tt.year)
+		}
+		if p.CanChi != tt.canChi || p.NapAm != tt.napAm || p.Element != tt.element || p.Trigram != tt.trigram {
+			t.Errorf("Lookup(%d, %q) = %+v", tt.year, tt.gender, p)
+		}
+	}
+
+	for _, year := range []int{1899, 2101} {
+		if _, ok := fengshui.Lookup(year, ""); ok {
+			t.Errorf("expected %d refused", year)
+		}
+	}
+
+	// The nạp âm table agrees with the stem and branch counting rule.
+	stem := map[string]int{"Giáp": 1, "Ất": 1, "Bính": 2, "Đinh": 2, "Mậu": 3, "Kỷ": 3, "Canh": 4, "Tân": 4, "Nhâm": 5, "Quý": 5}
+	branch := map[string]int{"Tý": 0, "Sửu": 0, "Ngọ": 0, "Mùi": 0, "Dần": 1, "Mão": 1, "Thân": 1, "Dậu": 1, "Thìn": 2, "Tỵ": 2, "Tuất": 2, "Hợi": 2}
+	byNumber := []string{"", fengshui.Kim, fengshui.Thuy, fengshui.Hoa, fengshui.Tho, fengshui.Moc}
+	for year := 1960; year < 2020; year++ {
+		p, _ := fengshui.Lookup(year, "")
+		var s, b string
+		for i, r := range p.CanChi {
+			if r == ' ' {
+				s, b = p.CanChi[:i], p.CanChi[i+1:]
+			}
+		}
+		n := stem[s] + branch[b]
+		if n > 5 {
+			n -= 5
+		}
+		if byNumber[n] != p.Element {
+			t.Errorf("%d %s: element %s, counting gives %s", year, p.CanChi, p.Element, byNumber[n])
+		}
+	}
+}
+
+func TestFengshuiCompatible(t *testing.T) {
+	want := map[string][]string{
+		fengshui.Kim:  {fengshui.Tho, fengshui.Kim},
+		fengshui.Moc:  {fengshui.Thuy, fengshui.Moc},
+		fengshui.Thuy: {fengshui.Kim, fengshui.Thuy},
+		fengshui.Hoa:  {fengshui.Moc, fengshui.Hoa},
+		fengshui.Tho:  {fengshui.Hoa, fengshui.Tho},
+	}
+	for e, w := range want {
+		if got := fengshui.Compatible(e); !reflect.DeepEqual(got, w) {
+			t.Errorf("Compatible(%s) = %v, want %v", e, got, w)
+		}
+	}
+	if got := fengshui.Conflicting(fengshui.Kim); got != fengshui.Hoa {
+		t.Errorf("Conflicting(kim) = %s, want hoa", got)
+	}
+	if fengshui.Compatible("gold") != nil || fengshui.IsElement("gold") {
+		t.Error("expected unknown elements refused")
+	}
+}
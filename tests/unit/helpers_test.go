@@ -1,18 +1,20 @@
 package unit
 
 import (
+	"strings"
 	"testing"
 
+	"shoop-golang/pkg/money"
 	"shoop-golang/pkg/utils"
 )
 
 func TestFormatPrice(t *testing.T) {
 	funcs := utils.TemplateFuncs()
-	formatPrice := funcs["formatPrice"].(func(float64) string)
+	formatPrice := funcs["formatPrice"].(func(money.Money) string)
 
 	tests := []struct {
 		name  string
-		price float64
+		price money.Money
 		want  string
 	}{
 		{"zero", 0, "Liên hệ"},
@@ -33,12 +35,12 @@ func TestFormatPrice(t *testing.T) {
 
 func TestSalePercent(t *testing.T) {
 	funcs := utils.TemplateFuncs()
-	salePercent := funcs["salePercent"].(func(float64, float64) int)
+	salePercent := funcs["salePercent"].(func(money.Money, money.Money) int)
 
 	tests := []struct {
 		name     string
-		original float64
-		sale     float64
+		original money.Money
+		sale     money.Money
 		want     int
 	}{
 		{"100k_80k", 100000, 80000, 20},
@@ -85,8 +87,13 @@ func TestSlugify(t *testing.T) {
 		want string
 	}{
 		{"hello_world", "Hello World", "hello-world"},
-		{"trimmed_vietnamese", "  Tượng Phong Thủy  ", "t-ng-phong-th-y"},
+		{"trimmed_vietnamese", "  Tượng Phong Thủy  ", "tuong-phong-thuy"},
 		{"multiple_dashes", "test---multiple", "test-multiple"},
+		{"d_stroke", "Vòng tay đá thạch anh", "vong-tay-da-thach-anh"},
+		{"upper_d_stroke", "ĐỒNG TIỀN Ngũ Đế", "dong-tien-ngu-de"},
+		{"all_tones", "ắằẳẵặ ếềểễệ ốồổỗộ ớờởỡợ ứừửữự ỹ", "aaaaa-eeeee-ooooo-ooooo-uuuuu-y"},
+		{"punctuation", "Tỳ hưu (ngọc) - 10cm!", "ty-huu-ngoc-10cm"},
+		{"no_letters", "★ ★", ""},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -103,7 +110,8 @@ func TestTemplateFuncs(t *testing.T) {
 	expected := []string{
 		"formatPrice", "salePercent", "truncate", "safeHTML",
 		"formatDate", "formatDateTime", "seq", "add", "sub",
-		"mul", "mulInt", "statusBadge", "dict",
+		"mul", "mulInt", "statusBadge", "reviewBadge", "stars", "dict",
+		"jsonLD",
 	}
 	for _, name := range expected {
 		t.Run(name, func(t *testing.T) {
@@ -113,3 +121,82 @@ func TestTemplateFuncs(t *testing.T) {
 		})
 	}
 }
+
+func TestJSONLD(t *testing.T) {
+	got, err := utils.JSONLD(map[string]string{"name": "Tỳ hưu </script><script>alert(1)</script> & co"})
+	if err != nil {
+		t.Fatalf("JSONLD: %v", err)
+	}
+	if strings.Contains(string(got), "</script>") || strings.Contains(string(got), "&") {
+		t.Errorf("expected <, > and & escaped, got %s", got)
+	}
+	if !strings.Contains(string(got), `Tỳ hưu \u003c/script\u003e`) {
+		t.Errorf("unexpected encoding %s", got)
+	}
+}
+
+func TestListPrice(t *testing.T) {
+	if got := utils.FormatPrice(0); got != "0₫" {
+		t.Errorf("FormatPrice(0) = %q, want 0₫", got)
+	}
+	if got := utils.ListPrice("en", 0); got != "Contact us" {
+		t.Errorf("ListPrice(en, 0) = %q, want Contact us", got)
+	}
+	if got := utils.ListPrice("vi", 0); got != "Liên hệ" {
+		t.Errorf("ListPrice(vi, 0) = %q, want Liên hệ", got)
+	}
+	if got := utils.ListPrice("en", 80000); got != "80.000₫" {
+		t.Errorf("ListPrice(en, 80000) = %q, want 80.000₫", got)
+	}
+}
+
+func TestFormatPriceIn(t *testing.T) {
+	usd := money.Display{Code: "USD", Rate: 25000}
+	tests := []struct {
+		price   money.Money
+		display money.Display
+		want    string
+	}{
+		{1990000, money.VND, "1.990.000₫"},
+		{80000, usd, "$3.20"},
+		{1990000000, usd, "$79,600.00"},
+		{-50000, usd, "-$2.00"},
+		{1000000, money.Display{Code: "EUR", Rate: 27500}, "€36.36"},
+		{0, usd, "$0.00"},
+		{80000, money.Display{Code: "USD"}, "80.000₫"},
+	}
+	for _, tt := range tests {
+		if got := utils.FormatPriceIn(tt.price, tt.display); got != tt.want {
+			t.Errorf("FormatPriceIn(%d, %v) = %q, want %q", tt.price, tt.display, got, tt.want)
+		}
+	}
+}
+
+func TestStarIcons(t *testing.T) {
+	tests := []struct {
+		rating float64
+		want   string
+	}{
+		{0, "far far far far far"},
+		{4, "fas fas fas fas far"},
+		{4.3, "fas fas fas fas half"},
+		{4.8, "fas fas fas fas fas"},
+		{1.2, "fas far far far far"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, icon := range utils.StarIcons(tt.rating) {
+			switch icon {
+			case "fas fa-star":
+				got = append(got, "fas")
+			case "fas fa-star-half-alt":
+				got = append(got, "half")
+			default:
+				got = append(got, "far")
+			}
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("StarIcons(%v) = %v, want %s", tt.rating, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shoop-golang/pkg/i18n"
+)
+
+func TestTranslate(t *testing.T) {
+	if got := i18n.Translate("vi", "cart.empty"); got != "Giỏ hàng trống" {
+		t.Errorf("vi cart.empty = %q", got)
+	}
+	if got := i18n.Translate("en", "cart.empty"); got != "Your cart is empty" {
+		t.Errorf("en cart.empty = %q", got)
+	}
+	if got := i18n.Translate("fr", "cart.empty"); got != "Giỏ hàng trống" {
+		t.Errorf("unknown locale should fall back to vi, got %q", got)
+	}
+	if got := i18n.Translate("en", "no.such.key"); got != "no.such.key" {
+		t.Errorf("missing key should pass through, got %q", got)
+	}
+	if got := i18n.Translate("en", "checkout.success", "OCC-2026-000001"); !strings.Contains(got, "OCC-2026-000001") {
+		t.Errorf("args not applied: %q", got)
+	}
+}
+
+func TestTranslateFallsBackToDefaultCatalog(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "vi.json"), []byte(`{"greeting": "Xin chào", "only.vi": "Chỉ có tiếng Việt"}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "en.json"), []byte(`{"greeting": "Hello"}`), 0o644)
+	if err := i18n.Load(dir); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	defer i18n.Load(filepath.Join("..", "..", "pkg", "i18n", "locales"))
+
+	if got := i18n.Translate("en", "greeting"); got != "Hello" {
+		t.Errorf("greeting = %q", got)
+	}
+	if got := i18n.Translate("en", "only.vi"); got != "Chỉ có tiếng Việt" {
+		t.Errorf("missing en key should use vi, got %q", got)
+	}
+	if err := i18n.Load(t.TempDir()); err == nil {
+		t.Error("expected an error for a directory without catalogs")
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "vi"},
+		{"en-US,en;q=0.9", "en"},
+		{"vi-VN,vi;q=0.9,en;q=0.8", "vi"},
+		{"fr-FR,en;q=0.5", "en"},
+		{"ja", "vi"},
+	}
+	for _, tt := range tests {
+		if got := i18n.Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+// Every message must exist in every locale with the same fmt verbs, so a
+// missing translation is caught here rather than shown as Vietnamese.
+func TestCatalogsMatch(t *testing.T) {
+	catalogs := map[string]map[string]string{}
+	for _, locale := range i18n.Locales {
+		b, err := os.ReadFile(filepath.Join("..", "..", "pkg", "i18n", "locales", locale+".json"))
+		if err != nil {
+			t.Fatalf("read %s: %v", locale, err)
+		}
+		messages := map[string]string{}
+		if err := json.Unmarshal(b, &messages); err != nil {
+			t.Fatalf("parse %s: %v", locale, err)
+		}
+		catalogs[locale] = messages
+	}
+	base := catalogs[i18n.Default]
+	for _, locale := range i18n.Locales[1:] {
+		for key, msg := range base {
+			other, ok := catalogs[locale][key]
+			if !ok {
+				t.Errorf("%s: missing %q", locale, key)
+				continue
+			}
+			if verbs(msg) != verbs(other) {
+				t.Errorf("%s: %q has verbs %q, want %q", locale, key, verbs(other), verbs(msg))
+			}
+		}
+		for key := range catalogs[locale] {
+			if _, ok := base[key]; !ok {
+				t.Errorf("%s: %q is not in %s", locale, key, i18n.Default)
+			}
+		}
+	}
+}
+
+func verbs(msg string) string {
+	var out []string
+	for i := 0; i < len(msg)-1; i++ {
+		if msg[i] == '%' {
+			out = append(out, msg[i:i+2])
+			i++
+		}
+	}
+	return strings.Join(out, "")
+}
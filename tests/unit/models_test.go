@@ -1,13 +1,22 @@
 package unit
 
 import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"shoop-golang/internal/models"
+	"shoop-golang/pkg/money"
 	"shoop-golang/tests/testutil"
 
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 func TestBaseModel_BeforeCreate(t *testing.T) {
@@ -55,8 +64,8 @@ func TestProduct_SalePercent(t *testing.T) {
 
 	tests := []struct {
 		name     string
-		original float64
-		sale     float64
+		original money.Money
+		sale     money.Money
 		want     int
 	}{
 		{"original_100k_sale_80k", 100000, 80000, 20},
@@ -76,6 +85,77 @@ func TestProduct_SalePercent(t *testing.T) {
 	}
 }
 
+func TestProduct_Localize(t *testing.T) {
+	p := models.Product{
+		Name:        "Tượng Rồng",
+		Slug:        "tuong-rong",
+		Description: "Mô tả",
+		Category: models.Category{
+			Name:         "Tượng",
+			Translations: []models.CategoryTranslation{{Locale: "en", Name: "Statues"}},
+		},
+		Translations: []models.ProductTranslation{{Locale: "en", Name: "Dragon Statue", Slug: "dragon-statue"}},
+	}
+
+	vi := p
+	vi.Localize("vi")
+	if vi.Name != "Tượng Rồng" || vi.Category.Name != "Tượng" {
+		t.Errorf("default locale should be unchanged, got %q / %q", vi.Name, vi.Category.Name)
+	}
+
+	p.Localize("en")
+	if p.Name != "Dragon Statue" || p.Slug != "dragon-statue" {
+		t.Errorf("expected the English name and slug, got %q %q", p.Name, p.Slug)
+	}
+	if p.Description != "Mô tả" {
+		t.Errorf("an empty translated field should fall back, got %q", p.Description)
+	}
+	if p.Category.Name != "Statues" {
+		t.Errorf("expected the category localized too, got %q", p.Category.Name)
+	}
+}
+
+func TestShippingMethod_Quote(t *testing.T) {
+	m := models.ShippingMethod{
+		Rules: []models.ShippingRule{
+			{Province: "Hà Nội", BaseFee: 30000, IncludedWeight: 1000, PerKgFee: 8000, MaxWeight: 5000},
+			{BaseFee: 35000, IncludedWeight: 1000, PerKgFee: 10000, FreeOver: 2000000},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		province string
+		subtotal money.Money
+		weight   int
+		wantFee  money.Money
+		wantOK   bool
+	}{
+		{"province_rule", "Hà Nội", 500000, 800, 30000, true},
+		{"province_match_ignores_case", "hà nội", 500000, 800, 30000, true},
+		{"extra_kg_rounds_up", "Hà Nội", 500000, 2100, 30000 + 2*8000, true},
+		{"over_max_weight", "Hà Nội", 500000, 6000, 0, false},
+		{"fallback_rule", "Đà Nẵng", 500000, 1000, 35000, true},
+		{"free_over_threshold", "Đà Nẵng", 2000000, 3000, 0, true},
+		{"no_threshold_on_province_rule", "Hà Nội", 5000000, 1000, 30000, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee, ok := m.Quote(tt.province, tt.subtotal, tt.weight)
+			if fee != tt.wantFee || ok != tt.wantOK {
+				t.Errorf("Quote() = (%v, %v), want (%v, %v)", fee, ok, tt.wantFee, tt.wantOK)
+			}
+		})
+	}
+
+	t.Run("no_rule_for_province", func(t *testing.T) {
+		express := models.ShippingMethod{Rules: []models.ShippingRule{{Province: "Hà Nội", BaseFee: 60000}}}
+		if _, ok := express.Quote("Cần Thơ", 100000, 500); ok {
+			t.Error("expected method without a matching rule to be unavailable")
+		}
+	})
+}
+
 func TestCategory_CRUD(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 
@@ -322,6 +402,64 @@ func TestOrder_WithItems(t *testing.T) {
 	}
 }
 
+func TestOrder_Number(t *testing.T) {
+	year := time.Now().Year()
+
+	t.Run("sequential", func(t *testing.T) {
+		db := testutil.SetupTestDB(t)
+		for i := 1; i <= 3; i++ {
+			order := models.Order{UserID: "u", TotalAmount: 1000}
+			if err := db.Create(&order).Error; err != nil {
+				t.Fatalf("create order: %v", err)
+			}
+			if want := fmt.Sprintf("OCC-%d-%06d", year, i); order.Number != want {
+				t.Errorf("expected %s, got %s", want, order.Number)
+			}
+		}
+	})
+
+	t.Run("unique_under_concurrency", func(t *testing.T) {
+		db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "orders.db")+"?_journal_mode=WAL&_busy_timeout=5000"), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		if err != nil {
+			t.Fatalf("open db: %v", err)
+		}
+		if err := db.AutoMigrate(&models.Order{}, &models.Sequence{}); err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+
+		const n = 20
+		var wg sync.WaitGroup
+		errs := make(chan error, n)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- db.Create(&models.Order{UserID: "u", TotalAmount: 1000}).Error
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("concurrent create failed: %v", err)
+			}
+		}
+
+		var numbers []string
+		db.Model(&models.Order{}).Order("number").Pluck("number", &numbers)
+		if len(numbers) != n {
+			t.Fatalf("expected %d orders, got %d", n, len(numbers))
+		}
+		for i, got := range numbers {
+			if want := fmt.Sprintf("OCC-%d-%06d", year, i+1); got != want {
+				t.Errorf("expected %s, got %s", want, got)
+			}
+		}
+	})
+}
+
 func TestBanner_CRUD(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 
@@ -519,3 +657,93 @@ func TestSoftDelete(t *testing.T) {
 		t.Errorf("Unscoped should find soft-deleted record: got ID %q", unscoped.ID)
 	}
 }
+
+func TestCategoryTree(t *testing.T) {
+	parent := func(id string) *string { return &id }
+	categories := []models.Category{
+		{BaseModel: models.BaseModel{ID: "vong"}, Name: "Vòng tay"},
+		{BaseModel: models.BaseModel{ID: "thach-anh"}, Name: "Thạch anh", ParentID: parent("vong")},
+		{BaseModel: models.BaseModel{ID: "tim"}, Name: "Thạch anh tím", ParentID: parent("thach-anh")},
+		{BaseModel: models.BaseModel{ID: "hong"}, Name: "Thạch anh hồng", ParentID: parent("thach-anh")},
+		{BaseModel: models.BaseModel{ID: "tuong"}, Name: "Tượng"},
+		{BaseModel: models.BaseModel{ID: "orphan"}, Name: "Mồ côi", ParentID: parent("hidden")},
+		// A cycle left by bad data must not hang anything.
+		{BaseModel: models.BaseModel{ID: "a"}, Name: "A", ParentID: parent("b")},
+		{BaseModel: models.BaseModel{ID: "b"}, Name: "B", ParentID: parent("a")},
+	}
+
+	tree := models.CategoryTree(categories)
+	var roots []string
+	for _, c := range tree {
+		roots = append(roots, c.ID)
+	}
+	if !reflect.DeepEqual(roots, []string{"vong", "tuong", "orphan"}) {
+		t.Fatalf("roots = %v", roots)
+	}
+	if kids := tree[0].Children; len(kids) != 1 || len(kids[0].Children) != 2 || kids[0].Children[0].ID != "tim" {
+		t.Errorf("unexpected subtree %+v", tree[0].Children)
+	}
+
+	var path []string
+	for _, c := range models.CategoryAncestors(categories, "tim") {
+		path = append(path, c.Name)
+	}
+	if !reflect.DeepEqual(path, []string{"Vòng tay", "Thạch anh"}) {
+		t.Errorf("ancestors = %v", path)
+	}
+	if got := models.CategoryAncestors(categories, "vong"); len(got) != 0 {
+		t.Errorf("expected no ancestors for a root, got %v", got)
+	}
+	if got := models.CategoryAncestors(categories, "a"); len(got) != 1 {
+		t.Errorf("expected the cycle cut short, got %v", got)
+	}
+
+	if got := models.CategoryDescendants(categories, "vong"); !reflect.DeepEqual(got, []string{"vong", "thach-anh", "tim", "hong"}) {
+		t.Errorf("descendants = %v", got)
+	}
+	if got := models.CategoryDescendants(categories, "a"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("descendants in a cycle = %v", got)
+	}
+
+	for id, want := range map[string]int{"vong": 3, "thach-anh": 2, "tim": 1, "tuong": 1} {
+		if got := models.CategoryHeight(categories, id); got != want {
+			t.Errorf("CategoryHeight(%s) = %d, want %d", id, got, want)
+		}
+	}
+	if got := models.CategoryHeight(categories, "a"); got < 1 {
+		t.Errorf("expected a height for a cycle, got %d", got)
+	}
+}
+
+func TestProductSpecs(t *testing.T) {
+	element := models.Attribute{BaseModel: models.BaseModel{ID: "e"}, Name: "Ngũ hành", Type: models.AttributeMulti, SortOrder: 1}
+	size := models.Attribute{BaseModel: models.BaseModel{ID: "s"}, Name: "Đường kính", Type: models.AttributeNumber, Unit: "mm", SortOrder: 2}
+	stone := models.Attribute{BaseModel: models.BaseModel{ID: "d"}, Name: "Loại đá", Type: models.AttributeSelect, SortOrder: 1}
+	value := func(name string, order int) *models.AttributeValue {
+		return &models.AttributeValue{Name: name, SortOrder: order}
+	}
+	rows := []models.ProductAttribute{
+		{AttributeID: "s", Attribute: size, Text: "8"},
+		{AttributeID: "e", Attribute: element, Value: value("Thủy", 2)},
+		{AttributeID: "d", Attribute: stone, Value: value("Thạch anh", 0)},
+		{AttributeID: "e", Attribute: element, Value: value("Kim", 0)},
+		{AttributeID: "s", Attribute: size},
+	}
+
+	var got []string
+	for _, spec := range models.ProductSpecs(rows) {
+		got = append(got, spec.Attribute.Name+": "+strings.Join(spec.Values, ", "))
+	}
+	want := []string{"Loại đá: Thạch anh", "Ngũ hành: Kim, Thủy", "Đường kính: 8 mm"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("specs = %v, want %v", got, want)
+	}
+
+	if !element.AppliesTo(nil) {
+		t.Error("expected an attribute without categories to apply everywhere")
+	}
+	stone.Categories = []models.Category{{BaseModel: models.BaseModel{ID: "vong"}}}
+	if !stone.AppliesTo([]string{"vong", "thach-anh"}) || stone.AppliesTo([]string{"tuong"}) {
+		t.Error("expected the attribute to apply under its category only")
+	}
+}
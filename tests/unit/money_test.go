@@ -0,0 +1,146 @@
+package unit
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/money"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMoneyParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want money.Money
+	}{
+		{"", 0},
+		{"1990000", 1990000},
+		{"1.990.000", 1990000},
+		{"1,990,000", 1990000},
+		{" 1.990.000₫ ", 1990000},
+		{"80000.6", 80001},
+		{"1.5", 2},
+	}
+	for _, tt := range tests {
+		got, err := money.Parse(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := money.Parse("abc"); err == nil {
+		t.Error("expected an error for non-numeric input")
+	}
+}
+
+func TestMoneyDecode(t *testing.T) {
+	var item models.CartItem
+	if err := json.Unmarshal([]byte(`{"price": 80000.4, "quantity": 2}`), &item); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if item.Price != 80000 || item.Price.Mul(item.Quantity) != 160000 {
+		t.Errorf("unexpected price %d", item.Price)
+	}
+	out, _ := json.Marshal(item)
+	if want := `"price":80000`; !strings.Contains(string(out), want) {
+		t.Errorf("expected %s in %s", want, out)
+	}
+
+	var m money.Money
+	scans := []struct {
+		src  any
+		want money.Money
+	}{
+		{int64(5), 5},
+		{99.5, 100},
+		{[]byte("120000"), 120000},
+		{nil, 0},
+	}
+	for _, tt := range scans {
+		if err := m.Scan(tt.src); err != nil || m != tt.want {
+			t.Errorf("Scan(%v) = %d, %v; want %d", tt.src, m, err, tt.want)
+		}
+	}
+}
+
+// TestMoneyMigration opens a database created while amounts were REAL
+// columns and checks they come back as whole đồng.
+func TestMoneyMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	legacy, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	legacy.Exec("CREATE TABLE products (id text PRIMARY KEY, created_at datetime, updated_at datetime, deleted_at datetime, " +
+		"name text NOT NULL, slug text NOT NULL, original_price real NOT NULL, sale_price real, stock integer DEFAULT 0)")
+	legacy.Exec("INSERT INTO products (id, name, slug, original_price, sale_price) VALUES ('p1', 'Tượng', 'tuong', 120000.0, 99999.6)")
+	sqlDB, _ := legacy.DB()
+	sqlDB.Close()
+
+	db := database.Init(path)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	var p models.Product
+	if err := db.First(&p, "id = ?", "p1").Error; err != nil {
+		t.Fatalf("load product: %v", err)
+	}
+	if p.OriginalPrice != 120000 || p.SalePrice != 100000 {
+		t.Errorf("expected rounded prices, got %d / %d", p.OriginalPrice, p.SalePrice)
+	}
+	var kind string
+	db.Raw("SELECT typeof(sale_price) FROM products WHERE id = 'p1'").Scan(&kind)
+	if kind != "integer" {
+		t.Errorf("expected sale_price stored as integer, got %s", kind)
+	}
+}
+
+// TestSubtotalMigration checks that orders from before shipping fees get
+// their total as subtotal once, and that later orders are left alone.
+func TestSubtotalMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	legacy, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	legacy.Exec("CREATE TABLE orders (id text PRIMARY KEY, created_at datetime, updated_at datetime, deleted_at datetime, " +
+		"user_id text NOT NULL, status text DEFAULT 'pending', total_amount integer NOT NULL)")
+	legacy.Exec("INSERT INTO orders (id, created_at, user_id, total_amount) VALUES ('o1', '2026-01-02 03:04:05', 'u1', 250000)")
+	sqlDB, _ := legacy.DB()
+	sqlDB.Close()
+
+	reopen := func() *gorm.DB {
+		db := database.Init(path)
+		t.Cleanup(func() {
+			if sqlDB, err := db.DB(); err == nil {
+				sqlDB.Close()
+			}
+		})
+		return db
+	}
+	subtotal := func(db *gorm.DB, id string) money.Money {
+		var o models.Order
+		if err := db.First(&o, "id = ?", id).Error; err != nil {
+			t.Fatalf("load order %s: %v", id, err)
+		}
+		return o.Subtotal
+	}
+	db := reopen()
+	if got := subtotal(db, "o1"); got != 250000 {
+		t.Fatalf("expected the legacy order's subtotal backfilled, got %d", got)
+	}
+
+	db.Exec("INSERT INTO orders (id, created_at, user_id, subtotal, total_amount) VALUES ('o2', '2026-02-03 04:05:06', 'u1', 0, 30000)")
+	db = reopen()
+	if got := subtotal(db, "o2"); got != 0 {
+		t.Errorf("expected a later order left alone after a restart, got subtotal %d", got)
+	}
+}
@@ -0,0 +1,121 @@
+package unit
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"shoop-golang/database"
+	"shoop-golang/internal/models"
+	"shoop-golang/internal/notifications"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestNotificationChanges(t *testing.T) {
+	base := models.Product{OriginalPrice: 100000, SalePrice: 80000, Stock: 5, IsActive: true}
+	with := func(f func(p *models.Product)) models.Product {
+		p := base
+		f(&p)
+		return p
+	}
+
+	tests := []struct {
+		name          string
+		before, after models.Product
+		want          []string
+	}{
+		{"unchanged", base, base, nil},
+		{"sale price lowered", base, with(func(p *models.Product) { p.SalePrice = 70000 }), []string{models.NotifyPriceDrop}},
+		{"sale removed", base, with(func(p *models.Product) { p.SalePrice = 0 }), nil},
+		{"sale started", with(func(p *models.Product) { p.SalePrice = 0 }), base, []string{models.NotifyPriceDrop}},
+		{"price raised", base, with(func(p *models.Product) { p.SalePrice = 90000 }), nil},
+		{"restocked", with(func(p *models.Product) { p.Stock = 0 }), base, []string{models.NotifyRestock}},
+		{"stock topped up", base, with(func(p *models.Product) { p.Stock = 50 }), nil},
+		{"restocked on sale", with(func(p *models.Product) { p.Stock = 0 }), with(func(p *models.Product) { p.SalePrice = 60000 }), []string{models.NotifyRestock, models.NotifyPriceDrop}},
+		{"hidden", with(func(p *models.Product) { p.Stock = 0 }), with(func(p *models.Product) { p.IsActive = false }), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := notifications.Changes(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Changes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotificationCompose(t *testing.T) {
+	n := models.Notification{
+		Email:   "khach@example.com",
+		Locale:  "vi",
+		Kind:    models.NotifyPriceDrop,
+		Product: models.Product{Name: "Tỳ Hưu Ngọc", Slug: "ty-huu-ngoc", OriginalPrice: 100000, SalePrice: 75000},
+	}
+	m := notifications.Compose(n, "https://shop.test")
+	if m.To != n.Email || !strings.Contains(m.Subject, "Tỳ Hưu Ngọc") {
+		t.Errorf("unexpected message %+v", m)
+	}
+	for _, want := range []string{"75.000", "https://shop.test/products/ty-huu-ngoc", "https://shop.test/account/wishlist"} {
+		if !strings.Contains(m.Body, want) {
+			t.Errorf("expected %q in the body, got %q", want, m.Body)
+		}
+	}
+
+	n.Kind = models.NotifyRestock
+	n.Locale = "en"
+	n.UnsubscribeToken = "tok"
+	m = notifications.Compose(n, "https://shop.test")
+	if !strings.Contains(m.Body, "https://shop.test/en/notify/unsubscribe/tok") || strings.Contains(m.Body, "/account/wishlist") {
+		t.Errorf("expected an unsubscribe link instead of the wishlist, got %q", m.Body)
+	}
+
+	n.Kind = models.NotifyConfirm
+	m = notifications.Compose(n, "https://shop.test")
+	if !strings.Contains(m.Subject, "Confirm") || !strings.Contains(m.Body, "https://shop.test/en/notify/confirm/tok") ||
+		strings.Contains(m.Body, "/notify/unsubscribe/") {
+		t.Errorf("expected only a confirm link, got %q", m.Body)
+	}
+}
+
+// TestSubscriptionConfirmMigration checks that subscriptions made before
+// guests had to confirm keep working, and that the backfill runs once.
+func TestSubscriptionConfirmMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	legacy, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	legacy.Exec("CREATE TABLE stock_subscriptions (id text PRIMARY KEY, created_at datetime, updated_at datetime, deleted_at datetime, " +
+		"product_id text NOT NULL, user_id text, email text NOT NULL, locale text, token text NOT NULL, notified_at datetime)")
+	legacy.Exec("INSERT INTO stock_subscriptions (id, created_at, product_id, email, token) VALUES ('s1', '2026-01-02 03:04:05', 'p1', 'khach@example.com', 'tok')")
+	sqlDB, _ := legacy.DB()
+	sqlDB.Close()
+
+	reopen := func() *gorm.DB {
+		db := database.Init(path)
+		t.Cleanup(func() {
+			if sqlDB, err := db.DB(); err == nil {
+				sqlDB.Close()
+			}
+		})
+		return db
+	}
+	db := reopen()
+	var sub models.StockSubscription
+	db.First(&sub, "id = ?", "s1")
+	if sub.ConfirmedAt == nil {
+		t.Fatal("expected the existing subscription confirmed")
+	}
+
+	if err := db.Create(&models.StockSubscription{ProductID: "p1", Email: "moi@example.com", Token: "tok2"}).Error; err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	db = reopen()
+	var fresh models.StockSubscription
+	db.First(&fresh, "token = ?", "tok2")
+	if fresh.ID == "" || fresh.ConfirmedAt != nil {
+		t.Error("expected a new guest subscription to stay unconfirmed after a restart")
+	}
+}
@@ -0,0 +1,79 @@
+package unit
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"shoop-golang/pkg/payments"
+)
+
+func TestVietQRPayload(t *testing.T) {
+	got := payments.VietQRPayload("970436", "0011001234567", 250000, "OCC-2026-000001")
+	want := "00020101021238570010A00000072701270006970436011300110012345670208QRIBFTTA" +
+		"530370454062500005802VN62190815OCC-2026-0000016304A9A5"
+	if got != want {
+		t.Errorf("unexpected payload\n got %s\nwant %s", got, want)
+	}
+
+	t.Run("open amount and memo without accents", func(t *testing.T) {
+		p := payments.VietQRPayload("970436", "123", 0, "Đơn #42")
+		if !strings.Contains(p, "62080804n 42") {
+			t.Errorf("expected sanitized memo in %s", p)
+		}
+		if strings.Contains(p, "530370454") {
+			t.Errorf("expected no amount field in %s", p)
+		}
+	})
+}
+
+func TestVietQR_Callback(t *testing.T) {
+	v := payments.VietQR{BankBIN: "970436", AccountNumber: "123", WebhookSecret: "s3cret", OrderPrefix: "OCC"}
+	body := []byte(`{"id": 9001, "amount": 250000, "description": "MBVCB.123 OCC2026000001 chuyen tien"}`)
+
+	header := http.Header{}
+	if err := v.VerifySignature(body, header); err != payments.ErrInvalidSignature {
+		t.Errorf("expected unsigned body to be rejected, got %v", err)
+	}
+	header.Set(payments.SignatureHeader, payments.Sign("s3cret", body))
+	if err := v.VerifySignature(body, header); err != nil {
+		t.Errorf("expected signature to verify, got %v", err)
+	}
+	if err := v.VerifySignature(append(body, ' '), header); err == nil {
+		t.Error("expected tampered body to be rejected")
+	}
+
+	e, err := v.HandleCallback(body)
+	if err != nil {
+		t.Fatalf("callback failed: %v", err)
+	}
+	if e.TransactionID != "9001" || e.Reference != "OCC-2026-000001" || e.Amount != 250000 || e.Status != payments.StatusSucceeded {
+		t.Errorf("unexpected event %+v", e)
+	}
+
+	if _, err := v.HandleCallback([]byte(`{"amount": 1}`)); err == nil {
+		t.Error("expected notification without id to fail")
+	}
+}
+
+func TestPaymentsRegistry(t *testing.T) {
+	payments.Reset()
+	defer payments.Reset()
+
+	payments.RegisterDefaults(payments.VietQR{})
+	if all := payments.All(); len(all) != 1 || all[0].Code() != "cod" {
+		t.Fatalf("expected only COD without a bank account, got %d providers", len(all))
+	}
+
+	payments.RegisterDefaults(payments.VietQR{BankBIN: "970436", AccountNumber: "123"})
+	if all := payments.All(); len(all) != 2 || all[1].Code() != "vietqr" {
+		t.Fatalf("expected COD and VietQR, got %d providers", len(all))
+	}
+
+	fake := payments.NewFake("x")
+	payments.Register(fake)
+	payments.Register(payments.NewFake("y"))
+	if p, ok := payments.Get("fake"); !ok || p == payments.Provider(fake) || len(payments.All()) != 3 {
+		t.Error("expected re-registering a code to replace the provider")
+	}
+}
@@ -0,0 +1,96 @@
+package unit
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"shoop-golang/pkg/pdf"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+func TestPDFEmbedsVietnameseFont(t *testing.T) {
+	doc := pdf.New()
+	p := doc.AddPage()
+	text := "HÓA ĐƠN – Tổng cộng: 1.990.000₫"
+	p.Text(40, 60, 16, true, text)
+	p.Text(40, 80, 10, false, text)
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	out := buf.String()
+
+	// Every cross-reference entry points at its object.
+	xref := regexp.MustCompile(`startxref\n(\d+)`).FindStringSubmatch(out)
+	at, _ := strconv.Atoi(xref[1])
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllStringSubmatch(out[at:], -1)
+	for i, e := range entries {
+		off, _ := strconv.Atoi(e[1])
+		if !strings.HasPrefix(out[off:], strconv.Itoa(i+1)+" 0 obj") {
+			t.Fatalf("xref entry %d does not point at its object", i+1)
+		}
+	}
+
+	if strings.Contains(out, "/Helvetica") {
+		t.Error("did not expect the non-Unicode standard fonts")
+	}
+	// The ToUnicode maps keep the text searchable.
+	for _, want := range []string{"<01A0>", "<20AB>", "/Identity-H", "/FontFile2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in the document", want)
+		}
+	}
+	if strings.Contains(out, "<1EC7>") {
+		t.Error("did not expect glyphs the text does not use")
+	}
+
+	programs := regexp.MustCompile(`/Length (\d+) /Length1 (\d+) /Filter /FlateDecode >>\nstream\n`).FindAllStringSubmatchIndex(out, -1)
+	if len(programs) != 2 {
+		t.Fatalf("expected a regular and a bold font program, got %d", len(programs))
+	}
+	for _, m := range programs {
+		n, _ := strconv.Atoi(out[m[2]:m[3]])
+		zr, err := zlib.NewReader(strings.NewReader(out[m[1] : m[1]+n]))
+		if err != nil {
+			t.Fatalf("font program is not deflated: %v", err)
+		}
+		ttf, _ := io.ReadAll(zr)
+		if len(ttf) > 60000 {
+			t.Errorf("expected a font subset, got %d bytes", len(ttf))
+		}
+		var sum uint32
+		for i := 0; i+4 <= len(ttf); i += 4 {
+			sum += binary.BigEndian.Uint32(ttf[i:])
+		}
+		if sum != 0xB1B0AFBA {
+			t.Errorf("font checksum adjustment is wrong: %08x", sum)
+		}
+
+		f, err := sfnt.Parse(ttf)
+		if err != nil {
+			t.Fatalf("font subset does not parse: %v", err)
+		}
+		var b sfnt.Buffer
+		for r, kept := range map[rune]bool{'Đ': true, 'Ơ': true, 'Z': false} {
+			id, _ := f.GlyphIndex(&b, r)
+			segs, err := f.LoadGlyph(&b, id, 1000, nil)
+			if err != nil {
+				t.Fatalf("glyph %q does not load: %v", r, err)
+			}
+			if (len(segs) > 0) != kept {
+				t.Errorf("glyph %q kept = %v, want %v", r, len(segs) > 0, kept)
+			}
+		}
+	}
+
+	if w := pdf.TextWidth("Ệ", 10, false); w < 5 || w > 8 {
+		t.Errorf("expected the width of a capital letter, got %.2f", w)
+	}
+}
@@ -0,0 +1,53 @@
+package unit
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"shoop-golang/internal/models"
+	"shoop-golang/pkg/money"
+	"shoop-golang/pkg/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestRendererCurrencyPerRequest(t *testing.T) {
+	r := utils.NewWebRenderer("../../templates")
+	e := echo.New()
+	items := []models.Wishlist{{Product: models.Product{Name: "Vòng tay", Slug: "vong-tay", OriginalPrice: 100000, Stock: 1}}}
+
+	render := func(d money.Display) string {
+		c := e.NewContext(httptest.NewRequest("GET", "/account/wishlist", nil), httptest.NewRecorder())
+		var buf bytes.Buffer
+		if err := r.Render(&buf, "web/account/wishlist", map[string]any{"Currency": d, "Currencies": []money.Display{d}, "Items": items}, c); err != nil {
+			t.Errorf("render failed: %v", err)
+		}
+		return buf.String()
+	}
+
+	// Requests rendered at the same time each show their own rate.
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if body := render(money.Display{Code: "USD", Rate: 25000}); !strings.Contains(body, "$4.00") {
+				t.Error("expected the price at 25.000₫ to the dollar")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if body := render(money.Display{Code: "USD", Rate: 20000}); !strings.Contains(body, "$5.00") {
+				t.Error("expected the price at 20.000₫ to the dollar")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if body := render(money.Display{Code: money.Currency, Rate: 1}); !strings.Contains(body, "100.000₫") {
+		t.Error("expected the price in VND")
+	}
+}